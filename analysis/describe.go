@@ -54,23 +54,156 @@ func (a *Analysis) Describe(pos esc.Pos) (string, bool) {
 
 func (a *Analysis) describeBuiltin(builtin *esc.BuiltinExpr) (string, bool) {
 	switch builtin.Name {
+	case "fn::add":
+		return "Returns the sum of its two numeric arguments.", true
+	case "fn::and":
+		return "Returns true if all of its boolean arguments are true. Stops evaluating at the first false argument.", true
+	case "fn::atPath":
+		return "Looks up a value by a list of property names and array indices. Returns null if the path does not " +
+			"exist, unless `strict` is true.", true
+	case "fn::camelCase":
+		return "Converts an identifier to camelCase. Words are delimited by any character that is not a letter or " +
+			"digit and by changes in case.", true
+	case "fn::capitalize":
+		return "Converts the first character of a string to title case. The rest of the string is unchanged.", true
+	case "fn::clamp":
+		return "Bounds a number to the range [`min`, `max`]. `min` must not be greater than `max`.", true
+	case "fn::difference":
+		return "Returns the distinct elements of the first list that are not present in any of the other lists.", true
+	case "fn::div":
+		return "Returns the quotient of its two numeric arguments. The divisor must not be zero.", true
+	case "fn::encodeQuery":
+		return "Encodes an object of strings or lists of strings as a URL query string. Keys are sorted.", true
+	case "fn::equals":
+		return "Returns true if its two arguments are structurally equal. Numbers are compared by value.", true
 	case "fn::fromJSON":
 		return "Decodes a value from its JSON representation.", true
+	case "fn::filter":
+		return "Returns the elements of a list for which a boolean predicate is true. Within the predicate, the " +
+			"current element is available as `${item}` (or the name given by `as`).", true
+	case "fn::formatTime":
+		return "Parses a timestamp using the named `inputLayout` and formats it using the named `outputLayout`. " +
+			"Layouts are named after the constants in Go's time package, e.g. `RFC3339` or `DateTime`.", true
 	case "fn::fromBase64":
 		return "Decodes a string from its Base64 representation.", true
+	case "fn::fromBase64URL":
+		return "Decodes a string from its URL-safe Base64 representation. Padding is optional.", true
+	case "fn::fromHex":
+		return "Decodes a string from its hexadecimal representation.", true
+	case "fn::getOr":
+		return "Returns the value at a property path within a value, or a default if the path is missing or null.", true
+	case "fn::groupBy":
+		return "Groups a list of objects into an object of lists by the value of the property named by `key`. " +
+			"Elements without the property are grouped under the empty string, unless `strict` is true.", true
+	case "fn::hmac":
+		return "Computes the HMAC of a message using a secret key. The result is secret.", true
+	case "fn::import":
+		return "Returns the value at a property path within another environment.", true
+	case "fn::importRaw":
+		return "Returns the value at a property path within the environment's imports, ignoring any value defined " +
+			"by the environment itself.", true
+	case "fn::intersection":
+		return "Returns the distinct elements of the first list that are present in every other list.", true
+	case "fn::isEmpty":
+		return "Returns true if its argument is null, an empty string, an empty array, or an empty object. Numbers " +
+			"and booleans are never empty.", true
 	case "fn::join":
 		return "Concatenates the elements of its second argument to create a single string. The first argument is " +
 			"placed between each element in the result.", true
+	case "fn::jwtDecode":
+		return "Decodes the claims of a JSON Web Token into an object. The token's signature is not verified.", true
+	case "fn::kebabCase":
+		return "Converts an identifier to kebab-case. Words are delimited by any character that is not a letter or " +
+			"digit and by changes in case.", true
+	case "fn::let":
+		return "Binds names to values within an expression. Each binding is only evaluated if it is used.", true
+	case "fn::map":
+		return "Evaluates a template once for each element of a list and returns the list of results. Within the " +
+			"template, the current element is available as `${item}` (or the name given by `as`).", true
+	case "fn::mask":
+		return "Replaces all but the last `visible` characters of a string with `*`. The result is secret.", true
+	case "fn::mul":
+		return "Returns the product of its two numeric arguments.", true
+	case "fn::nearest":
+		return "Returns the element of `options` that is closest to `value` by edit distance.", true
+	case "fn::not":
+		return "Returns the logical negation of its boolean argument.", true
+	case "fn::objectDiff":
+		return "Describes the properties that were added, removed, or changed between two objects. Changes to " +
+			"nested objects are described recursively.", true
+	case "fn::objectFromKeys":
+		return "Builds an object from a list of keys. Each key maps to a copy of `value`, or to the element of " +
+			"`values` at the same index.", true
 	case "fn::open":
 		return "Fetches values from an external source when the environment is opened.", true
+	case "fn::or":
+		return "Returns true if any of its boolean arguments are true. Stops evaluating at the first true argument.", true
+	case "fn::padLeft":
+		return "Pads the start of a string with a single character until it reaches a target length.", true
+	case "fn::padRight":
+		return "Pads the end of a string with a single character until it reaches a target length.", true
+	case "fn::parseArn":
+		return "Parses an Amazon Resource Name (ARN) into its partition, service, region, account ID, and resource.", true
+	case "fn::parseDuration":
+		return "Parses a duration string such as `1h30m` and returns its length in seconds (or milliseconds).", true
+	case "fn::parseSize":
+		return "Parses a size such as `10Mi` or `2GB` and returns the number of bytes. Units with an `i` are " +
+			"binary (powers of 1024); all other units are decimal (powers of 1000).", true
+	case "fn::randomString":
+		return "Generates a random string of the given length. The result is secret.", true
+	case "fn::reduce":
+		return "Folds a list into a single value by evaluating a reducer once for each element. Within the reducer, " +
+			"the accumulated value is available as `${acc}` and the current element as `${item}` (or the name given " +
+			"by `as`).", true
+	case "fn::repeat":
+		return "Returns an array that contains `count` copies of `value`.", true
+	case "fn::require":
+		return "Returns `value` unchanged if it is not null. Otherwise, reports `message` as an error.", true
+	case "fn::sample":
+		return "Returns an element of a list chosen at random.", true
 	case "fn::secret":
 		return "Marks a value as secret.", true
+	case "fn::secretIf":
+		return "Marks a value as secret if a condition is true.", true
+	case "fn::semverCompare":
+		return "Compares two semantic versions. Returns -1, 0, or 1 if the first version precedes, equals, or follows the second.", true
+	case "fn::snakeCase":
+		return "Converts an identifier to snake_case. Words are delimited by any character that is not a letter or " +
+			"digit and by changes in case.", true
+	case "fn::sub":
+		return "Returns the difference of its two numeric arguments.", true
+	case "fn::switch":
+		return "Returns the result of the first case whose `when` equals the value, or the default if no case matches. " +
+			"Only the selected result is evaluated.", true
+	case "fn::title":
+		return "Converts each word of a string to title case. Casing is language-insensitive.", true
+	case "fn::toArray":
+		return "Wraps a value that is not a list in a single-element list. Lists are returned unchanged, and null " +
+			"is converted to an empty list.", true
 	case "fn::toBase64":
 		return "Encodes a string into its Base64 representation.", true
+	case "fn::toBase64URL":
+		return "Encodes a string into its unpadded URL-safe Base64 representation.", true
+	case "fn::toHex":
+		return "Encodes a string into its lowercase hexadecimal representation.", true
 	case "fn::toJSON":
 		return "Encodes a value into its JSON representation.", true
 	case "fn::toString":
 		return "Encodes a value into its string representation.", true
+	case "fn::typeOf":
+		return "Returns the name of the JSON type of its argument: one of \"null\", \"boolean\", \"number\", " +
+			"\"string\", \"array\", or \"object\".", true
+	case "fn::union":
+		return "Returns the distinct elements of all of the given lists.", true
+	case "fn::urlDecode":
+		return "Decodes a percent-encoded URL query component (or path segment).", true
+	case "fn::urlEncode":
+		return "Percent-encodes a string for use as a URL query component (or path segment).", true
+	case "fn::urlJoin":
+		return "Joins a base URL with a list of path segments, ensuring that exactly one slash separates each segment.", true
+	case "fn::zip":
+		return "Pairs the elements of a list of arrays by index. Stops at the shortest array unless `pad` is true, in " +
+			"which case missing elements are null.", true
 	default:
 		if strings.HasPrefix(builtin.Name, "fn::open::") {
 			return "Fetches values from an external source when the environment is opened.", true
@@ -68,7 +68,8 @@ func expressionAtPos(root esc.Expr, where []traverser, pos esc.Pos) (*esc.Expr,
 			}
 		}
 	case len(root.Object) != 0:
-		for key, property := range root.Object {
+		for _, key := range root.ObjectKeys() {
+			property := root.Object[key]
 			here := append(where, newObjectTraverser(root, key))
 			if x, where, ok := expressionAtPos(property, here, pos); ok {
 				return x, where, true
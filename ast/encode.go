@@ -0,0 +1,231 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ast
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pulumi/esc/syntax"
+	"github.com/pulumi/esc/syntax/encoding"
+	"gopkg.in/yaml.v3"
+)
+
+// EncodeEnvironment serializes an environment declaration to YAML. If the declaration was parsed from source, the
+// output preserves the order of imports and properties, the form of each builtin call, and the comments and styles of
+// the original source where possible. Parsing the output yields an equivalent declaration.
+func EncodeEnvironment(env *EnvironmentDecl) ([]byte, syntax.Diagnostics) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+
+	diags := encoding.EncodeYAML(enc, MarshalEnvironment(env))
+	if err := enc.Close(); err != nil {
+		diags.Extend(syntax.Error(nil, err.Error(), ""))
+	}
+	return b.Bytes(), diags
+}
+
+// MarshalEnvironment converts an environment declaration into a syntax tree. See EncodeEnvironment for details.
+func MarshalEnvironment(env *EnvironmentDecl) syntax.Node {
+	var imports, parameters, values syntax.Node
+	if env.Imports != nil {
+		elements := make([]syntax.Node, len(env.Imports.Elements))
+		for i, imp := range env.Imports.Elements {
+			elements[i] = marshalImport(imp)
+		}
+		imports = syntax.ArraySyntax(nodeSyntax(env.Imports.Syntax()), elements...)
+	}
+	if env.Parameters != nil {
+		entries := make([]syntax.ObjectPropertyDef, len(env.Parameters.Entries))
+		for i, entry := range env.Parameters.Entries {
+			entries[i] = syntax.ObjectPropertySyntax(entry.syntax.Syntax, stringNode(entry.Key), marshalParameter(entry.Value))
+		}
+		parameters = syntax.ObjectSyntax(nodeSyntax(env.Parameters.Syntax()), entries...)
+	}
+	if env.Values != nil {
+		entries := make([]syntax.ObjectPropertyDef, len(env.Values.Entries))
+		for i, entry := range env.Values.Entries {
+			entries[i] = syntax.ObjectPropertySyntax(entry.syntax.Syntax, stringNode(entry.Key), MarshalExpr(entry.Value))
+		}
+		values = syntax.ObjectSyntax(nodeSyntax(env.Values.Syntax()), entries...)
+	}
+
+	return marshalRecord(env.Syntax(), []recordField{
+		{"description", exprSyntax(env.Description)},
+		{"imports", imports},
+		{"parameters", parameters},
+		{"values", values},
+		{"secret", exprSyntax(env.Secret)},
+	})
+}
+
+// marshalImport converts an import declaration into a syntax node.
+func marshalImport(imp *ImportDecl) syntax.Node {
+	if imp.Meta == nil {
+		if imp.Environment == nil {
+			return imp.Syntax()
+		}
+		return stringNode(imp.Environment)
+	}
+
+	var only syntax.Node
+	if imp.Meta.Only != nil {
+		elements := make([]syntax.Node, len(imp.Meta.Only.Elements))
+		for i, k := range imp.Meta.Only.Elements {
+			elements[i] = exprSyntax(k)
+		}
+		only = syntax.ArraySyntax(nodeSyntax(imp.Meta.Only.Syntax()), elements...)
+	}
+
+	meta := marshalRecord(imp.Meta.Syntax(), []recordField{
+		{"merge", exprSyntax(imp.Meta.Merge)},
+		{"as", exprSyntax(imp.Meta.As)},
+		{"only", only},
+		{"if", MarshalExpr(imp.Meta.If)},
+	})
+
+	var propSyntax syntax.Syntax
+	if obj, ok := imp.Syntax().(*syntax.ObjectNode); ok && obj.Len() == 1 {
+		propSyntax = obj.Index(0).Syntax
+	}
+	prop := syntax.ObjectPropertySyntax(propSyntax, stringNode(imp.Environment), meta)
+	return syntax.ObjectSyntax(nodeSyntax(imp.Syntax()), prop)
+}
+
+// marshalParameter converts a parameter declaration into a syntax node.
+func marshalParameter(param *ParameterDecl) syntax.Node {
+	if param == nil {
+		return syntax.Null()
+	}
+	return marshalRecord(param.Syntax(), []recordField{
+		{"schema", MarshalExpr(param.Schema)},
+		{"default", MarshalExpr(param.Default)},
+	})
+}
+
+// A recordField is a field of a record declaration.
+type recordField struct {
+	name  string
+	value syntax.Node
+}
+
+// marshalRecord converts the fields of a record declaration into an object node. If the record was parsed from an
+// object node, fields are emitted in their original order, and any keys that do not correspond to fields are retained.
+// Other fields are emitted in the given order. Fields with nil values are omitted.
+func marshalRecord(original syntax.Node, fields []recordField) *syntax.ObjectNode {
+	var entries []syntax.ObjectPropertyDef
+	emitted := make([]bool, len(fields))
+
+	obj, _ := original.(*syntax.ObjectNode)
+	if obj != nil {
+		for i := 0; i < obj.Len(); i++ {
+			kvp := obj.Index(i)
+
+			matched := false
+			for j, f := range fields {
+				if strings.EqualFold(f.name, kvp.Key.Value()) && !emitted[j] {
+					if f.value != nil {
+						entries = append(entries, syntax.ObjectPropertySyntax(kvp.Syntax, kvp.Key, f.value))
+					}
+					emitted[j], matched = true, true
+					break
+				}
+			}
+			if !matched {
+				entries = append(entries, kvp)
+			}
+		}
+	}
+
+	for i, f := range fields {
+		if !emitted[i] && f.value != nil {
+			entries = append(entries, syntax.ObjectProperty(syntax.String(f.name), f.value))
+		}
+	}
+
+	var s syntax.Syntax = syntax.NoSyntax
+	if obj != nil {
+		s = obj.Syntax()
+	}
+	return syntax.ObjectSyntax(s, entries...)
+}
+
+// MarshalExpr converts an expression into a syntax tree. Literals, symbols, and interpolations are represented by their
+// original syntax nodes. Lists, objects, and builtin calls are rebuilt from their components so that modifications to
+// the expression tree are reflected in the result.
+func MarshalExpr(x Expr) syntax.Node {
+	if x == nil {
+		return nil
+	}
+
+	switch x := x.(type) {
+	case *ArrayExpr:
+		elements := make([]syntax.Node, len(x.Elements))
+		for i, e := range x.Elements {
+			elements[i] = MarshalExpr(e)
+		}
+		return syntax.ArraySyntax(nodeSyntax(x.Syntax()), elements...)
+	case *ObjectExpr:
+		entries := make([]syntax.ObjectPropertyDef, len(x.Entries))
+		for i, entry := range x.Entries {
+			key := entry.syntax.Key
+			if entry.Key != nil {
+				key = stringNode(entry.Key)
+			}
+			entries[i] = syntax.ObjectPropertySyntax(entry.syntax.Syntax, key, MarshalExpr(entry.Value))
+		}
+		return syntax.ObjectSyntax(nodeSyntax(x.Syntax()), entries...)
+	case BuiltinExpr:
+		// Builtins with invalid arguments (e.g. a short-form fn::open with no inputs) are retained as written.
+		if x.Name() == nil || x.Args() == nil {
+			return x.Syntax()
+		}
+
+		var propSyntax syntax.Syntax
+		if obj, ok := x.Syntax().(*syntax.ObjectNode); ok && obj.Len() == 1 {
+			propSyntax = obj.Index(0).Syntax
+		}
+		prop := syntax.ObjectPropertySyntax(propSyntax, stringNode(x.Name()), MarshalExpr(x.Args()))
+		return syntax.ObjectSyntax(nodeSyntax(x.Syntax()), prop)
+	default:
+		return x.Syntax()
+	}
+}
+
+// exprSyntax returns the syntax node for a literal expression, or nil if the expression is nil.
+func exprSyntax[T Expr](x T) syntax.Node {
+	var zero T
+	if Expr(x) == Expr(zero) {
+		return nil
+	}
+	return MarshalExpr(x)
+}
+
+// stringNode returns the string node for a string literal, synthesizing one if necessary.
+func stringNode(x *StringExpr) *syntax.StringNode {
+	if n, ok := x.Syntax().(*syntax.StringNode); ok {
+		return n
+	}
+	return syntax.String(x.Value)
+}
+
+// nodeSyntax returns the syntax associated with a node, if any.
+func nodeSyntax(n syntax.Node) syntax.Syntax {
+	if n == nil {
+		return syntax.NoSyntax
+	}
+	return n.Syntax()
+}
@@ -147,6 +147,18 @@ type ImportMetaDecl struct {
 	declNode
 
 	Merge *BooleanExpr
+
+	// As is an optional alias for the import. An aliased import's values are available in the importing environment
+	// under the alias (e.g. `${prod.aws.region}`). Aliased imports are not merged unless Merge is explicitly true.
+	As *StringExpr
+
+	// Only optionally restricts the import to the listed top-level keys of the imported environment.
+	Only *ArrayDecl[*StringExpr]
+
+	// If is an optional condition that guards the import. The import is skipped unless the condition evaluates to
+	// true. Because imports are evaluated before the importing environment's values, the condition may only refer to
+	// the evaluation context and to previously-listed imports.
+	If Expr
 }
 
 func (d *ImportMetaDecl) recordSyntax() *syntax.Node {
@@ -181,7 +193,24 @@ func (d *ImportDecl) parse(name string, node syntax.Node) syntax.Diagnostics {
 	}
 }
 
+// ParameterDecl declares an input parameter of an environment. The value of a parameter is supplied by the caller at
+// evaluation time, and is available to the environment's values as `${parameters.<name>}`.
+type ParameterDecl struct {
+	declNode
+
+	// Schema is an optional JSON schema for the parameter's value. Supplied values are validated against the schema.
+	Schema Expr
+
+	// Default is an optional default value for the parameter. A parameter without a default is required.
+	Default Expr
+}
+
+func (d *ParameterDecl) recordSyntax() *syntax.Node {
+	return &d.syntax
+}
+
 type ImportListDecl = *ArrayDecl[*ImportDecl]
+type ParameterMapDecl = *MapDecl[*ParameterDecl]
 type PropertyMapEntry = MapEntry[Expr]
 type PropertyMapDecl = *MapDecl[Expr]
 
@@ -193,7 +222,12 @@ type EnvironmentDecl struct {
 
 	Description *StringExpr
 	Imports     ImportListDecl
+	Parameters  ParameterMapDecl
 	Values      PropertyMapDecl
+
+	// Secret, if true, marks every value produced by the environment as secret, including values inherited from
+	// imports.
+	Secret *BooleanExpr
 }
 
 func (d *EnvironmentDecl) Syntax() syntax.Node {
@@ -207,6 +241,21 @@ func (d *EnvironmentDecl) recordSyntax() *syntax.Node {
 	return &d.syntax
 }
 
+// HeadComment returns the text of the comment that precedes the given top-level key (e.g. "imports" or "values") in
+// the environment's definition, if any. Comment markers are removed (see syntax.HeadCommentText).
+func (d *EnvironmentDecl) HeadComment(key string) string {
+	obj, ok := d.Syntax().(*syntax.ObjectNode)
+	if !ok || obj == nil {
+		return ""
+	}
+	for i := 0; i < obj.Len(); i++ {
+		if kvp := obj.Index(i); kvp.Key.Value() == key {
+			return syntax.HeadCommentText(kvp.Key.Syntax())
+		}
+	}
+	return ""
+}
+
 // NewDiagnosticWriter returns a new hcl.DiagnosticWriter that can be used to print diagnostics associated with the
 // environment.
 func (d *EnvironmentDecl) NewDiagnosticWriter(w io.Writer, width uint, color bool) hcl.DiagnosticWriter {
@@ -232,15 +281,74 @@ func Environment(description *StringExpr, imports ImportListDecl, values Propert
 	return EnvironmentSyntax(nil, description, imports, values)
 }
 
+// Overlay returns an environment that imports the named environments in order and has no values of its own. Each
+// environment is merged onto the result of merging its predecessors, so later environments take precedence. This
+// allows a base environment to be combined with different overlays without an imports block in source.
+func Overlay(environments ...string) *EnvironmentDecl {
+	imports := make([]*ImportDecl, len(environments))
+	for i, name := range environments {
+		imports[i] = &ImportDecl{Environment: String(name)}
+	}
+	return Environment(nil, &ArrayDecl[*ImportDecl]{Elements: imports}, nil)
+}
+
+// ParseOptions controls the parsing of environments.
+type ParseOptions struct {
+	// DuplicateKeySeverity is the severity of the diagnostics reported for duplicate object keys. Defaults to
+	// hcl.DiagError.
+	DuplicateKeySeverity hcl.DiagnosticSeverity
+}
+
 // ParseEnvironment parses a environment from the given syntax node. The source text is optional, and is only used to print
 // diagnostics.
-func ParseEnvironment(source []byte, node syntax.Node) (*EnvironmentDecl, syntax.Diagnostics) {
+func ParseEnvironment(source []byte, node syntax.Node, opts ...ParseOptions) (*EnvironmentDecl, syntax.Diagnostics) {
+	var options ParseOptions
+	if len(opts) != 0 {
+		options = opts[0]
+	}
+	if options.DuplicateKeySeverity == hcl.DiagInvalid {
+		options.DuplicateKeySeverity = hcl.DiagError
+	}
+
 	environment := EnvironmentDecl{source: source}
 
-	diags := parseRecord("environment", &environment, node, false)
+	diags := checkDuplicateKeys(node, options.DuplicateKeySeverity)
+	diags.Extend(parseRecord("environment", &environment, node, false)...)
 	return &environment, diags
 }
 
+// checkDuplicateKeys reports a diagnostic for each object key in the tree rooted at node that duplicates a previous
+// key in the same object. YAML decoders silently collapse duplicate keys, which usually hides a copy-paste mistake.
+func checkDuplicateKeys(node syntax.Node, severity hcl.DiagnosticSeverity) syntax.Diagnostics {
+	var diags syntax.Diagnostics
+	switch node := node.(type) {
+	case *syntax.ArrayNode:
+		for i := 0; i < node.Len(); i++ {
+			diags.Extend(checkDuplicateKeys(node.Index(i), severity)...)
+		}
+	case *syntax.ObjectNode:
+		seen := make(map[string]*syntax.StringNode, node.Len())
+		for i := 0; i < node.Len(); i++ {
+			kvp := node.Index(i)
+
+			key := kvp.Key.Value()
+			if first, ok := seen[key]; ok {
+				diag := syntax.NodeError(kvp.Key, fmt.Sprintf("duplicate key %q", key))
+				diag.Severity = severity
+				if rng := first.Syntax().Range(); rng != nil {
+					diag.Detail = fmt.Sprintf("%q was previously defined at %v", key, rng)
+				}
+				diags.Extend(diag)
+			} else {
+				seen[key] = kvp.Key
+			}
+
+			diags.Extend(checkDuplicateKeys(kvp.Value, severity)...)
+		}
+	}
+	return diags
+}
+
 var parseDeclType = reflect.TypeOf((*parseDecl)(nil)).Elem()
 var nonNilDeclType = reflect.TypeOf((*nonNilDecl)(nil)).Elem()
 var recordDeclType = reflect.TypeOf((*recordDecl)(nil)).Elem()
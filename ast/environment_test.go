@@ -23,6 +23,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -209,3 +210,160 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeEnvironmentRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	const example = `# The production environment.
+imports:
+  - green-channel
+  - us-west-2:
+      merge: false
+  - shared:
+      only: [aws, region]
+      as: common
+parameters:
+  # The region to deploy to.
+  region:
+    schema: { type: string }
+    default: us-west-2
+  replicas: {}
+values:
+  # AWS configuration.
+  aws:
+    fn::open:
+      provider: aws-oidc
+      inputs:
+        sessionName: site-prod-session
+        roleArn: some-role-arn # the role to assume
+  login:
+    fn::open::aws-login:
+      oidc:
+        roleArn: ${aws.roleArn}
+  password:
+    fn::secret: hunter2
+  greeting: hello, ${common.region}! it costs $${price}
+  list: [1, "2", true, null]
+  joined:
+    fn::join: [",", ["${greeting}", "world"]]
+  quoted: '1.0'
+  pulumi:
+    aws:defaultTags:
+      tags:
+        environment: prod
+description: the description comes last
+secret: true
+`
+
+	parse := func(source []byte) *EnvironmentDecl {
+		decl, diags, err := loadYAMLBytes("<stdin>", source)
+		require.NoError(t, err)
+		require.Empty(t, diags)
+		return decl
+	}
+	encode := func(decl *EnvironmentDecl) []byte {
+		bytes, diags := EncodeEnvironment(decl)
+		require.Empty(t, diags)
+		return bytes
+	}
+
+	decl := parse([]byte(example))
+	first := encode(decl)
+	roundTripped := parse(first)
+	second := encode(roundTripped)
+
+	// Encoding is idempotent.
+	assert.Equal(t, string(first), string(second))
+
+	// Comments, builtin forms, and key order are preserved.
+	assert.Contains(t, string(first), "# The production environment.")
+	assert.Contains(t, string(first), "# AWS configuration.")
+	assert.Contains(t, string(first), "# the role to assume")
+	assert.Contains(t, string(first), "fn::open::aws-login:")
+	assert.Less(t, strings.Index(string(first), "imports:"), strings.Index(string(first), "values:"))
+	assert.Less(t, strings.Index(string(first), "values:"), strings.Index(string(first), "description:"))
+	assert.Contains(t, string(first), "secret: true")
+	assert.Contains(t, string(first), "# The region to deploy to.")
+	assert.Less(t, strings.Index(string(first), "parameters:"), strings.Index(string(first), "values:"))
+
+	// The round-tripped declaration is equivalent to the original, modulo source ranges.
+	assert.Equal(t, declJSONWithoutRanges(t, decl), declJSONWithoutRanges(t, roundTripped))
+}
+
+// declJSONWithoutRanges returns the JSON representation of a declaration with all source ranges removed.
+func declJSONWithoutRanges(t *testing.T, decl *EnvironmentDecl) any {
+	bytes, err := json.Marshal(decl)
+	require.NoError(t, err)
+
+	var v any
+	err = json.Unmarshal(bytes, &v)
+	require.NoError(t, err)
+
+	var strip func(v any) any
+	strip = func(v any) any {
+		switch v := v.(type) {
+		case map[string]any:
+			delete(v, "AccessorRange")
+			for k, e := range v {
+				v[k] = strip(e)
+			}
+		case []any:
+			for i, e := range v {
+				v[i] = strip(e)
+			}
+		}
+		return v
+	}
+	return strip(v)
+}
+
+func TestDuplicateKeySeverity(t *testing.T) {
+	t.Parallel()
+
+	const example = `values:
+  region: us-west-2
+  region: us-east-1
+`
+
+	syntax, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(example)), nil)
+	require.Len(t, diags, 0)
+
+	_, diags = ParseEnvironment([]byte(example), syntax)
+	require.Len(t, diags, 1)
+	assert.True(t, diags.HasErrors())
+	assert.Equal(t, `duplicate key "region"`, diags[0].Summary)
+	assert.Equal(t, `"region" was previously defined at <stdin>:2,3-9`, diags[0].Detail)
+	assert.Equal(t, 3, diags[0].Subject.Start.Line)
+
+	_, diags = ParseEnvironment([]byte(example), syntax, ParseOptions{DuplicateKeySeverity: hcl.DiagWarning})
+	require.Len(t, diags, 1)
+	assert.False(t, diags.HasErrors())
+	assert.Equal(t, hcl.DiagWarning, diags[0].Severity)
+}
+
+func TestHeadComment(t *testing.T) {
+	t.Parallel()
+
+	const example = `# The environments that provide shared credentials.
+# Later imports take precedence.
+imports:
+  - base
+values:
+  #   Indentation after the marker is retained.
+  region: us-west-2
+`
+
+	node, diags := encoding.DecodeYAML("<stdin>", yaml.NewDecoder(strings.NewReader(example)), nil)
+	require.Len(t, diags, 0)
+
+	env, diags := ParseEnvironment([]byte(example), node)
+	require.Len(t, diags, 0)
+
+	assert.Equal(t, "The environments that provide shared credentials.\nLater imports take precedence.",
+		env.HeadComment("imports"))
+	assert.Equal(t, "", env.HeadComment("values"))
+	assert.Equal(t, "", env.HeadComment("missing"))
+
+	region := env.Values.GetEntries()[0].Key
+	assert.Equal(t, "  Indentation after the marker is retained.", syntax.HeadCommentText(region.Syntax().Syntax()))
+}
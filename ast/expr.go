@@ -235,6 +235,11 @@ func Symbol(accessors ...PropertyAccessor) *SymbolExpr {
 	}
 }
 
+// SymbolSyntax creates a new symbol expression with the given syntax and property access.
+func SymbolSyntax(node syntax.Node, property *PropertyAccess) *SymbolExpr {
+	return &SymbolExpr{exprNode: expr(node), Property: property}
+}
+
 func (n *SymbolExpr) String() string {
 	return fmt.Sprintf("${%v}", n.Property)
 }
@@ -472,6 +477,63 @@ func FromJSON(value Expr) *FromJSONExpr {
 	return FromJSONSyntax(nil, name, value)
 }
 
+// TypeOfExpr returns the name of the JSON type of its argument.
+type TypeOfExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func TypeOfSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *TypeOfExpr {
+	return &TypeOfExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func TypeOf(value Expr) *TypeOfExpr {
+	name := String("fn::typeOf")
+	return TypeOfSyntax(nil, name, value)
+}
+
+// IsEmptyExpr returns true if its argument is null, an empty string, an empty array, or an empty object.
+type IsEmptyExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func IsEmptySyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *IsEmptyExpr {
+	return &IsEmptyExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func IsEmpty(value Expr) *IsEmptyExpr {
+	name := String("fn::isEmpty")
+	return IsEmptySyntax(nil, name, value)
+}
+
+// ToArrayExpr wraps a value that is not a list in a single-element list.
+type ToArrayExpr struct {
+	builtinNode
+
+	Value Expr
+}
+
+func ToArraySyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ToArrayExpr {
+	return &ToArrayExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+	}
+}
+
+func ToArray(value Expr) *ToArrayExpr {
+	name := String("fn::toArray")
+	return ToArraySyntax(nil, name, value)
+}
+
 // ToString returns the underlying structure as a string.
 type ToStringExpr struct {
 	builtinNode
@@ -557,10 +619,37 @@ func Ciphertext(value *StringExpr) *SecretExpr {
 	}
 }
 
+// SecretIfExpr marks a value as secret if a condition is true.
+type SecretIfExpr struct {
+	builtinNode
+
+	Condition Expr
+	Value     Expr
+}
+
+func SecretIfSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, condition, value Expr) *SecretIfExpr {
+	return &SecretIfExpr{
+		builtinNode: builtin(node, name, args),
+		Condition:   condition,
+		Value:       value,
+	}
+}
+
+func SecretIf(condition, value Expr) *SecretIfExpr {
+	name := String("fn::secretIf")
+	return SecretIfSyntax(nil, name, Object(
+		ObjectProperty{Key: String("condition"), Value: condition},
+		ObjectProperty{Key: String("value"), Value: value},
+	), condition, value)
+}
+
+// ToBase64Expr encodes a string using Base64. If URLSafe is true, the string is encoded using the unpadded URL-safe
+// alphabet (fn::toBase64URL).
 type ToBase64Expr struct {
 	builtinNode
 
-	Value Expr
+	Value   Expr
+	URLSafe bool
 }
 
 func ToBase64Syntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ToBase64Expr {
@@ -570,11 +659,26 @@ func ToBase64Syntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ToBas
 	}
 }
 
-// FromBase64 decodes a Base64 string.
+func ToBase64URLSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ToBase64Expr {
+	return &ToBase64Expr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
+		URLSafe:     true,
+	}
+}
+
+func ToBase64URL(value Expr) *ToBase64Expr {
+	name := String("fn::toBase64URL")
+	return ToBase64URLSyntax(nil, name, value)
+}
+
+// FromBase64Expr decodes a Base64 string. If URLSafe is true, the string is decoded using the URL-safe alphabet
+// (fn::fromBase64URL). Padding is optional for URL-safe strings.
 type FromBase64Expr struct {
 	builtinNode
 
-	String Expr
+	String  Expr
+	URLSafe bool
 }
 
 func FromBase64Syntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *FromBase64Expr {
@@ -589,158 +693,2443 @@ func FromBase64(value Expr) *FromBase64Expr {
 	return FromBase64Syntax(nil, name, value)
 }
 
-func tryParseFunction(node *syntax.ObjectNode) (Expr, syntax.Diagnostics, bool) {
-	if node.Len() != 1 {
-		return nil, nil, false
+func FromBase64URLSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *FromBase64Expr {
+	return &FromBase64Expr{
+		builtinNode: builtin(node, name, args),
+		String:      args,
+		URLSafe:     true,
 	}
+}
 
-	kvp := node.Index(0)
+func FromBase64URL(value Expr) *FromBase64Expr {
+	name := String("fn::fromBase64URL")
+	return FromBase64URLSyntax(nil, name, value)
+}
 
-	var parse func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics)
-	var diags syntax.Diagnostics
-	switch kvp.Key.Value() {
-	case "fn::fromJSON":
-		parse = parseFromJSON
-	case "fn::fromBase64":
-		parse = parseFromBase64
-	case "fn::join":
-		parse = parseJoin
-	case "fn::open":
-		parse = parseOpen
-	case "fn::secret":
-		parse = parseSecret
-	case "fn::toBase64":
-		parse = parseToBase64
-	case "fn::toJSON":
-		parse = parseToJSON
-	case "fn::toString":
-		parse = parseToString
-	default:
-		if strings.HasPrefix(kvp.Key.Value(), "fn::open::") {
-			parse = parseShortOpen
-			break
-		}
+// ToHexExpr encodes a string using hexadecimal.
+type ToHexExpr struct {
+	builtinNode
 
-		if strings.HasPrefix(strings.ToLower(kvp.Key.Value()), "fn::") {
-			diags = append(diags, syntax.Error(kvp.Key.Syntax().Range(),
-				"'fn::' is a reserved prefix",
-				node.Syntax().Path()))
-		}
-		return nil, diags, false
+	Value Expr
+}
+
+func ToHexSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ToHexExpr {
+	return &ToHexExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       args,
 	}
+}
 
-	name := StringSyntax(kvp.Key)
+func ToHex(value Expr) *ToHexExpr {
+	name := String("fn::toHex")
+	return ToHexSyntax(nil, name, value)
+}
 
-	args, adiags := ParseExpr(kvp.Value)
-	diags.Extend(adiags...)
+// FromHexExpr decodes a hexadecimal string.
+type FromHexExpr struct {
+	builtinNode
 
-	expr, xdiags := parse(node, name, args)
-	diags.Extend(xdiags...)
+	String Expr
+}
 
-	if expr == nil {
-		expr = ObjectSyntax(node, ObjectProperty{
-			syntax: kvp,
-			Key:    name,
-			Value:  args,
-		})
+func FromHexSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *FromHexExpr {
+	return &FromHexExpr{
+		builtinNode: builtin(node, name, args),
+		String:      args,
 	}
+}
 
-	return expr, diags, true
+func FromHex(value Expr) *FromHexExpr {
+	name := String("fn::fromHex")
+	return FromHexSyntax(nil, name, value)
 }
 
-func parseOpen(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	obj, ok := args.(*ObjectExpr)
-	if !ok {
-		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::open must be an object containing 'provider' and 'inputs'")}
-		return OpenSyntax(node, name, args, nil, nil), diags
+// EqualsExpr compares two values for structural equality.
+type EqualsExpr struct {
+	builtinNode
+
+	Left  Expr
+	Right Expr
+}
+
+func EqualsSyntax(node *syntax.ObjectNode, name *StringExpr, args, left, right Expr) *EqualsExpr {
+	return &EqualsExpr{
+		builtinNode: builtin(node, name, args),
+		Left:        left,
+		Right:       right,
 	}
+}
 
-	var providerExpr, inputs Expr
-	var diags syntax.Diagnostics
+func Equals(left, right Expr) *EqualsExpr {
+	name := String("fn::equals")
+	return &EqualsExpr{
+		builtinNode: builtin(nil, name, Array(left, right)),
+		Left:        left,
+		Right:       right,
+	}
+}
 
-	for i := 0; i < len(obj.Entries); i++ {
-		kvp := obj.Entries[i]
-		key := kvp.Key
-		switch key.GetValue() {
-		case "provider":
-			providerExpr = kvp.Value
-		case "inputs":
-			inputs = kvp.Value
-		}
+// ObjectDiffExpr describes the differences between two objects.
+type ObjectDiffExpr struct {
+	builtinNode
+
+	A Expr
+	B Expr
+}
+
+func ObjectDiffSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, a, b Expr) *ObjectDiffExpr {
+	return &ObjectDiffExpr{
+		builtinNode: builtin(node, name, args),
+		A:           a,
+		B:           b,
 	}
+}
 
-	provider, ok := providerExpr.(*StringExpr)
-	if !ok {
-		if providerExpr == nil {
-			diags.Extend(ExprError(obj, "missing provider name ('provider')"))
-		} else {
-			diags.Extend(ExprError(providerExpr, "provider name must be a string literal"))
-		}
+func ObjectDiff(a, b Expr) *ObjectDiffExpr {
+	name := String("fn::objectDiff")
+	return ObjectDiffSyntax(nil, name, Object(
+		ObjectProperty{Key: String("a"), Value: a},
+		ObjectProperty{Key: String("b"), Value: b},
+	), a, b)
+}
+
+// ObjectFromKeysExpr creates an object from a list of keys. If Value is set, each key maps to a copy of Value. If Values
+// is set, each key maps to the element of Values at the same index.
+type ObjectFromKeysExpr struct {
+	builtinNode
+
+	Keys   Expr
+	Value  Expr
+	Values Expr
+}
+
+func ObjectFromKeysSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, keys, value, values Expr) *ObjectFromKeysExpr {
+	return &ObjectFromKeysExpr{
+		builtinNode: builtin(node, name, args),
+		Keys:        keys,
+		Value:       value,
+		Values:      values,
 	}
+}
 
-	if inputs == nil {
-		diags.Extend(ExprError(obj, "missing provider inputs ('inputs')"))
+func ObjectFromKeys(keys, value, values Expr) *ObjectFromKeysExpr {
+	name := String("fn::objectFromKeys")
+
+	entries := []ObjectProperty{{Key: String("keys"), Value: keys}}
+	if value != nil {
+		entries = append(entries, ObjectProperty{Key: String("value"), Value: value})
+	}
+	if values != nil {
+		entries = append(entries, ObjectProperty{Key: String("values"), Value: values})
 	}
 
-	return OpenSyntax(node, name, obj, provider, inputs), diags
+	return ObjectFromKeysSyntax(nil, name, Object(entries...), keys, value, values)
 }
 
-func parseShortOpen(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	kvp := node.Index(0)
-	provider := StringSyntaxValue(name.Syntax().(*syntax.StringNode), strings.TrimPrefix(kvp.Key.Value(), "fn::open::"))
-	if args == nil {
-		diags := syntax.Diagnostics{ExprError(name, "missing provider inputs")}
-		return OpenSyntax(node, name, args, provider, nil), diags
+// ArithmeticOp is the operator of an ArithmeticExpr.
+type ArithmeticOp int
+
+const (
+	ArithmeticAdd ArithmeticOp = iota // fn::add
+	ArithmeticSub                     // fn::sub
+	ArithmeticMul                     // fn::mul
+	ArithmeticDiv                     // fn::div
+)
+
+// GroupByExpr groups a list of objects into an object of lists by the value of the property named by Key. If Strict is
+// true, elements that do not have the property are an error. Otherwise, they are grouped under the empty string.
+type GroupByExpr struct {
+	builtinNode
+
+	Items  Expr
+	Key    Expr
+	Strict Expr
+}
+
+func GroupBySyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, items, key, strict Expr) *GroupByExpr {
+	return &GroupByExpr{
+		builtinNode: builtin(node, name, args),
+		Items:       items,
+		Key:         key,
+		Strict:      strict,
 	}
+}
 
-	return OpenSyntax(node, name, args, provider, args), nil
+func GroupBy(items, key, strict Expr) *GroupByExpr {
+	name := String("fn::groupBy")
+
+	entries := []ObjectProperty{
+		{Key: String("items"), Value: items},
+		{Key: String("key"), Value: key},
+	}
+	if strict != nil {
+		entries = append(entries, ObjectProperty{Key: String("strict"), Value: strict})
+	}
+
+	return GroupBySyntax(nil, name, Object(entries...), items, key, strict)
 }
 
-func parseJoin(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	list, ok := args.(*ArrayExpr)
-	if !ok || len(list.Elements) != 2 {
-		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::join must be a two-valued list")}
-		return JoinSyntax(node, name, args, nil, nil), diags
+// ArithmeticExpr applies an arithmetic operator to a pair of numbers.
+type ArithmeticExpr struct {
+	builtinNode
+
+	Op    ArithmeticOp
+	Left  Expr
+	Right Expr
+}
+
+func ArithmeticSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, op ArithmeticOp, left, right Expr) *ArithmeticExpr {
+	return &ArithmeticExpr{
+		builtinNode: builtin(node, name, args),
+		Op:          op,
+		Left:        left,
+		Right:       right,
 	}
+}
 
-	return JoinSyntax(node, name, list, list.Elements[0], list.Elements[1]), nil
+func Arithmetic(op ArithmeticOp, left, right Expr) *ArithmeticExpr {
+	var name *StringExpr
+	switch op {
+	case ArithmeticAdd:
+		name = String("fn::add")
+	case ArithmeticSub:
+		name = String("fn::sub")
+	case ArithmeticMul:
+		name = String("fn::mul")
+	case ArithmeticDiv:
+		name = String("fn::div")
+	}
+	return ArithmeticSyntax(nil, name, Array(left, right), op, left, right)
 }
 
-func parseToJSON(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	return ToJSONSyntax(node, name, args), nil
+// ClampExpr bounds a number to the closed interval [Min, Max].
+type ClampExpr struct {
+	builtinNode
+
+	Value Expr
+	Min   Expr
+	Max   Expr
 }
 
-func parseFromJSON(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	return FromJSONSyntax(node, name, args), nil
+func ClampSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value, min, max Expr) *ClampExpr {
+	return &ClampExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Min:         min,
+		Max:         max,
+	}
 }
 
-func parseToString(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	return ToStringSyntax(node, name, args), nil
+func Clamp(value, min, max Expr) *ClampExpr {
+	name := String("fn::clamp")
+	return ClampSyntax(nil, name, Object(
+		ObjectProperty{Key: String("value"), Value: value},
+		ObjectProperty{Key: String("min"), Value: min},
+		ObjectProperty{Key: String("max"), Value: max},
+	), value, min, max)
 }
 
-func parseToBase64(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	return ToBase64Syntax(node, name, args), nil
+// RepeatExpr creates an array that contains Count copies of Value.
+type RepeatExpr struct {
+	builtinNode
+
+	Value Expr
+	Count Expr
 }
 
-func parseFromBase64(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
-	return FromBase64Syntax(node, name, args), nil
+func RepeatSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value, count Expr) *RepeatExpr {
+	return &RepeatExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Count:       count,
+	}
 }
 
-func parseSecret(node *syntax.ObjectNode, name *StringExpr, value Expr) (Expr, syntax.Diagnostics) {
-	if arg, ok := value.(*ObjectExpr); ok && len(arg.Entries) == 1 {
-		kvp := arg.Entries[0]
-		if kvp.Key.Value == "ciphertext" {
-			if str, ok := kvp.Value.(*StringExpr); ok {
-				return CiphertextSyntax(node, name, arg, str), nil
-			}
-		}
+func Repeat(value, count Expr) *RepeatExpr {
+	name := String("fn::repeat")
+	return RepeatSyntax(nil, name, Object(
+		ObjectProperty{Key: String("value"), Value: value},
+		ObjectProperty{Key: String("count"), Value: count},
+	), value, count)
+}
+
+// SetOp is the operator of a SetExpr.
+type SetOp int
+
+const (
+	SetUnion        SetOp = iota // fn::union
+	SetIntersection              // fn::intersection
+	SetDifference                // fn::difference
+)
+
+// SetExpr applies a set operator to a list of lists. Each list is treated as a set of distinct values.
+type SetExpr struct {
+	builtinNode
+
+	Op       SetOp
+	Operands []Expr
+}
+
+func SetSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, op SetOp, operands []Expr) *SetExpr {
+	return &SetExpr{
+		builtinNode: builtin(node, name, args),
+		Op:          op,
+		Operands:    operands,
 	}
+}
 
-	var diags syntax.Diagnostics
-	str, ok := value.(*StringExpr)
-	if !ok {
-		str = String("")
-		diags = syntax.Diagnostics{ExprError(value, "secret values must be string literals")}
+func Set(op SetOp, operands ...Expr) *SetExpr {
+	var name *StringExpr
+	switch op {
+	case SetUnion:
+		name = String("fn::union")
+	case SetIntersection:
+		name = String("fn::intersection")
+	case SetDifference:
+		name = String("fn::difference")
 	}
-	return PlaintextSyntax(node, name, str), diags
+	return SetSyntax(nil, name, Array(operands...), op, operands)
+}
+
+// AndExpr computes the logical conjunction of a list of boolean operands. Evaluation stops at the first false operand.
+type AndExpr struct {
+	builtinNode
+
+	Operands []Expr
+}
+
+func AndSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, operands []Expr) *AndExpr {
+	return &AndExpr{
+		builtinNode: builtin(node, name, args),
+		Operands:    operands,
+	}
+}
+
+func And(operands ...Expr) *AndExpr {
+	name := String("fn::and")
+	return &AndExpr{
+		builtinNode: builtin(nil, name, Array(operands...)),
+		Operands:    operands,
+	}
+}
+
+// OrExpr computes the logical disjunction of a list of boolean operands. Evaluation stops at the first true operand.
+type OrExpr struct {
+	builtinNode
+
+	Operands []Expr
+}
+
+func OrSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, operands []Expr) *OrExpr {
+	return &OrExpr{
+		builtinNode: builtin(node, name, args),
+		Operands:    operands,
+	}
+}
+
+func Or(operands ...Expr) *OrExpr {
+	name := String("fn::or")
+	return &OrExpr{
+		builtinNode: builtin(nil, name, Array(operands...)),
+		Operands:    operands,
+	}
+}
+
+// NotExpr computes the logical negation of a boolean operand.
+type NotExpr struct {
+	builtinNode
+
+	Operand Expr
+}
+
+func NotSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *NotExpr {
+	return &NotExpr{
+		builtinNode: builtin(node, name, args),
+		Operand:     args,
+	}
+}
+
+func Not(operand Expr) *NotExpr {
+	name := String("fn::not")
+	return NotSyntax(nil, name, operand)
+}
+
+// SemverCompareExpr compares two semantic versions.
+type SemverCompareExpr struct {
+	builtinNode
+
+	Left  Expr
+	Right Expr
+}
+
+func SemverCompareSyntax(node *syntax.ObjectNode, name *StringExpr, args, left, right Expr) *SemverCompareExpr {
+	return &SemverCompareExpr{
+		builtinNode: builtin(node, name, args),
+		Left:        left,
+		Right:       right,
+	}
+}
+
+func SemverCompare(left, right Expr) *SemverCompareExpr {
+	name := String("fn::semverCompare")
+	return &SemverCompareExpr{
+		builtinNode: builtin(nil, name, Array(left, right)),
+		Left:        left,
+		Right:       right,
+	}
+}
+
+// GetOrExpr resolves a property path against a value, returning a default if the path does not resolve to a non-null
+// value.
+type GetOrExpr struct {
+	builtinNode
+
+	From    Expr
+	Path    Expr
+	Default Expr
+}
+
+func GetOrSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, from, path, defaultValue Expr) *GetOrExpr {
+	return &GetOrExpr{
+		builtinNode: builtin(node, name, args),
+		From:        from,
+		Path:        path,
+		Default:     defaultValue,
+	}
+}
+
+func GetOr(from, path, defaultValue Expr) *GetOrExpr {
+	name := String("fn::getOr")
+
+	entries := []ObjectProperty{
+		{Key: String("from"), Value: from},
+		{Key: String("path"), Value: path},
+		{Key: String("default"), Value: defaultValue},
+	}
+
+	return &GetOrExpr{
+		builtinNode: builtin(nil, name, Object(entries...)),
+		From:        from,
+		Path:        path,
+		Default:     defaultValue,
+	}
+}
+
+// AtPathExpr resolves a path of property names and array indices against a value. Unlike GetOrExpr, the path is a
+// list rather than a string, so property names may contain any character.
+type AtPathExpr struct {
+	builtinNode
+
+	Value  Expr
+	Path   Expr
+	Strict Expr
+}
+
+func AtPathSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value, path, strict Expr) *AtPathExpr {
+	return &AtPathExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Path:        path,
+		Strict:      strict,
+	}
+}
+
+func AtPath(value, path, strict Expr) *AtPathExpr {
+	name := String("fn::atPath")
+
+	entries := []ObjectProperty{
+		{Key: String("value"), Value: value},
+		{Key: String("path"), Value: path},
+	}
+	if strict != nil {
+		entries = append(entries, ObjectProperty{Key: String("strict"), Value: strict})
+	}
+
+	return AtPathSyntax(nil, name, Object(entries...), value, path, strict)
+}
+
+// RequireExpr passes a value through unchanged if it is not null, and reports an error with the given message
+// otherwise.
+type RequireExpr struct {
+	builtinNode
+
+	Value   Expr
+	Message Expr
+}
+
+func RequireSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value, message Expr) *RequireExpr {
+	return &RequireExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Message:     message,
+	}
+}
+
+func Require(value, message Expr) *RequireExpr {
+	name := String("fn::require")
+	return RequireSyntax(nil, name, Object(
+		ObjectProperty{Key: String("value"), Value: value},
+		ObjectProperty{Key: String("message"), Value: message},
+	), value, message)
+}
+
+// ZipExpr pairs the elements of a list of arrays by index. The result is an array of tuples, the i'th of which holds
+// the i'th element of each array. By default the result is as long as the shortest array; if Pad is true, the result is
+// as long as the longest array and missing elements are null.
+type ZipExpr struct {
+	builtinNode
+
+	Arrays Expr
+	Pad    Expr
+}
+
+func ZipSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, arrays, pad Expr) *ZipExpr {
+	return &ZipExpr{
+		builtinNode: builtin(node, name, args),
+		Arrays:      arrays,
+		Pad:         pad,
+	}
+}
+
+func Zip(arrays, pad Expr) *ZipExpr {
+	name := String("fn::zip")
+
+	entries := []ObjectProperty{{Key: String("arrays"), Value: arrays}}
+	if pad != nil {
+		entries = append(entries, ObjectProperty{Key: String("pad"), Value: pad})
+	}
+
+	return ZipSyntax(nil, name, Object(entries...), arrays, pad)
+}
+
+// LetExpr binds names to values within an expression. Each binding is visible to symbol references within In, but
+// not to the other bindings.
+type LetExpr struct {
+	builtinNode
+
+	Bindings []ObjectProperty
+	In       Expr
+}
+
+func LetSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, bindings []ObjectProperty, in Expr) *LetExpr {
+	return &LetExpr{
+		builtinNode: builtin(node, name, args),
+		Bindings:    bindings,
+		In:          in,
+	}
+}
+
+func Let(bindings []ObjectProperty, in Expr) *LetExpr {
+	name := String("fn::let")
+	return LetSyntax(nil, name, Object(
+		ObjectProperty{Key: String("bindings"), Value: Object(bindings...)},
+		ObjectProperty{Key: String("in"), Value: in},
+	), bindings, in)
+}
+
+// MapExpr evaluates a template once for each element of a list and returns the list of results. Within the template,
+// the current element is bound to the name given by As, or to "item" if As is nil.
+type MapExpr struct {
+	builtinNode
+
+	Items Expr
+	Each  Expr
+	As    *StringExpr
+}
+
+func MapSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, items, each Expr, as *StringExpr) *MapExpr {
+	return &MapExpr{
+		builtinNode: builtin(node, name, args),
+		Items:       items,
+		Each:        each,
+		As:          as,
+	}
+}
+
+func Map(items, each Expr, as *StringExpr) *MapExpr {
+	name := String("fn::map")
+
+	entries := []ObjectProperty{
+		{Key: String("items"), Value: items},
+		{Key: String("each"), Value: each},
+	}
+	if as != nil {
+		entries = append(entries, ObjectProperty{Key: String("as"), Value: as})
+	}
+
+	return MapSyntax(nil, name, Object(entries...), items, each, as)
+}
+
+// BindingName returns the name to which the current element is bound within the template.
+func (x *MapExpr) BindingName() string {
+	return bindingName(x.As)
+}
+
+// FilterExpr evaluates a predicate once for each element of a list and returns the list of elements for which the
+// predicate is true. Within the predicate, the current element is bound to the name given by As, or to "item" if As is
+// nil.
+type FilterExpr struct {
+	builtinNode
+
+	Items Expr
+	Where Expr
+	As    *StringExpr
+}
+
+func FilterSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, items, where Expr, as *StringExpr) *FilterExpr {
+	return &FilterExpr{
+		builtinNode: builtin(node, name, args),
+		Items:       items,
+		Where:       where,
+		As:          as,
+	}
+}
+
+func Filter(items, where Expr, as *StringExpr) *FilterExpr {
+	name := String("fn::filter")
+
+	entries := []ObjectProperty{
+		{Key: String("items"), Value: items},
+		{Key: String("where"), Value: where},
+	}
+	if as != nil {
+		entries = append(entries, ObjectProperty{Key: String("as"), Value: as})
+	}
+
+	return FilterSyntax(nil, name, Object(entries...), items, where, as)
+}
+
+// BindingName returns the name to which the current element is bound within the predicate.
+func (x *FilterExpr) BindingName() string {
+	return bindingName(x.As)
+}
+
+// ReduceExpr folds a list into a single value. The reducer is evaluated once for each element of the list, with the
+// result of the previous evaluation (or the initial value, for the first element) bound to "acc" and the current
+// element bound to the name given by As, or to "item" if As is nil.
+type ReduceExpr struct {
+	builtinNode
+
+	Items   Expr
+	Initial Expr
+	Reducer Expr
+	As      *StringExpr
+}
+
+func ReduceSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, items, initial, reducer Expr, as *StringExpr) *ReduceExpr {
+	return &ReduceExpr{
+		builtinNode: builtin(node, name, args),
+		Items:       items,
+		Initial:     initial,
+		Reducer:     reducer,
+		As:          as,
+	}
+}
+
+func Reduce(items, initial, reducer Expr, as *StringExpr) *ReduceExpr {
+	name := String("fn::reduce")
+
+	entries := []ObjectProperty{
+		{Key: String("items"), Value: items},
+		{Key: String("initial"), Value: initial},
+		{Key: String("reducer"), Value: reducer},
+	}
+	if as != nil {
+		entries = append(entries, ObjectProperty{Key: String("as"), Value: as})
+	}
+
+	return ReduceSyntax(nil, name, Object(entries...), items, initial, reducer, as)
+}
+
+// AccumulatorName is the name to which the accumulated value is bound within the reducer of a ReduceExpr.
+const AccumulatorName = "acc"
+
+// BindingName returns the name to which the current element is bound within the reducer.
+func (x *ReduceExpr) BindingName() string {
+	return bindingName(x.As)
+}
+
+// bindingName returns the name given by as, or "item" if as is nil.
+func bindingName(as *StringExpr) string {
+	if as == nil {
+		return "item"
+	}
+	return as.Value
+}
+
+// SwitchCase is a single case of a SwitchExpr.
+type SwitchCase struct {
+	When Expr
+	Then Expr
+}
+
+// SwitchExpr returns the result of the first case whose condition is structurally equal to a value, or a default if
+// no case matches.
+type SwitchExpr struct {
+	builtinNode
+
+	Value   Expr
+	Cases   []SwitchCase
+	Default Expr
+}
+
+func SwitchSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value Expr, cases []SwitchCase, defaultValue Expr) *SwitchExpr {
+	return &SwitchExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Cases:       cases,
+		Default:     defaultValue,
+	}
+}
+
+func Switch(value Expr, cases []SwitchCase, defaultValue Expr) *SwitchExpr {
+	name := String("fn::switch")
+
+	caseExprs := make([]Expr, len(cases))
+	for i, c := range cases {
+		caseExprs[i] = Object(
+			ObjectProperty{Key: String("when"), Value: c.When},
+			ObjectProperty{Key: String("then"), Value: c.Then},
+		)
+	}
+
+	entries := []ObjectProperty{
+		{Key: String("value"), Value: value},
+		{Key: String("cases"), Value: Array(caseExprs...)},
+	}
+	if defaultValue != nil {
+		entries = append(entries, ObjectProperty{Key: String("default"), Value: defaultValue})
+	}
+
+	return SwitchSyntax(nil, name, Object(entries...), value, cases, defaultValue)
+}
+
+// CapitalizeExpr converts the first character of a string to title case. The rest of the string is unchanged.
+type CapitalizeExpr struct {
+	builtinNode
+
+	String Expr
+}
+
+func CapitalizeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *CapitalizeExpr {
+	return &CapitalizeExpr{
+		builtinNode: builtin(node, name, args),
+		String:      args,
+	}
+}
+
+func Capitalize(value Expr) *CapitalizeExpr {
+	name := String("fn::capitalize")
+	return CapitalizeSyntax(nil, name, value)
+}
+
+// TitleExpr converts each word in a string to title case.
+type TitleExpr struct {
+	builtinNode
+
+	String Expr
+}
+
+func TitleSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *TitleExpr {
+	return &TitleExpr{
+		builtinNode: builtin(node, name, args),
+		String:      args,
+	}
+}
+
+func Title(value Expr) *TitleExpr {
+	name := String("fn::title")
+	return TitleSyntax(nil, name, value)
+}
+
+// CaseStyle is the case style produced by a ChangeCaseExpr.
+type CaseStyle int
+
+const (
+	CaseCamel CaseStyle = iota // fn::camelCase
+	CaseSnake                  // fn::snakeCase
+	CaseKebab                  // fn::kebabCase
+)
+
+// ChangeCaseExpr converts an identifier to a different case style.
+type ChangeCaseExpr struct {
+	builtinNode
+
+	Style  CaseStyle
+	String Expr
+}
+
+func ChangeCaseSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, style CaseStyle) *ChangeCaseExpr {
+	return &ChangeCaseExpr{
+		builtinNode: builtin(node, name, args),
+		Style:       style,
+		String:      args,
+	}
+}
+
+func ChangeCase(style CaseStyle, value Expr) *ChangeCaseExpr {
+	var name *StringExpr
+	switch style {
+	case CaseCamel:
+		name = String("fn::camelCase")
+	case CaseSnake:
+		name = String("fn::snakeCase")
+	case CaseKebab:
+		name = String("fn::kebabCase")
+	}
+	return ChangeCaseSyntax(nil, name, value, style)
+}
+
+// PadSide is the side of the string on which a PadExpr inserts padding.
+type PadSide int
+
+const (
+	PadLeft  PadSide = iota // fn::padLeft
+	PadRight                // fn::padRight
+)
+
+// MaskExpr replaces all but the last Visible characters of a string with asterisks.
+type MaskExpr struct {
+	builtinNode
+
+	String  Expr
+	Visible Expr
+}
+
+func MaskSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, str, visible Expr) *MaskExpr {
+	return &MaskExpr{
+		builtinNode: builtin(node, name, args),
+		String:      str,
+		Visible:     visible,
+	}
+}
+
+func Mask(str, visible Expr) *MaskExpr {
+	name := String("fn::mask")
+	return MaskSyntax(nil, name, Object(
+		ObjectProperty{Key: String("string"), Value: str},
+		ObjectProperty{Key: String("visible"), Value: visible},
+	), str, visible)
+}
+
+// NearestExpr returns the option that is closest to a string by edit distance.
+type NearestExpr struct {
+	builtinNode
+
+	Value   Expr
+	Options Expr
+}
+
+func NearestSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value, options Expr) *NearestExpr {
+	return &NearestExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Options:     options,
+	}
+}
+
+func Nearest(value, options Expr) *NearestExpr {
+	name := String("fn::nearest")
+	return NearestSyntax(nil, name, Object(
+		ObjectProperty{Key: String("value"), Value: value},
+		ObjectProperty{Key: String("options"), Value: options},
+	), value, options)
+}
+
+// PadExpr pads a string to a target length with a single-character pad string.
+type PadExpr struct {
+	builtinNode
+
+	Side   PadSide
+	String Expr
+	Length Expr
+	Pad    Expr
+}
+
+func PadSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, side PadSide, str, length, pad Expr) *PadExpr {
+	return &PadExpr{
+		builtinNode: builtin(node, name, args),
+		Side:        side,
+		String:      str,
+		Length:      length,
+		Pad:         pad,
+	}
+}
+
+func Pad(side PadSide, str, length, pad Expr) *PadExpr {
+	name := String("fn::padLeft")
+	if side == PadRight {
+		name = String("fn::padRight")
+	}
+
+	entries := []ObjectProperty{
+		{Key: String("string"), Value: str},
+		{Key: String("length"), Value: length},
+	}
+	if pad != nil {
+		entries = append(entries, ObjectProperty{Key: String("pad"), Value: pad})
+	}
+
+	return PadSyntax(nil, name, Object(entries...), side, str, length, pad)
+}
+
+// ParseArnExpr parses an Amazon Resource Name (ARN) into its components.
+type ParseArnExpr struct {
+	builtinNode
+
+	Arn Expr
+}
+
+func ParseArnSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ParseArnExpr {
+	return &ParseArnExpr{
+		builtinNode: builtin(node, name, args),
+		Arn:         args,
+	}
+}
+
+func ParseArn(arn Expr) *ParseArnExpr {
+	name := String("fn::parseArn")
+	return ParseArnSyntax(nil, name, arn)
+}
+
+// ParseDurationExpr parses a Go duration string and returns its length in seconds or milliseconds.
+type ParseDurationExpr struct {
+	builtinNode
+
+	Duration Expr
+	Unit     Expr
+}
+
+func ParseDurationSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, duration, unit Expr) *ParseDurationExpr {
+	return &ParseDurationExpr{
+		builtinNode: builtin(node, name, args),
+		Duration:    duration,
+		Unit:        unit,
+	}
+}
+
+func ParseDuration(duration, unit Expr) *ParseDurationExpr {
+	name := String("fn::parseDuration")
+
+	entries := []ObjectProperty{{Key: String("duration"), Value: duration}}
+	if unit != nil {
+		entries = append(entries, ObjectProperty{Key: String("unit"), Value: unit})
+	}
+
+	return ParseDurationSyntax(nil, name, Object(entries...), duration, unit)
+}
+
+// FormatTimeExpr parses a timestamp using one named layout and formats it using another.
+type FormatTimeExpr struct {
+	builtinNode
+
+	Time         Expr
+	InputLayout  Expr
+	OutputLayout Expr
+}
+
+func FormatTimeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, time, inputLayout, outputLayout Expr) *FormatTimeExpr {
+	return &FormatTimeExpr{
+		builtinNode:  builtin(node, name, args),
+		Time:         time,
+		InputLayout:  inputLayout,
+		OutputLayout: outputLayout,
+	}
+}
+
+func FormatTime(time, inputLayout, outputLayout Expr) *FormatTimeExpr {
+	name := String("fn::formatTime")
+	return FormatTimeSyntax(nil, name, Object(
+		ObjectProperty{Key: String("time"), Value: time},
+		ObjectProperty{Key: String("inputLayout"), Value: inputLayout},
+		ObjectProperty{Key: String("outputLayout"), Value: outputLayout},
+	), time, inputLayout, outputLayout)
+}
+
+// ParseSizeExpr parses a human-readable size such as "10Mi" or "2GB" into a number of bytes.
+type ParseSizeExpr struct {
+	builtinNode
+
+	Size Expr
+}
+
+func ParseSizeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ParseSizeExpr {
+	return &ParseSizeExpr{
+		builtinNode: builtin(node, name, args),
+		Size:        args,
+	}
+}
+
+func ParseSize(size Expr) *ParseSizeExpr {
+	name := String("fn::parseSize")
+	return ParseSizeSyntax(nil, name, size)
+}
+
+// RandomStringExpr generates a random string of a given length from a set of characters.
+type RandomStringExpr struct {
+	builtinNode
+
+	Length  Expr
+	Charset Expr
+}
+
+func RandomStringSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, length, charset Expr) *RandomStringExpr {
+	return &RandomStringExpr{
+		builtinNode: builtin(node, name, args),
+		Length:      length,
+		Charset:     charset,
+	}
+}
+
+func RandomString(length, charset Expr) *RandomStringExpr {
+	name := String("fn::randomString")
+
+	entries := []ObjectProperty{{Key: String("length"), Value: length}}
+	if charset != nil {
+		entries = append(entries, ObjectProperty{Key: String("charset"), Value: charset})
+	}
+
+	return RandomStringSyntax(nil, name, Object(entries...), length, charset)
+}
+
+// SampleExpr selects a random element from an array.
+type SampleExpr struct {
+	builtinNode
+
+	Array Expr
+}
+
+func SampleSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *SampleExpr {
+	return &SampleExpr{
+		builtinNode: builtin(node, name, args),
+		Array:       args,
+	}
+}
+
+func Sample(array Expr) *SampleExpr {
+	name := String("fn::sample")
+	return SampleSyntax(nil, name, array)
+}
+
+// URLEncodeExpr percent-encodes a string for use as a URL query component or path segment.
+type URLEncodeExpr struct {
+	builtinNode
+
+	Value Expr
+	Mode  Expr
+}
+
+func URLEncodeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value, mode Expr) *URLEncodeExpr {
+	return &URLEncodeExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Mode:        mode,
+	}
+}
+
+func URLEncode(value, mode Expr) *URLEncodeExpr {
+	name := String("fn::urlEncode")
+	return URLEncodeSyntax(nil, name, urlArgs(value, mode), value, mode)
+}
+
+// URLDecodeExpr decodes a percent-encoded URL query component or path segment.
+type URLDecodeExpr struct {
+	builtinNode
+
+	Value Expr
+	Mode  Expr
+}
+
+func URLDecodeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, value, mode Expr) *URLDecodeExpr {
+	return &URLDecodeExpr{
+		builtinNode: builtin(node, name, args),
+		Value:       value,
+		Mode:        mode,
+	}
+}
+
+func URLDecode(value, mode Expr) *URLDecodeExpr {
+	name := String("fn::urlDecode")
+	return URLDecodeSyntax(nil, name, urlArgs(value, mode), value, mode)
+}
+
+func urlArgs(value, mode Expr) *ObjectExpr {
+	entries := []ObjectProperty{{Key: String("value"), Value: value}}
+	if mode != nil {
+		entries = append(entries, ObjectProperty{Key: String("mode"), Value: mode})
+	}
+	return Object(entries...)
+}
+
+// URLJoinExpr joins a base URL with a list of path segments.
+type URLJoinExpr struct {
+	builtinNode
+
+	Base     Expr
+	Segments Expr
+}
+
+func URLJoinSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, base, segments Expr) *URLJoinExpr {
+	return &URLJoinExpr{
+		builtinNode: builtin(node, name, args),
+		Base:        base,
+		Segments:    segments,
+	}
+}
+
+func URLJoin(base, segments Expr) *URLJoinExpr {
+	name := String("fn::urlJoin")
+	return URLJoinSyntax(nil, name, Object(
+		ObjectProperty{Key: String("base"), Value: base},
+		ObjectProperty{Key: String("segments"), Value: segments},
+	), base, segments)
+}
+
+// EncodeQueryExpr encodes an object of query parameters as a URL query string.
+type EncodeQueryExpr struct {
+	builtinNode
+
+	Values Expr
+}
+
+func EncodeQuerySyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *EncodeQueryExpr {
+	return &EncodeQueryExpr{
+		builtinNode: builtin(node, name, args),
+		Values:      args,
+	}
+}
+
+func EncodeQuery(values Expr) *EncodeQueryExpr {
+	name := String("fn::encodeQuery")
+	return EncodeQuerySyntax(nil, name, values)
+}
+
+// HMACExpr computes the HMAC of a message using a secret key.
+type HMACExpr struct {
+	builtinNode
+
+	Algorithm Expr
+	Key       Expr
+	Message   Expr
+	Encoding  Expr
+}
+
+func HMACSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, algorithm, key, message, encoding Expr) *HMACExpr {
+	return &HMACExpr{
+		builtinNode: builtin(node, name, args),
+		Algorithm:   algorithm,
+		Key:         key,
+		Message:     message,
+		Encoding:    encoding,
+	}
+}
+
+func HMAC(algorithm, key, message, encoding Expr) *HMACExpr {
+	name := String("fn::hmac")
+
+	entries := []ObjectProperty{
+		{Key: String("algorithm"), Value: algorithm},
+		{Key: String("key"), Value: key},
+		{Key: String("message"), Value: message},
+	}
+	if encoding != nil {
+		entries = append(entries, ObjectProperty{Key: String("encoding"), Value: encoding})
+	}
+
+	return HMACSyntax(nil, name, Object(entries...), algorithm, key, message, encoding)
+}
+
+// ImportExpr evaluates another environment and returns the value at a property path within that environment.
+type ImportExpr struct {
+	builtinNode
+
+	Environment Expr
+	Path        Expr
+}
+
+func ImportSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr, environment, path Expr) *ImportExpr {
+	return &ImportExpr{
+		builtinNode: builtin(node, name, args),
+		Environment: environment,
+		Path:        path,
+	}
+}
+
+func Import(environment, path Expr) *ImportExpr {
+	name := String("fn::import")
+
+	entries := []ObjectProperty{
+		{Key: String("env"), Value: environment},
+		{Key: String("path"), Value: path},
+	}
+
+	return &ImportExpr{
+		builtinNode: builtin(nil, name, Object(entries...)),
+		Environment: environment,
+		Path:        path,
+	}
+}
+
+// ImportRawExpr returns the value at a property path within the environment's imports, ignoring any values defined by
+// the environment itself.
+type ImportRawExpr struct {
+	builtinNode
+
+	Path Expr
+}
+
+func ImportRawSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *ImportRawExpr {
+	return &ImportRawExpr{
+		builtinNode: builtin(node, name, args),
+		Path:        args,
+	}
+}
+
+func ImportRaw(path Expr) *ImportRawExpr {
+	name := String("fn::importRaw")
+	return ImportRawSyntax(nil, name, path)
+}
+
+// JWTDecodeExpr decodes the claims of a JSON Web Token. The token's signature is not verified.
+type JWTDecodeExpr struct {
+	builtinNode
+
+	Token Expr
+}
+
+func JWTDecodeSyntax(node *syntax.ObjectNode, name *StringExpr, args Expr) *JWTDecodeExpr {
+	return &JWTDecodeExpr{
+		builtinNode: builtin(node, name, args),
+		Token:       args,
+	}
+}
+
+func JWTDecode(token Expr) *JWTDecodeExpr {
+	name := String("fn::jwtDecode")
+	return JWTDecodeSyntax(nil, name, token)
+}
+
+func tryParseFunction(node *syntax.ObjectNode) (Expr, syntax.Diagnostics, bool) {
+	if node.Len() != 1 {
+		return nil, nil, false
+	}
+
+	kvp := node.Index(0)
+
+	var parse func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics)
+	var diags syntax.Diagnostics
+	switch kvp.Key.Value() {
+	case "fn::add":
+		parse = parseArithmetic(ArithmeticAdd)
+	case "fn::and":
+		parse = parseAnd
+	case "fn::atPath":
+		parse = parseAtPath
+	case "fn::camelCase":
+		parse = parseChangeCase(CaseCamel)
+	case "fn::capitalize":
+		parse = parseCapitalize
+	case "fn::clamp":
+		parse = parseClamp
+	case "fn::difference":
+		parse = parseSet(SetDifference)
+	case "fn::div":
+		parse = parseArithmetic(ArithmeticDiv)
+	case "fn::encodeQuery":
+		parse = parseEncodeQuery
+	case "fn::equals":
+		parse = parseEquals
+	case "fn::filter":
+		parse = parseFilter
+	case "fn::formatTime":
+		parse = parseFormatTime
+	case "fn::fromJSON":
+		parse = parseFromJSON
+	case "fn::fromBase64":
+		parse = parseFromBase64
+	case "fn::fromBase64URL":
+		parse = parseFromBase64URL
+	case "fn::fromHex":
+		parse = parseFromHex
+	case "fn::getOr":
+		parse = parseGetOr
+	case "fn::groupBy":
+		parse = parseGroupBy
+	case "fn::hmac":
+		parse = parseHMAC
+	case "fn::import":
+		parse = parseImport
+	case "fn::importRaw":
+		parse = parseImportRaw
+	case "fn::intersection":
+		parse = parseSet(SetIntersection)
+	case "fn::isEmpty":
+		parse = parseIsEmpty
+	case "fn::join":
+		parse = parseJoin
+	case "fn::jwtDecode":
+		parse = parseJWTDecode
+	case "fn::kebabCase":
+		parse = parseChangeCase(CaseKebab)
+	case "fn::let":
+		parse = parseLet
+	case "fn::map":
+		parse = parseMap
+	case "fn::mask":
+		parse = parseMask
+	case "fn::mul":
+		parse = parseArithmetic(ArithmeticMul)
+	case "fn::nearest":
+		parse = parseNearest
+	case "fn::not":
+		parse = parseNot
+	case "fn::objectDiff":
+		parse = parseObjectDiff
+	case "fn::objectFromKeys":
+		parse = parseObjectFromKeys
+	case "fn::open":
+		parse = parseOpen
+	case "fn::or":
+		parse = parseOr
+	case "fn::padLeft":
+		parse = parsePad(PadLeft)
+	case "fn::padRight":
+		parse = parsePad(PadRight)
+	case "fn::parseArn":
+		parse = parseParseArn
+	case "fn::parseDuration":
+		parse = parseParseDuration
+	case "fn::parseSize":
+		parse = parseParseSize
+	case "fn::randomString":
+		parse = parseRandomString
+	case "fn::reduce":
+		parse = parseReduce
+	case "fn::repeat":
+		parse = parseRepeat
+	case "fn::require":
+		parse = parseRequire
+	case "fn::sample":
+		parse = parseSample
+	case "fn::secret":
+		parse = parseSecret
+	case "fn::secretIf":
+		parse = parseSecretIf
+	case "fn::semverCompare":
+		parse = parseSemverCompare
+	case "fn::snakeCase":
+		parse = parseChangeCase(CaseSnake)
+	case "fn::sub":
+		parse = parseArithmetic(ArithmeticSub)
+	case "fn::switch":
+		parse = parseSwitch
+	case "fn::title":
+		parse = parseTitle
+	case "fn::toArray":
+		parse = parseToArray
+	case "fn::toBase64":
+		parse = parseToBase64
+	case "fn::toBase64URL":
+		parse = parseToBase64URL
+	case "fn::toHex":
+		parse = parseToHex
+	case "fn::toJSON":
+		parse = parseToJSON
+	case "fn::toString":
+		parse = parseToString
+	case "fn::typeOf":
+		parse = parseTypeOf
+	case "fn::union":
+		parse = parseSet(SetUnion)
+	case "fn::urlDecode":
+		parse = parseURLDecode
+	case "fn::urlEncode":
+		parse = parseURLEncode
+	case "fn::urlJoin":
+		parse = parseURLJoin
+	case "fn::zip":
+		parse = parseZip
+	default:
+		if strings.HasPrefix(kvp.Key.Value(), "fn::open::") {
+			parse = parseShortOpen
+			break
+		}
+
+		if strings.HasPrefix(strings.ToLower(kvp.Key.Value()), "fn::") {
+			diags = append(diags, syntax.Error(kvp.Key.Syntax().Range(),
+				"'fn::' is a reserved prefix",
+				node.Syntax().Path()))
+		}
+		return nil, diags, false
+	}
+
+	name := StringSyntax(kvp.Key)
+
+	args, adiags := ParseExpr(kvp.Value)
+	diags.Extend(adiags...)
+
+	expr, xdiags := parse(node, name, args)
+	diags.Extend(xdiags...)
+
+	if expr == nil {
+		expr = ObjectSyntax(node, ObjectProperty{
+			syntax: kvp,
+			Key:    name,
+			Value:  args,
+		})
+	}
+
+	return expr, diags, true
+}
+
+func parseOpen(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::open must be an object containing 'provider' and 'inputs'")}
+		return OpenSyntax(node, name, args, nil, nil), diags
+	}
+
+	var providerExpr, inputs Expr
+	var diags syntax.Diagnostics
+
+	for i := 0; i < len(obj.Entries); i++ {
+		kvp := obj.Entries[i]
+		key := kvp.Key
+		switch key.GetValue() {
+		case "provider":
+			providerExpr = kvp.Value
+		case "inputs":
+			inputs = kvp.Value
+		}
+	}
+
+	provider, ok := providerExpr.(*StringExpr)
+	if !ok {
+		if providerExpr == nil {
+			diags.Extend(ExprError(obj, "missing provider name ('provider')"))
+		} else {
+			diags.Extend(ExprError(providerExpr, "provider name must be a string literal"))
+		}
+	}
+
+	if inputs == nil {
+		diags.Extend(ExprError(obj, "missing provider inputs ('inputs')"))
+	}
+
+	return OpenSyntax(node, name, obj, provider, inputs), diags
+}
+
+func parseShortOpen(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	kvp := node.Index(0)
+	provider := StringSyntaxValue(name.Syntax().(*syntax.StringNode), strings.TrimPrefix(kvp.Key.Value(), "fn::open::"))
+	if args == nil {
+		diags := syntax.Diagnostics{ExprError(name, "missing provider inputs")}
+		return OpenSyntax(node, name, args, provider, nil), diags
+	}
+
+	return OpenSyntax(node, name, args, provider, args), nil
+}
+
+func parseJoin(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ArrayExpr)
+	if !ok || len(list.Elements) != 2 {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::join must be a two-valued list")}
+		return JoinSyntax(node, name, args, nil, nil), diags
+	}
+
+	return JoinSyntax(node, name, list, list.Elements[0], list.Elements[1]), nil
+}
+
+func parseEquals(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ArrayExpr)
+	if !ok || len(list.Elements) != 2 {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::equals must be a two-valued list")}
+		return EqualsSyntax(node, name, args, nil, nil), diags
+	}
+
+	return EqualsSyntax(node, name, list, list.Elements[0], list.Elements[1]), nil
+}
+
+func parseObjectDiff(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::objectDiff must be an object containing 'a' and 'b'")}
+		return ObjectDiffSyntax(node, name, args, nil, nil), diags
+	}
+
+	var a, b Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "a":
+			a = kvp.Value
+		case "b":
+			b = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if a == nil {
+		diags.Extend(ExprError(obj, "missing first object ('a')"))
+	}
+	if b == nil {
+		diags.Extend(ExprError(obj, "missing second object ('b')"))
+	}
+
+	return ObjectDiffSyntax(node, name, obj, a, b), diags
+}
+
+func parseObjectFromKeys(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::objectFromKeys must be an object containing 'keys' and either 'value' or 'values'")}
+		return ObjectFromKeysSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var keys, value, values Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "keys":
+			keys = kvp.Value
+		case "value":
+			value = kvp.Value
+		case "values":
+			values = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if keys == nil {
+		diags.Extend(ExprError(obj, "missing keys ('keys')"))
+	}
+	switch {
+	case value == nil && values == nil:
+		diags.Extend(ExprError(obj, "missing value ('value') or values ('values')"))
+	case value != nil && values != nil:
+		diags.Extend(ExprError(obj, "only one of 'value' or 'values' may be specified"))
+	}
+
+	return ObjectFromKeysSyntax(node, name, obj, keys, value, values), diags
+}
+
+func parseArithmetic(op ArithmeticOp) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		list, ok := args.(*ArrayExpr)
+		if !ok || len(list.Elements) != 2 {
+			diags := syntax.Diagnostics{ExprError(args, fmt.Sprintf("the argument to %v must be a two-valued list", name.Value))}
+			return ArithmeticSyntax(node, name, args, op, nil, nil), diags
+		}
+
+		return ArithmeticSyntax(node, name, list, op, list.Elements[0], list.Elements[1]), nil
+	}
+}
+
+func parseClamp(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::clamp must be an object containing 'value', 'min', and 'max'")}
+		return ClampSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var value, min, max Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "value":
+			value = kvp.Value
+		case "min":
+			min = kvp.Value
+		case "max":
+			max = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+	if min == nil {
+		diags.Extend(ExprError(obj, "missing lower bound ('min')"))
+	}
+	if max == nil {
+		diags.Extend(ExprError(obj, "missing upper bound ('max')"))
+	}
+
+	return ClampSyntax(node, name, obj, value, min, max), diags
+}
+
+func parseRepeat(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::repeat must be an object containing 'value' and 'count'")}
+		return RepeatSyntax(node, name, args, nil, nil), diags
+	}
+
+	var value, count Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "value":
+			value = kvp.Value
+		case "count":
+			count = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+	if count == nil {
+		diags.Extend(ExprError(obj, "missing count ('count')"))
+	}
+
+	return RepeatSyntax(node, name, obj, value, count), diags
+}
+
+func parseSet(op SetOp) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		list, ok := args.(*ArrayExpr)
+		if !ok || len(list.Elements) < 2 {
+			diags := syntax.Diagnostics{ExprError(args, fmt.Sprintf("the argument to %v must be a list of at least two lists", name.Value))}
+			return SetSyntax(node, name, args, op, nil), diags
+		}
+
+		return SetSyntax(node, name, list, op, list.Elements), nil
+	}
+}
+
+func parseAnd(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ArrayExpr)
+	if !ok || len(list.Elements) == 0 {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::and must be a non-empty list")}
+		return AndSyntax(node, name, args, nil), diags
+	}
+	return AndSyntax(node, name, list, list.Elements), nil
+}
+
+func parseOr(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ArrayExpr)
+	if !ok || len(list.Elements) == 0 {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::or must be a non-empty list")}
+		return OrSyntax(node, name, args, nil), diags
+	}
+	return OrSyntax(node, name, list, list.Elements), nil
+}
+
+func parseNot(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return NotSyntax(node, name, args), nil
+}
+
+func parseSemverCompare(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	list, ok := args.(*ArrayExpr)
+	if !ok || len(list.Elements) != 2 {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::semverCompare must be a two-valued list")}
+		return SemverCompareSyntax(node, name, args, nil, nil), diags
+	}
+
+	return SemverCompareSyntax(node, name, list, list.Elements[0], list.Elements[1]), nil
+}
+
+func parseAtPath(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::atPath must be an object containing 'value' and 'path'")}
+		return AtPathSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var value, path, strict Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "value":
+			value = kvp.Value
+		case "path":
+			path = kvp.Value
+		case "strict":
+			strict = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+	if path == nil {
+		diags.Extend(ExprError(obj, "missing path ('path')"))
+	}
+
+	return AtPathSyntax(node, name, obj, value, path, strict), diags
+}
+
+func parseZip(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::zip must be an object containing 'arrays'")}
+		return ZipSyntax(node, name, args, nil, nil), diags
+	}
+
+	var arrays, pad Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "arrays":
+			arrays = kvp.Value
+		case "pad":
+			pad = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if arrays == nil {
+		diags.Extend(ExprError(obj, "missing arrays ('arrays')"))
+	}
+
+	return ZipSyntax(node, name, obj, arrays, pad), diags
+}
+
+func parseGroupBy(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::groupBy must be an object containing 'items' and 'key'")}
+		return GroupBySyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var items, key, strict Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "items":
+			items = kvp.Value
+		case "key":
+			key = kvp.Value
+		case "strict":
+			strict = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if items == nil {
+		diags.Extend(ExprError(obj, "missing items ('items')"))
+	}
+	if key == nil {
+		diags.Extend(ExprError(obj, "missing key ('key')"))
+	}
+
+	return GroupBySyntax(node, name, obj, items, key, strict), diags
+}
+
+func parseGetOr(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::getOr must be an object containing 'from', 'path', and 'default'")}
+		return GetOrSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var from, path, defaultValue Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "from":
+			from = kvp.Value
+		case "path":
+			path = kvp.Value
+		case "default":
+			defaultValue = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if from == nil {
+		diags.Extend(ExprError(obj, "missing value ('from')"))
+	}
+	if path == nil {
+		diags.Extend(ExprError(obj, "missing property path ('path')"))
+	}
+	if defaultValue == nil {
+		diags.Extend(ExprError(obj, "missing default value ('default')"))
+	}
+
+	return GetOrSyntax(node, name, obj, from, path, defaultValue), diags
+}
+
+func parseLet(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::let must be an object containing 'bindings' and 'in'")}
+		return LetSyntax(node, name, args, nil, nil), diags
+	}
+
+	var bindingsExpr, in Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "bindings":
+			bindingsExpr = kvp.Value
+		case "in":
+			in = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	var bindings []ObjectProperty
+	switch b := bindingsExpr.(type) {
+	case nil:
+		diags.Extend(ExprError(obj, "missing bindings ('bindings')"))
+	case *ObjectExpr:
+		for _, kvp := range b.Entries {
+			if _, bdiags := parseBindingName(kvp.Key); len(bdiags) != 0 {
+				diags.Extend(bdiags...)
+				continue
+			}
+			bindings = append(bindings, kvp)
+		}
+	default:
+		diags.Extend(ExprError(bindingsExpr, "bindings must be an object"))
+	}
+	if in == nil {
+		diags.Extend(ExprError(obj, "missing expression ('in')"))
+	}
+
+	return LetSyntax(node, name, obj, bindings, in), diags
+}
+
+func parseMap(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::map must be an object containing 'items' and 'each'")}
+		return MapSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var items, each, asExpr Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "items":
+			items = kvp.Value
+		case "each":
+			each = kvp.Value
+		case "as":
+			asExpr = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if items == nil {
+		diags.Extend(ExprError(obj, "missing list ('items')"))
+	}
+	if each == nil {
+		diags.Extend(ExprError(obj, "missing template ('each')"))
+	}
+
+	as, asDiags := parseBindingName(asExpr)
+	diags.Extend(asDiags...)
+
+	return MapSyntax(node, name, obj, items, each, as), diags
+}
+
+func parseFilter(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::filter must be an object containing 'items' and 'where'")}
+		return FilterSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var items, where, asExpr Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "items":
+			items = kvp.Value
+		case "where":
+			where = kvp.Value
+		case "as":
+			asExpr = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if items == nil {
+		diags.Extend(ExprError(obj, "missing list ('items')"))
+	}
+	if where == nil {
+		diags.Extend(ExprError(obj, "missing predicate ('where')"))
+	}
+
+	as, asDiags := parseBindingName(asExpr)
+	diags.Extend(asDiags...)
+
+	return FilterSyntax(node, name, obj, items, where, as), diags
+}
+
+func parseReduce(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::reduce must be an object containing 'items', 'initial', and 'reducer'")}
+		return ReduceSyntax(node, name, args, nil, nil, nil, nil), diags
+	}
+
+	var items, initial, reducer, asExpr Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "items":
+			items = kvp.Value
+		case "initial":
+			initial = kvp.Value
+		case "reducer":
+			reducer = kvp.Value
+		case "as":
+			asExpr = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if items == nil {
+		diags.Extend(ExprError(obj, "missing list ('items')"))
+	}
+	if initial == nil {
+		diags.Extend(ExprError(obj, "missing initial value ('initial')"))
+	}
+	if reducer == nil {
+		diags.Extend(ExprError(obj, "missing reducer ('reducer')"))
+	}
+
+	as, asDiags := parseBindingName(asExpr)
+	diags.Extend(asDiags...)
+	if as != nil && as.Value == AccumulatorName {
+		diags.Extend(ExprError(as, fmt.Sprintf("%q is not a valid binding name: it is bound to the accumulated value", as.Value)))
+		as = nil
+	}
+
+	return ReduceSyntax(node, name, obj, items, initial, reducer, as), diags
+}
+
+// parseBindingName parses the optional binding name ('as') of a call to fn::map, fn::filter, or fn::reduce, or the name
+// of a binding of a call to fn::let.
+func parseBindingName(x Expr) (*StringExpr, syntax.Diagnostics) {
+	if x == nil {
+		return nil, nil
+	}
+
+	str, ok := x.(*StringExpr)
+	switch {
+	case !ok:
+		return nil, syntax.Diagnostics{ExprError(x, "the binding name ('as') must be a string literal")}
+	case str.Value == "" || str.Value == "imports" || str.Value == "context":
+		return nil, syntax.Diagnostics{ExprError(x, fmt.Sprintf("%q is not a valid binding name", str.Value))}
+	default:
+		return str, nil
+	}
+}
+
+func parseSwitch(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::switch must be an object containing 'value' and 'cases'")}
+		return SwitchSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var value, cases, defaultValue Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "value":
+			value = kvp.Value
+		case "cases":
+			cases = kvp.Value
+		case "default":
+			defaultValue = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+
+	var switchCases []SwitchCase
+	switch list := cases.(type) {
+	case nil:
+		diags.Extend(ExprError(obj, "missing cases ('cases')"))
+	case *ArrayExpr:
+		// Invalid cases are retained with missing conditions and/or results so that evaluation does not report
+		// spurious errors for values that would have matched them.
+		switchCases = make([]SwitchCase, 0, len(list.Elements))
+		for _, el := range list.Elements {
+			c, ok := el.(*ObjectExpr)
+			if !ok {
+				diags.Extend(ExprError(el, "each case must be an object containing 'when' and 'then'"))
+				switchCases = append(switchCases, SwitchCase{})
+				continue
+			}
+
+			var when, then Expr
+			for _, kvp := range c.Entries {
+				switch kvp.Key.GetValue() {
+				case "when":
+					when = kvp.Value
+				case "then":
+					then = kvp.Value
+				}
+			}
+			if when == nil {
+				diags.Extend(ExprError(c, "missing case value ('when')"))
+			}
+			if then == nil {
+				diags.Extend(ExprError(c, "missing case result ('then')"))
+			}
+			switchCases = append(switchCases, SwitchCase{When: when, Then: then})
+		}
+	default:
+		diags.Extend(ExprError(cases, "cases must be a list"))
+	}
+
+	return SwitchSyntax(node, name, obj, value, switchCases, defaultValue), diags
+}
+
+func parseMask(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::mask must be an object containing 'string' and 'visible'")}
+		return MaskSyntax(node, name, args, nil, nil), diags
+	}
+
+	var str, visible Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "string":
+			str = kvp.Value
+		case "visible":
+			visible = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if str == nil {
+		diags.Extend(ExprError(obj, "missing string ('string')"))
+	}
+	if visible == nil {
+		diags.Extend(ExprError(obj, "missing visible character count ('visible')"))
+	}
+
+	return MaskSyntax(node, name, obj, str, visible), diags
+}
+
+func parseNearest(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::nearest must be an object containing 'value' and 'options'")}
+		return NearestSyntax(node, name, args, nil, nil), diags
+	}
+
+	var value, options Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "value":
+			value = kvp.Value
+		case "options":
+			options = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+	if options == nil {
+		diags.Extend(ExprError(obj, "missing options ('options')"))
+	}
+
+	return NearestSyntax(node, name, obj, value, options), diags
+}
+
+func parsePad(side PadSide) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		obj, ok := args.(*ObjectExpr)
+		if !ok {
+			diags := syntax.Diagnostics{ExprError(args, fmt.Sprintf("the argument to %v must be an object containing 'string' and 'length'", name.Value))}
+			return PadSyntax(node, name, args, side, nil, nil, nil), diags
+		}
+
+		var str, length, pad Expr
+		for _, kvp := range obj.Entries {
+			switch kvp.Key.GetValue() {
+			case "string":
+				str = kvp.Value
+			case "length":
+				length = kvp.Value
+			case "pad":
+				pad = kvp.Value
+			}
+		}
+
+		var diags syntax.Diagnostics
+		if str == nil {
+			diags.Extend(ExprError(obj, "missing string ('string')"))
+		}
+		if length == nil {
+			diags.Extend(ExprError(obj, "missing target length ('length')"))
+		}
+
+		return PadSyntax(node, name, obj, side, str, length, pad), diags
+	}
+}
+
+func parseParseArn(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ParseArnSyntax(node, name, args), nil
+}
+
+func parseParseDuration(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::parseDuration must be an object containing 'duration'")}
+		return ParseDurationSyntax(node, name, args, nil, nil), diags
+	}
+
+	var duration, unit Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "duration":
+			duration = kvp.Value
+		case "unit":
+			unit = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if duration == nil {
+		diags.Extend(ExprError(obj, "missing duration ('duration')"))
+	}
+
+	return ParseDurationSyntax(node, name, obj, duration, unit), diags
+}
+
+func parseFormatTime(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::formatTime must be an object containing 'time', 'inputLayout', and 'outputLayout'")}
+		return FormatTimeSyntax(node, name, args, nil, nil, nil), diags
+	}
+
+	var time, inputLayout, outputLayout Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "time":
+			time = kvp.Value
+		case "inputLayout":
+			inputLayout = kvp.Value
+		case "outputLayout":
+			outputLayout = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if time == nil {
+		diags.Extend(ExprError(obj, "missing time ('time')"))
+	}
+	if inputLayout == nil {
+		diags.Extend(ExprError(obj, "missing input layout ('inputLayout')"))
+	}
+	if outputLayout == nil {
+		diags.Extend(ExprError(obj, "missing output layout ('outputLayout')"))
+	}
+
+	return FormatTimeSyntax(node, name, obj, time, inputLayout, outputLayout), diags
+}
+
+func parseParseSize(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ParseSizeSyntax(node, name, args), nil
+}
+
+func parseRandomString(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::randomString must be an object containing 'length'")}
+		return RandomStringSyntax(node, name, args, nil, nil), diags
+	}
+
+	var length, charset Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "length":
+			length = kvp.Value
+		case "charset":
+			charset = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if length == nil {
+		diags.Extend(ExprError(obj, "missing length ('length')"))
+	}
+
+	return RandomStringSyntax(node, name, obj, length, charset), diags
+}
+
+func parseURLEncode(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, value, mode, diags := parseURLArgs(name, args)
+	return URLEncodeSyntax(node, name, obj, value, mode), diags
+}
+
+func parseURLDecode(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, value, mode, diags := parseURLArgs(name, args)
+	return URLDecodeSyntax(node, name, obj, value, mode), diags
+}
+
+func parseURLArgs(name *StringExpr, args Expr) (Expr, Expr, Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, fmt.Sprintf("the argument to %v must be an object containing 'value'", name.Value))}
+		return args, nil, nil, diags
+	}
+
+	var value, mode Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "value":
+			value = kvp.Value
+		case "mode":
+			mode = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+
+	return obj, value, mode, diags
+}
+
+func parseURLJoin(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::urlJoin must be an object containing 'base' and 'segments'")}
+		return URLJoinSyntax(node, name, args, nil, nil), diags
+	}
+
+	var base, segments Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "base":
+			base = kvp.Value
+		case "segments":
+			segments = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if base == nil {
+		diags.Extend(ExprError(obj, "missing base URL ('base')"))
+	}
+	if segments == nil {
+		diags.Extend(ExprError(obj, "missing path segments ('segments')"))
+	}
+
+	return URLJoinSyntax(node, name, obj, base, segments), diags
+}
+
+func parseCapitalize(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return CapitalizeSyntax(node, name, args), nil
+}
+
+func parseTitle(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return TitleSyntax(node, name, args), nil
+}
+
+func parseChangeCase(style CaseStyle) func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return func(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+		return ChangeCaseSyntax(node, name, args, style), nil
+	}
+}
+
+func parseHMAC(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::hmac must be an object containing 'algorithm', 'key', and 'message'")}
+		return HMACSyntax(node, name, args, nil, nil, nil, nil), diags
+	}
+
+	var algorithm, key, message, encoding Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "algorithm":
+			algorithm = kvp.Value
+		case "key":
+			key = kvp.Value
+		case "message":
+			message = kvp.Value
+		case "encoding":
+			encoding = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if algorithm == nil {
+		diags.Extend(ExprError(obj, "missing algorithm ('algorithm')"))
+	}
+	if key == nil {
+		diags.Extend(ExprError(obj, "missing key ('key')"))
+	}
+	if message == nil {
+		diags.Extend(ExprError(obj, "missing message ('message')"))
+	}
+
+	return HMACSyntax(node, name, obj, algorithm, key, message, encoding), diags
+}
+
+func parseImport(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::import must be an object containing 'env' and 'path'")}
+		return ImportSyntax(node, name, args, nil, nil), diags
+	}
+
+	var environment, path Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "env":
+			environment = kvp.Value
+		case "path":
+			path = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if environment == nil {
+		diags.Extend(ExprError(obj, "missing environment name ('env')"))
+	}
+	if path == nil {
+		diags.Extend(ExprError(obj, "missing property path ('path')"))
+	}
+
+	return ImportSyntax(node, name, obj, environment, path), diags
+}
+
+func parseJWTDecode(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return JWTDecodeSyntax(node, name, args), nil
+}
+
+func parseToJSON(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ToJSONSyntax(node, name, args), nil
+}
+
+func parseEncodeQuery(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return EncodeQuerySyntax(node, name, args), nil
+}
+
+func parseImportRaw(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ImportRawSyntax(node, name, args), nil
+}
+
+func parseFromJSON(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return FromJSONSyntax(node, name, args), nil
+}
+
+func parseTypeOf(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return TypeOfSyntax(node, name, args), nil
+}
+
+func parseIsEmpty(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return IsEmptySyntax(node, name, args), nil
+}
+
+func parseToArray(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ToArraySyntax(node, name, args), nil
+}
+
+func parseToString(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ToStringSyntax(node, name, args), nil
+}
+
+func parseToBase64(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ToBase64Syntax(node, name, args), nil
+}
+
+func parseToBase64URL(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ToBase64URLSyntax(node, name, args), nil
+}
+
+func parseFromBase64(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return FromBase64Syntax(node, name, args), nil
+}
+
+func parseFromBase64URL(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return FromBase64URLSyntax(node, name, args), nil
+}
+
+func parseToHex(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return ToHexSyntax(node, name, args), nil
+}
+
+func parseFromHex(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return FromHexSyntax(node, name, args), nil
+}
+
+func parseRequire(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::require must be an object containing 'value' and 'message'")}
+		return RequireSyntax(node, name, args, nil, nil), diags
+	}
+
+	var value, message Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "value":
+			value = kvp.Value
+		case "message":
+			message = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+	if message == nil {
+		diags.Extend(ExprError(obj, "missing message ('message')"))
+	}
+
+	return RequireSyntax(node, name, obj, value, message), diags
+}
+
+func parseSample(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	return SampleSyntax(node, name, args), nil
+}
+
+func parseSecret(node *syntax.ObjectNode, name *StringExpr, value Expr) (Expr, syntax.Diagnostics) {
+	if arg, ok := value.(*ObjectExpr); ok && len(arg.Entries) == 1 {
+		kvp := arg.Entries[0]
+		if kvp.Key.Value == "ciphertext" {
+			if str, ok := kvp.Value.(*StringExpr); ok {
+				return CiphertextSyntax(node, name, arg, str), nil
+			}
+		}
+	}
+
+	var diags syntax.Diagnostics
+	str, ok := value.(*StringExpr)
+	if !ok {
+		str = String("")
+		diags = syntax.Diagnostics{ExprError(value, "secret values must be string literals")}
+	}
+	return PlaintextSyntax(node, name, str), diags
+}
+
+func parseSecretIf(node *syntax.ObjectNode, name *StringExpr, args Expr) (Expr, syntax.Diagnostics) {
+	obj, ok := args.(*ObjectExpr)
+	if !ok {
+		diags := syntax.Diagnostics{ExprError(args, "the argument to fn::secretIf must be an object containing 'condition' and 'value'")}
+		return SecretIfSyntax(node, name, args, nil, nil), diags
+	}
+
+	var condition, value Expr
+	for _, kvp := range obj.Entries {
+		switch kvp.Key.GetValue() {
+		case "condition":
+			condition = kvp.Value
+		case "value":
+			value = kvp.Value
+		}
+	}
+
+	var diags syntax.Diagnostics
+	if condition == nil {
+		diags.Extend(ExprError(obj, "missing condition ('condition')"))
+	}
+	if value == nil {
+		diags.Extend(ExprError(obj, "missing value ('value')"))
+	}
+
+	return SecretIfSyntax(node, name, obj, condition, value), diags
 }
@@ -279,7 +279,7 @@ func (p *propertyAccessParser) parseIndexSubscript(start int) any {
 	num, err := strconv.ParseInt(indexStr, 10, 0)
 	if err != nil {
 		// skip the opening brace when reporting the error.
-		p.error(start+1, "numeric subscript must be a positive base-10 integer")
+		p.error(start+1, "numeric subscript must be a base-10 integer")
 		return indexStr
 	}
 
@@ -296,7 +296,7 @@ func (p *propertyAccessParser) parseIndexSubscript(start int) any {
 //
 //	propertyName := [a-zA-Z_$] { [a-zA-Z0-9_$] }
 //	quotedPropertyName := '"' ( '\' '"' | [^"] ) { ( '\' '"' | [^"] ) } '"'
-//	arrayIndex := { [0-9] }
+//	arrayIndex := [ '-' ] { [0-9] }
 //
 //	propertyIndex := '[' ( quotedPropertyName | arrayIndex ) ']'
 //	rootProperty := ( propertyName | propertyIndex )
@@ -320,6 +320,24 @@ func (p *propertyAccessParser) parseIndexSubscript(start int) any {
 // - ["root key with \"escaped\" quotes"].nested
 // - ["root key with a ."][100]
 func parsePropertyAccess(node syntax.Node, start int, access string) (int, string, *PropertyAccess, syntax.Diagnostics) {
+	p := newPropertyAccessParser(node, start, access)
+	return p.parse()
+}
+
+// ParsePropertyPath parses a standalone property path (e.g. `foo.bar[0]["baz"]`) into a PropertyAccess value. The
+// grammar is the same as that accepted inside of an interpolation, but without the surrounding `${` and `}`.
+func ParsePropertyPath(path string) (*PropertyAccess, syntax.Diagnostics) {
+	node := syntax.String(path)
+
+	// The parser expects the access to be terminated by a closing brace, so we supply one.
+	_, rest, access, diags := newPropertyAccessParser(node, 0, path+"}").parse()
+	if rest != "" {
+		diags.Extend(syntax.NodeError(node, fmt.Sprintf("unexpected text %q following property path", rest)))
+	}
+	return access, diags
+}
+
+func newPropertyAccessParser(node syntax.Node, start int, access string) *propertyAccessParser {
 
 	// TODO: diagnostic ranges
 
@@ -328,11 +346,10 @@ func parsePropertyAccess(node syntax.Node, start int, access string) (int, strin
 		getRange = scalar.ScalarRange
 	}
 
-	p := &propertyAccessParser{
+	return &propertyAccessParser{
 		parent:   node,
 		getRange: getRange,
 		offset:   start,
 		text:     access,
 	}
-	return p.parse()
 }
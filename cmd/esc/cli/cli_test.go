@@ -126,6 +126,16 @@ func (tfs testFS) CreateTemp(dir, pattern string) (string, io.ReadWriteCloser, e
 	}
 }
 
+func (tfs testFS) Rename(oldpath, newpath string) error {
+	f, ok := tfs.MapFS[oldpath]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: fs.ErrNotExist}
+	}
+	tfs.MapFS[newpath] = f
+	delete(tfs.MapFS, oldpath)
+	return nil
+}
+
 func (tfs testFS) Remove(name string) error {
 	_, err := tfs.Stat(name)
 	if err != nil {
@@ -403,16 +413,37 @@ func (c *testPulumiClient) checkEnvironment(ctx context.Context, orgName, envNam
 	}
 
 	showSecrets := false
+	var parameters map[string]string
 	if len(opts) > 0 {
-		showSecrets = opts[0].ShowSecrets
+		showSecrets, parameters = opts[0].ShowSecrets, opts[0].Parameters
 	}
 
-	checked, checkDiags := eval.CheckEnvironment(ctx, envName, environment, rot128{}, providers, envLoader, execContext, showSecrets)
+	checked, checkDiags := eval.CheckEnvironment(ctx, envName, environment, rot128{}, providers, envLoader, execContext, showSecrets,
+		eval.EvalOptions{Parameters: parameterValues(parameters)})
 	diags.Extend(checkDiags...)
 	return checked, mapDiags(diags), nil
 }
 
-func (c *testPulumiClient) openEnvironment(ctx context.Context, orgName, name string, yaml []byte) (string, []client.EnvironmentDiagnostic, error) {
+// parameterValues converts the parameter values sent by the client to the values expected by the evaluator.
+func parameterValues(parameters map[string]string) map[string]esc.Value {
+	if len(parameters) == 0 {
+		return nil
+	}
+
+	values := make(map[string]esc.Value, len(parameters))
+	for name, value := range parameters {
+		values[name] = esc.NewValue(value)
+	}
+	return values
+}
+
+func (c *testPulumiClient) openEnvironment(
+	ctx context.Context,
+	orgName string,
+	name string,
+	yaml []byte,
+	parameters map[string]string,
+) (string, []client.EnvironmentDiagnostic, error) {
 	id, err := uuid.NewV4()
 	if err != nil {
 		return "", nil, err
@@ -434,7 +465,8 @@ func (c *testPulumiClient) openEnvironment(ctx context.Context, orgName, name st
 		return "", nil, fmt.Errorf("initializing the ESC exec context: %w", err)
 	}
 
-	openEnv, evalDiags := eval.EvalEnvironment(ctx, name, decl, rot128{}, providers, envLoader, execContext)
+	openEnv, evalDiags := eval.EvalEnvironment(ctx, name, decl, rot128{}, providers, envLoader, execContext,
+		eval.EvalOptions{Parameters: parameterValues(parameters)})
 	diags.Extend(evalDiags...)
 
 	if diags.HasErrors() {
@@ -664,13 +696,18 @@ func (c *testPulumiClient) OpenEnvironment(
 	envName string,
 	version string,
 	duration time.Duration,
+	opts ...client.OpenEnvironmentOption,
 ) (string, []client.EnvironmentDiagnostic, error) {
 	_, env, err := c.getEnvironment(orgName, projectName, envName, version)
 	if err != nil {
 		return "", nil, err
 	}
 
-	return c.openEnvironment(ctx, orgName, envName, env.yaml)
+	var parameters map[string]string
+	if len(opts) > 0 {
+		parameters = opts[0].Parameters
+	}
+	return c.openEnvironment(ctx, orgName, envName, env.yaml, parameters)
 }
 
 func (c *testPulumiClient) CheckYAMLEnvironment(
@@ -688,7 +725,7 @@ func (c *testPulumiClient) OpenYAMLEnvironment(
 	yaml []byte,
 	duration time.Duration,
 ) (string, []client.EnvironmentDiagnostic, error) {
-	return c.openEnvironment(ctx, orgName, "<yaml>", yaml)
+	return c.openEnvironment(ctx, orgName, "<yaml>", yaml, nil)
 }
 
 func (c *testPulumiClient) GetOpenEnvironment(ctx context.Context, orgName, envName, openEnvID string) (*esc.Environment, error) {
@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"path"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -45,6 +46,15 @@ const DefaultProject = "default"
 
 type CheckYAMLOption struct {
 	ShowSecrets bool
+
+	// Parameters supplies the values of the parameters declared by the environment, keyed by parameter name.
+	Parameters map[string]string
+}
+
+// OpenEnvironmentOption contains optional settings for OpenEnvironment.
+type OpenEnvironmentOption struct {
+	// Parameters supplies the values of the parameters declared by the environment, keyed by parameter name.
+	Parameters map[string]string
 }
 
 // Client provides a slim wrapper around the Pulumi HTTP/REST API.
@@ -153,6 +163,7 @@ type Client interface {
 		envName string,
 		version string,
 		duration time.Duration,
+		opts ...OpenEnvironmentOption,
 	) (string, []EnvironmentDiagnostic, error)
 
 	// CheckYAMLEnvironment checks the given environment YAML for errors within the context of org orgName.
@@ -586,6 +597,7 @@ func (pc *client) OpenEnvironment(
 	envName string,
 	version string,
 	duration time.Duration,
+	opts ...OpenEnvironmentOption,
 ) (string, []EnvironmentDiagnostic, error) {
 	path, err := pc.resolveEnvironmentPath(orgName, projectName, envName, version)
 	if err != nil {
@@ -594,9 +606,11 @@ func (pc *client) OpenEnvironment(
 	path += "/open"
 
 	queryObj := struct {
-		Duration string `url:"duration"`
+		Duration   string   `url:"duration"`
+		Parameters []string `url:"param,omitempty"`
 	}{
-		Duration: duration.String(),
+		Duration:   duration.String(),
+		Parameters: parameterQuery(firstOrDefault(opts).Parameters),
 	}
 
 	var resp struct {
@@ -632,10 +646,14 @@ func (pc *client) CheckYAMLEnvironment(
 
 	path := fmt.Sprintf("/api/esc/environments/%v/yaml/check", orgName)
 
+	opt := firstOrDefault(opts)
+
 	queryObj := struct {
-		ShowSecrets bool `url:"showSecrets"`
+		ShowSecrets bool     `url:"showSecrets"`
+		Parameters  []string `url:"param,omitempty"`
 	}{
-		ShowSecrets: firstOrDefault(opts).ShowSecrets,
+		ShowSecrets: opt.ShowSecrets,
+		Parameters:  parameterQuery(opt.Parameters),
 	}
 
 	var resp esc.Environment
@@ -1218,6 +1236,20 @@ func IsNotFound(err error) bool {
 	return ok && resp.Code == http.StatusNotFound
 }
 
+// parameterQuery encodes parameter values as name=value pairs in a stable order.
+func parameterQuery(parameters map[string]string) []string {
+	if len(parameters) == 0 {
+		return nil
+	}
+
+	pairs := make([]string, 0, len(parameters))
+	for name, value := range parameters {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
 func firstOrDefault[T any](ts []T) (t T) {
 	if len(ts) > 0 {
 		return ts[0]
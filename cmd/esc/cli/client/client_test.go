@@ -383,6 +383,24 @@ func TestOpenEnvironment(t *testing.T) {
 		assert.Empty(t, diags)
 	})
 
+	t.Run("Parameters", func(t *testing.T) {
+		const expectedID = "open-id"
+		duration := 2 * time.Hour
+
+		client := newTestClient(t, http.MethodPost, "/api/esc/environments/test-org/test-project/test-env/open", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, []string{"count=3", "region=us-east-1"}, r.URL.Query()["param"])
+
+			err := json.NewEncoder(w).Encode(map[string]any{"id": expectedID})
+			require.NoError(t, err)
+		})
+
+		id, diags, err := client.OpenEnvironment(context.Background(), "test-org", "test-project", "test-env", "", duration,
+			OpenEnvironmentOption{Parameters: map[string]string{"region": "us-east-1", "count": "3"}})
+		require.NoError(t, err)
+		assert.Equal(t, expectedID, id)
+		assert.Empty(t, diags)
+	})
+
 	t.Run("Revision", func(t *testing.T) {
 		const expectedID = "open-id"
 		duration := 2 * time.Hour
@@ -496,6 +514,22 @@ func TestCheckYAMLEnvironment(t *testing.T) {
 		assert.Empty(t, diags)
 	})
 
+	t.Run("Parameters", func(t *testing.T) {
+		yaml := []byte(`{"values":{"foo":"bar"}}`)
+
+		client := newTestClient(t, http.MethodPost, "/api/esc/environments/test-org/yaml/check", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, []string{"region=us-east-1"}, r.URL.Query()["param"])
+
+			err := json.NewEncoder(w).Encode(&esc.Environment{})
+			require.NoError(t, err)
+		})
+
+		_, diags, err := client.CheckYAMLEnvironment(context.Background(), "test-org", yaml,
+			CheckYAMLOption{Parameters: map[string]string{"region": "us-east-1"}})
+		require.NoError(t, err)
+		assert.Empty(t, diags)
+	})
+
 	t.Run("Diags", func(t *testing.T) {
 		yaml := []byte(`arbitrary`)
 
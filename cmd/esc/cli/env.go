@@ -66,6 +66,7 @@ func newEnvCmd(esc *escCommand) *cobra.Command {
 	cmd.AddCommand(newEnvInitCmd(env))
 	cmd.AddCommand(newEnvCloneCmd(env))
 	cmd.AddCommand(newEnvEditCmd(env))
+	cmd.AddCommand(newEnvCheckCmd(env))
 	cmd.AddCommand(newEnvGetCmd(env))
 	cmd.AddCommand(newEnvDiffCmd(env))
 	cmd.AddCommand(newEnvSetCmd(env))
@@ -302,6 +303,23 @@ func (cmd *envCommand) getExistingEnvRefWithRelative(
 	return ref, nil
 }
 
+// parseParameters parses the name=value parameter assignments given by repeated --param flags.
+func parseParameters(params []string) (map[string]string, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	parameters := make(map[string]string, len(params))
+	for _, p := range params {
+		name, value, ok := strings.Cut(p, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid parameter %q: expected name=value", p)
+		}
+		parameters[name] = value
+	}
+	return parameters, nil
+}
+
 func sortEnvironmentDiagnostics(diags []client.EnvironmentDiagnostic) {
 	sort.Slice(diags, func(i, j int) bool {
 		di, dj := diags[i], diags[j]
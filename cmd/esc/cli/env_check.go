@@ -0,0 +1,102 @@
+// Copyright 2024, Pulumi Corporation.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/esc/cmd/esc/cli/client"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// anonymousEnvironmentName is the name given to environments that are checked or opened from a YAML definition.
+const anonymousEnvironmentName = "<yaml>"
+
+func newEnvCheckCmd(env *envCommand) *cobra.Command {
+	var orgName string
+	var params []string
+
+	cmd := &cobra.Command{
+		Use:   "check <file>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Check an environment definition without opening it.",
+		Long: "Check an environment definition without opening it\n" +
+			"\n" +
+			"This command statically validates the environment definition in the given file.\n" +
+			"Literals are checked against their schemas, references are resolved, and the\n" +
+			"arguments to builtin functions are type-checked. Providers are not opened, so no\n" +
+			"credentials are issued. Imports are resolved within the organization given by\n" +
+			"the --organization flag, which defaults to your default organization.\n" +
+			"\n" +
+			"The --param flag supplies the value of a parameter declared by the definition in\n" +
+			"the form name=value. The flag may be repeated. Supplied values are validated\n" +
+			"against the parameters' schemas.\n" +
+			"\n" +
+			"Pass `-` to read the definition from standard input. The command exits with a\n" +
+			"non-zero status if the definition contains errors.\n",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if err := env.esc.getCachedClient(ctx); err != nil {
+				return err
+			}
+
+			if orgName == "" {
+				orgName = env.esc.account.DefaultOrg
+			}
+
+			parameters, err := parseParameters(params)
+			if err != nil {
+				return err
+			}
+
+			file := args[0]
+
+			var yaml []byte
+			switch file {
+			case "-":
+				yaml, err = io.ReadAll(env.esc.stdin)
+			default:
+				yaml, err = fs.ReadFile(env.esc.fs, file)
+			}
+			if err != nil {
+				return fmt.Errorf("reading environment definition: %w", err)
+			}
+
+			_, diags, err := env.esc.client.CheckYAMLEnvironment(ctx, orgName, yaml, client.CheckYAMLOption{Parameters: parameters})
+			if err != nil {
+				return fmt.Errorf("checking environment definition: %w", err)
+			}
+			if len(diags) != 0 {
+				// Attribute diagnostics in the checked definition to the file that contains it.
+				for _, d := range diags {
+					if d.Range != nil && d.Range.Environment == anonymousEnvironmentName {
+						d.Range.Environment = file
+					}
+				}
+
+				err = env.writeYAMLEnvironmentDiagnostics(env.esc.stderr, file, yaml, diags)
+				contract.IgnoreError(err)
+
+				return fmt.Errorf("checking environment definition: too many errors")
+			}
+
+			fmt.Fprintln(env.esc.stdout, "Environment definition is valid.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(
+		&orgName, "organization", "o", "", "the organization in which to resolve imports")
+	cmd.Flags().StringArrayVar(
+		&params, "param", nil,
+		"the value of a parameter in the form name=value, e.g. 'region=us-east-1'. May be repeated")
+
+	return cmd
+}
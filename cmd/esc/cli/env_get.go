@@ -5,7 +5,6 @@ package cli
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
@@ -136,6 +135,17 @@ func marshalYAML(v any) (string, error) {
 	return b.String(), nil
 }
 
+// encodeValueJSON renders a value as indented JSON without a trailing newline. The value is encoded directly rather
+// than first being converted to its JSON representation, but the result is still buffered, as the template that
+// renders the output of `esc env get` requires the entire document.
+func encodeValueJSON(v esc.Value, showSecrets bool) (string, error) {
+	var b strings.Builder
+	if err := v.EncodeJSON(&b, !showSecrets, "  "); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
 func (get *envGetCommand) writeValue(
 	ctx context.Context,
 	out io.Writer,
@@ -248,7 +258,7 @@ func (get *envGetCommand) getEntireEnvironment(
 		return nil, nil
 	}
 
-	envJSON, err := json.MarshalIndent(esc.NewValue(env.Properties).ToJSON(!showSecrets), "", "  ")
+	envJSON, err := encodeValueJSON(esc.NewValue(env.Properties), showSecrets)
 	if err != nil {
 		return nil, fmt.Errorf("encoding value: %w", err)
 	}
@@ -259,7 +269,7 @@ func (get *envGetCommand) getEntireEnvironment(
 	}
 
 	return &envGetTemplateData{
-		Value:      string(envJSON),
+		Value:      envJSON,
 		Definition: defYAML,
 	}, nil
 }
@@ -305,11 +315,10 @@ func (get *envGetCommand) getEnvironmentMember(
 	if value != nil {
 		stacker = &stackableValue{v: value}
 
-		j, err := json.MarshalIndent(value.ToJSON(!showSecrets), "", "  ")
+		valueJSON, err = encodeValueJSON(*value, showSecrets)
 		if err != nil {
 			return nil, fmt.Errorf("encoding value: %w", err)
 		}
-		valueJSON = string(j)
 	}
 
 	definitionYAML := ""
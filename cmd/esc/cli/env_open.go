@@ -5,12 +5,14 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"time"
 
 	"github.com/pulumi/esc"
 	"github.com/pulumi/esc/cmd/esc/cli/client"
+	"github.com/pulumi/esc/eval"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -19,6 +21,10 @@ import (
 func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 	var duration time.Duration
 	var format string
+	var valuePath string
+	var overlays []string
+	var outputFile string
+	var params []string
 
 	cmd := &cobra.Command{
 		Use:   "open [<org-name>/][<project-name>/]<environment-name>[@<version>] [property path]",
@@ -27,7 +33,23 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 		Long: "Open the environment with the given name and return the result\n" +
 			"\n" +
 			"This command opens the environment with the given name. The result is written to\n" +
-			"stdout as JSON. If a property path is specified, only retrieves that property.\n",
+			"stdout as JSON. If a property path is specified, only retrieves that property.\n" +
+			"\n" +
+			"The --value flag projects the result onto the value at the given path. The path\n" +
+			"uses the same syntax as interpolations (e.g. `config.aws.roleArn` or `list[0].id`).\n" +
+			"Unlike the property path argument, it is an error if the path does not exist.\n" +
+			"\n" +
+			"The --overlay flag merges another environment onto the opened environment as if\n" +
+			"both were imported in order. The flag may be repeated; later overlays take\n" +
+			"precedence. Overlays must belong to the same organization as the environment.\n" +
+			"\n" +
+			"The --param flag supplies the value of a parameter declared by the environment in\n" +
+			"the form name=value. The flag may be repeated. Supplied values are validated\n" +
+			"against the parameters' schemas. Parameters may not be used with --overlay.\n" +
+			"\n" +
+			"The --output-file flag writes the result to the given file instead of stdout. The\n" +
+			"file is only readable and writable by the current user, and is replaced atomically:\n" +
+			"if the result cannot be written, any existing file is left untouched.\n",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
@@ -50,19 +72,36 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 				}
 				path = p
 			}
+			if valuePath != "" && len(path) != 0 {
+				return errors.New("--value may not be used with a property path")
+			}
+
+			parameters, err := parseParameters(params)
+			if err != nil {
+				return err
+			}
+			if len(parameters) != 0 && len(overlays) != 0 {
+				return errors.New("--param may not be used with --overlay")
+			}
 
 			switch format {
 			case "detailed", "json", "yaml", "string":
 				// OK
 			case "dotenv", "shell":
-				if len(path) != 0 {
+				if len(path) != 0 || valuePath != "" {
 					return fmt.Errorf("output format '%s' may not be used with a property path", format)
 				}
 			default:
 				return fmt.Errorf("unknown output format %q", format)
 			}
 
-			env, diags, err := envcmd.openEnvironment(ctx, ref, duration)
+			var env *esc.Environment
+			var diags []client.EnvironmentDiagnostic
+			if len(overlays) != 0 {
+				env, diags, err = envcmd.openOverlay(ctx, ref, overlays, duration)
+			} else {
+				env, diags, err = envcmd.openEnvironment(ctx, ref, duration, parameters)
+			}
 			if err != nil {
 				return err
 			}
@@ -70,7 +109,16 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 				return envcmd.writePropertyEnvironmentDiagnostics(envcmd.esc.stderr, diags)
 			}
 
-			return envcmd.renderValue(envcmd.esc.stdout, env, path, format, false, true)
+			render := func(out io.Writer) error {
+				if valuePath != "" {
+					return envcmd.renderQuery(out, env, valuePath, format, true)
+				}
+				return envcmd.renderValue(out, env, path, format, false, true)
+			}
+			if outputFile != "" {
+				return writeFileAtomic(envcmd.esc.fs, outputFile, render)
+			}
+			return render(envcmd.esc.stdout)
 		},
 	}
 
@@ -80,10 +128,35 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 	cmd.Flags().StringVarP(
 		&format, "format", "f", "json",
 		"the output format to use. May be 'dotenv', 'json', 'yaml', 'detailed', or 'shell'")
+	cmd.Flags().StringVar(
+		&valuePath, "value", "",
+		"the path of a single value to print, e.g. 'config.aws.roleArn' or 'list[0].id'")
+	cmd.Flags().StringArrayVar(
+		&overlays, "overlay", nil,
+		"an environment to merge onto the opened environment. May be repeated; later overlays take precedence")
+	cmd.Flags().StringVar(
+		&outputFile, "output-file", "",
+		"the file to write the result to instead of stdout. The file is created with 0600 permissions")
+	cmd.Flags().StringArrayVar(
+		&params, "param", nil,
+		"the value of a parameter in the form name=value, e.g. 'region=us-east-1'. May be repeated")
 
 	return cmd
 }
 
+// renderQuery renders the value at the given path within an environment. The path is resolved using eval.Query.
+func (env *envCommand) renderQuery(out io.Writer, e *esc.Environment, path, format string, showSecrets bool) error {
+	if e == nil {
+		return nil
+	}
+
+	val, err := eval.Query(esc.NewValue(e.Properties), path)
+	if err != nil {
+		return err
+	}
+	return renderFormattedValue(out, val, format, showSecrets)
+}
+
 func (env *envCommand) renderValue(
 	out io.Writer,
 	e *esc.Environment,
@@ -106,20 +179,6 @@ func (env *envCommand) renderValue(
 	}
 
 	switch format {
-	case "json":
-		body := val.ToJSON(!showSecrets)
-		enc := json.NewEncoder(out)
-		enc.SetIndent("", "  ")
-		return enc.Encode(body)
-	case "yaml":
-		body := val.ToJSON(!showSecrets)
-		enc := yaml.NewEncoder(out)
-		enc.SetIndent(3)
-		return enc.Encode(body)
-	case "detailed":
-		enc := json.NewEncoder(out)
-		enc.SetIndent("", "  ")
-		return enc.Encode(val)
 	case "dotenv":
 		_, environ, _, err := env.prepareEnvironment(e, PrepareOptions{Pretend: pretend, Quote: true, Redact: !showSecrets})
 		if err != nil {
@@ -138,6 +197,26 @@ func (env *envCommand) renderValue(
 			fmt.Fprintf(out, "export %v\n", kvp)
 		}
 		return nil
+	default:
+		return renderFormattedValue(out, val, format, showSecrets)
+	}
+}
+
+// renderFormattedValue renders a single value in one of the formats that do not depend on the shape of the environment
+// (i.e. 'json', 'yaml', 'detailed', or 'string').
+func renderFormattedValue(out io.Writer, val esc.Value, format string, showSecrets bool) error {
+	switch format {
+	case "json":
+		return val.EncodeJSON(out, !showSecrets, "  ")
+	case "yaml":
+		body := val.ToJSON(!showSecrets)
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(3)
+		return enc.Encode(body)
+	case "detailed":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(val)
 	case "string":
 		fmt.Fprintf(out, "%v\n", val.ToString(!showSecrets))
 		return nil
@@ -163,8 +242,10 @@ func (env *envCommand) openEnvironment(
 	ctx context.Context,
 	ref environmentRef,
 	duration time.Duration,
+	parameters map[string]string,
 ) (*esc.Environment, []client.EnvironmentDiagnostic, error) {
-	envID, diags, err := env.esc.client.OpenEnvironment(ctx, ref.orgName, ref.projectName, ref.envName, ref.version, duration)
+	envID, diags, err := env.esc.client.OpenEnvironment(ctx, ref.orgName, ref.projectName, ref.envName, ref.version, duration,
+		client.OpenEnvironmentOption{Parameters: parameters})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -174,3 +255,39 @@ func (env *envCommand) openEnvironment(
 	open, err := env.esc.client.GetOpenEnvironmentWithProject(ctx, ref.orgName, ref.projectName, ref.envName, envID)
 	return open, nil, err
 }
+
+// openOverlay opens an anonymous environment that imports the given environment followed by each of the overlays.
+// This merges the overlays onto the environment without requiring an imports block in either definition.
+func (env *envCommand) openOverlay(
+	ctx context.Context,
+	ref environmentRef,
+	overlays []string,
+	duration time.Duration,
+) (*esc.Environment, []client.EnvironmentDiagnostic, error) {
+	imports := []string{ref.Id()}
+	for _, o := range overlays {
+		overlay, err := env.getExistingEnvRefWithRelative(ctx, o, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		if overlay.orgName != ref.orgName {
+			return nil, nil, fmt.Errorf("overlay %v must belong to organization %v", o, ref.orgName)
+		}
+		imports = append(imports, overlay.Id())
+	}
+
+	def, err := yaml.Marshal(map[string]any{"imports": imports})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envID, diags, err := env.esc.client.OpenYAMLEnvironment(ctx, ref.orgName, def, duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(diags) != 0 {
+		return nil, diags, err
+	}
+	open, err := env.esc.client.GetAnonymousOpenEnvironment(ctx, ref.orgName, envID)
+	return open, nil, err
+}
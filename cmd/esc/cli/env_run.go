@@ -144,7 +144,7 @@ func newEnvRunCmd(envcmd *envCommand) *cobra.Command {
 			}
 			args = args[1:]
 
-			env, diags, err := envcmd.openEnvironment(ctx, ref, duration)
+			env, diags, err := envcmd.openEnvironment(ctx, ref, duration, nil)
 			if err != nil {
 				return err
 			}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/pulumi/esc/cmd/esc/cli/workspace"
 )
@@ -16,6 +17,7 @@ type escFS interface {
 
 	CreateTemp(dir, pattern string) (string, io.ReadWriteCloser, error)
 	Remove(name string) error
+	Rename(oldpath, newpath string) error
 }
 
 type defaultFS struct {
@@ -41,3 +43,31 @@ func (defaultFS) CreateTemp(dir, pattern string) (string, io.ReadWriteCloser, er
 func (defaultFS) Remove(name string) error {
 	return os.Remove(name)
 }
+
+func (defaultFS) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// writeFileAtomic writes the output of render to the file at the given path. The output is first written to a
+// temporary file in the same directory, which is only readable and writable by the current user (0600), and the
+// temporary file is then renamed over the target. If render fails, the temporary file is removed and any existing file
+// at the target path is left untouched.
+func writeFileAtomic(fs escFS, path string, render func(w io.Writer) error) error {
+	temp, f, err := fs.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+
+	err = render(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = fs.Rename(temp, path)
+	}
+	if err != nil {
+		removeTemporaryFiles(fs, []string{temp})
+		return err
+	}
+	return nil
+}
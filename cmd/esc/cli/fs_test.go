@@ -0,0 +1,135 @@
+// Copyright 2024, Pulumi Corporation.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		tfs := testFS{MapFS: fstest.MapFS{
+			"out/.env": &fstest.MapFile{Data: []byte("OLD=1\n"), Mode: 0o644},
+		}}
+
+		err := writeFileAtomic(tfs, "out/.env", func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, "NEW=2")
+			return err
+		})
+		require.NoError(t, err)
+
+		f, ok := tfs.MapFS["out/.env"]
+		require.True(t, ok)
+		assert.Equal(t, "NEW=2\n", string(f.Data))
+		assert.Equal(t, fs.FileMode(0o600), f.Mode)
+		assert.Len(t, tfs.MapFS, 1)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		tfs := testFS{MapFS: fstest.MapFS{
+			"out/.env": &fstest.MapFile{Data: []byte("OLD=1\n"), Mode: 0o600},
+		}}
+
+		err := writeFileAtomic(tfs, "out/.env", func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, "NEW=2")
+			require.NoError(t, err)
+			return errors.New("oops")
+		})
+		assert.EqualError(t, err, "oops")
+
+		// The existing file must not be clobbered, and the temporary file must be removed.
+		f, ok := tfs.MapFS["out/.env"]
+		require.True(t, ok)
+		assert.Equal(t, "OLD=1\n", string(f.Data))
+		assert.Len(t, tfs.MapFS, 1)
+	})
+}
+
+func TestWriteFileAtomicOS(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on Windows")
+	}
+
+	write := func(data string) func(w io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, data)
+			return err
+		}
+	}
+
+	// assertFile asserts that the file at path has the given contents and mode, and that it is the only file in its
+	// directory.
+	assertFile := func(t *testing.T, path, data string, mode fs.FileMode) {
+		actual, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, data, string(actual))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, mode, info.Mode().Perm())
+
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+
+		err := writeFileAtomic(newFS(), path, write("NEW=2\n"))
+		require.NoError(t, err)
+		assertFile(t, path, "NEW=2\n", 0o600)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+		require.NoError(t, os.WriteFile(path, []byte("OLD=1\n"), 0o644))
+
+		// The replacement is only readable and writable by the current user, regardless of the existing file's mode.
+		err := writeFileAtomic(newFS(), path, write("NEW=2\n"))
+		require.NoError(t, err)
+		assertFile(t, path, "NEW=2\n", 0o600)
+	})
+
+	t.Run("render error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+		require.NoError(t, os.WriteFile(path, []byte("OLD=1\n"), 0o644))
+
+		err := writeFileAtomic(newFS(), path, func(w io.Writer) error {
+			_, err := io.WriteString(w, "NEW=2\n")
+			require.NoError(t, err)
+			return errors.New("oops")
+		})
+		assert.EqualError(t, err, "oops")
+
+		// The existing file and its mode are left untouched, and the temporary file is removed.
+		assertFile(t, path, "OLD=1\n", 0o644)
+	})
+
+	t.Run("rename error", func(t *testing.T) {
+		// Renaming a file over a non-empty directory fails.
+		path := filepath.Join(t.TempDir(), ".env")
+		require.NoError(t, os.Mkdir(path, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(path, "child"), nil, 0o600))
+
+		err := writeFileAtomic(newFS(), path, write("NEW=2\n"))
+		assert.Error(t, err)
+
+		// The temporary file is removed.
+		entries, err := os.ReadDir(filepath.Dir(path))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, ".env", entries[0].Name())
+	})
+}
@@ -73,6 +73,32 @@ func sortByEditDistance(words []string, comparedTo string) []string {
 	return w
 }
 
+// Suggest returns the word in words that is closest to the given word, if any word is close enough to be a plausible
+// misspelling. A word is close enough if its edit distance from the given word is at most a third of the given word's
+// length (and at least one).
+func Suggest(words []string, word string) (string, bool) {
+	maxDistance := len(word) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	sorted := sortByEditDistance(words, word)
+	if len(sorted) == 0 || sorted[0] == word || editDistance(sorted[0], word) > maxDistance {
+		return "", false
+	}
+	return sorted[0], true
+}
+
+// Nearest returns the word in words that is closest to the given word, regardless of distance. Ties are broken in
+// favor of the lexically smallest word. Nearest returns false if words is empty.
+func Nearest(words []string, word string) (string, bool) {
+	sorted := sortByEditDistance(words, word)
+	if len(sorted) == 0 {
+		return "", false
+	}
+	return sorted[0], true
+}
+
 // A list that displays in the human readable format: "a, b and c".
 type AndList []string
 
@@ -54,6 +54,49 @@ func TestSortByEditDistance(t *testing.T) {
 	}
 }
 
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		words    []string
+		word     string
+		expected string
+		ok       bool
+	}{
+		{[]string{}, "config", "", false},
+		{[]string{"config", "context", "imports"}, "confgi", "config", true},
+		{[]string{"config", "context", "imports"}, "contxt", "context", true},
+		{[]string{"config", "context", "imports"}, "config", "", false},
+		{[]string{"config", "context", "imports"}, "region", "", false},
+		{[]string{"a", "b"}, "c", "a", true},
+	}
+	for _, c := range cases {
+		actual, ok := Suggest(c.words, c.word)
+		assert.Equalf(t, c.expected, actual, "Suggest(%v, %v)", c.words, c.word)
+		assert.Equalf(t, c.ok, ok, "Suggest(%v, %v)", c.words, c.word)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		words    []string
+		word     string
+		expected string
+		ok       bool
+	}{
+		{[]string{}, "config", "", false},
+		{[]string{"config", "context", "imports"}, "config", "config", true},
+		{[]string{"config", "context", "imports"}, "confgi", "config", true},
+		{[]string{"config", "context", "imports"}, "region", "config", true},
+		{[]string{"b", "a"}, "c", "a", true},
+	}
+	for _, c := range cases {
+		actual, ok := Nearest(c.words, c.word)
+		assert.Equalf(t, c.expected, actual, "Nearest(%v, %v)", c.words, c.word)
+		assert.Equalf(t, c.ok, ok, "Nearest(%v, %v)", c.words, c.word)
+	}
+}
+
 func TestDisplayList(t *testing.T) {
 	t.Parallel()
 	cases := []struct {
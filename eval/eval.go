@@ -15,22 +15,42 @@
 package eval
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"net/url"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/blang/semver"
 	"github.com/pulumi/esc"
 	"github.com/pulumi/esc/ast"
+	yamldiags "github.com/pulumi/esc/diags"
 	"github.com/pulumi/esc/internal/util"
 	"github.com/pulumi/esc/schema"
 	"github.com/pulumi/esc/syntax"
 	"github.com/pulumi/esc/syntax/encoding"
 	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 )
 
 // A ProviderLoader provides the environment evaluator the capability to load providers.
@@ -39,6 +59,16 @@ type ProviderLoader interface {
 	LoadProvider(ctx context.Context, name string) (esc.Provider, error)
 }
 
+// A ProviderLister is a ProviderLoader that can enumerate the names of the providers it is able to load. If the
+// evaluator's ProviderLoader is a ProviderLister, references to unknown providers include a suggestion for the
+// provider that was most likely intended.
+type ProviderLister interface {
+	ProviderLoader
+
+	// ProviderNames returns the names of the providers that the loader is able to load.
+	ProviderNames() []string
+}
+
 // An EnvironmentLoader provides the environment evaluator the capability to load imported environment definitions.
 type EnvironmentLoader interface {
 	// LoadEnvironment loads the definition for the environment with the given name.
@@ -69,7 +99,162 @@ func LoadYAMLBytes(filename string, source []byte) (*ast.EnvironmentDecl, syntax
 	return t, diags, nil
 }
 
+// EvalOptions contains optional settings for environment evaluation and checking.
+type EvalOptions struct {
+	// BuiltinArgValues causes the evaluated value of each builtin's argument to be attached to the builtin's exported
+	// expression (see esc.BuiltinExpr.ArgValue). This is primarily useful for debugging.
+	BuiltinArgValues bool
+
+	// ShowSecretArgValues disables the redaction of secrets within exported builtin argument values.
+	ShowSecretArgValues bool
+
+	// Provenance causes the range of the expression that ultimately defined the value of each non-list, non-object
+	// expression to be attached to the expression's export (see esc.Expr.Provenance). This is primarily useful for
+	// editor tooling, e.g. hover tooltips.
+	Provenance bool
+
+	// OpenCapture, if non-nil, receives a record of each provider call made by fn::open during evaluation, including
+	// calls made by imported environments. This is primarily useful for debugging.
+	OpenCapture *OpenCapture
+
+	// Random enables nondeterministic builtins such as fn::randomString and fn::sample, which read their randomness
+	// from it. These builtins fail to evaluate if Random is nil. Callers should normally use crypto/rand.Reader; a
+	// seeded source produces deterministic results, which is primarily useful for testing.
+	Random io.Reader
+
+	// Metrics, if non-nil, receives the number of calls to and the time spent in each builtin and provider during
+	// evaluation, including evaluation of imported environments. Metrics are not collected if Metrics is nil. This is
+	// primarily useful for performance analysis.
+	Metrics *EvalMetrics
+
+	// Observer, if non-nil, is called each time an expression is evaluated, including expressions in imported
+	// environments. Observer is called synchronously by the evaluator and must not modify the observation's value.
+	// This is primarily useful for building debuggers and tracers.
+	Observer func(Observation)
+
+	// CoerceStrings enables the coercion of strings passed to builtins and providers. If a string is passed where a
+	// number or boolean is expected and the string represents a value of the expected type (e.g. "42" or "true"), the
+	// string is replaced with that value. Strings that do not represent a value of the expected type are reported as
+	// type errors as usual.
+	CoerceStrings bool
+
+	// JSONPointerPaths causes the paths of values that fail validation to be rendered as RFC 6901 JSON Pointers (e.g.
+	// `/a/b/0`) rather than as property paths (e.g. `a.b[0]`). This is primarily useful for interop with JSON Schema
+	// tooling.
+	JSONPointerPaths bool
+
+	// Parameters supplies the values of the parameters declared by the environment. Each value is validated against
+	// its parameter's schema. It is an error to supply a value for a parameter that the environment does not declare.
+	// Parameters are only supplied to the environment being evaluated: the parameters of imported environments take
+	// their default values.
+	Parameters map[string]esc.Value
+
+	// Keys, if non-empty, restricts evaluation to the given top-level properties and the values they refer to. The
+	// result contains only the requested properties, and builtins that are not reachable from those properties
+	// (including calls to fn::open) are not evaluated. Requested properties that are not defined are omitted from the
+	// result. Imported environments are always evaluated in full.
+	Keys []string
+
+	// OmitHidden causes values whose schemas are marked hidden (see schema.Schema.Hidden) to be omitted from the
+	// environment's exported properties. Hidden values are still evaluated, and may be referred to by other values. The
+	// schemas of fn::open outputs and of parameters are consulted.
+	OmitHidden bool
+
+	// RejectUnknowns causes evaluation to fail if the environment's exported properties contain unknown values, e.g.
+	// the outputs of providers that could not be fully resolved. The error lists the path of each unknown value. Paths
+	// are rendered as JSON Pointers if JSONPointerPaths is set. Note that checked environments usually contain unknown
+	// values, so this option is primarily useful with EvalEnvironment.
+	RejectUnknowns bool
+
+	// MaxImportDepth, if positive, limits the length of chains of imports. The environment being evaluated is at depth
+	// zero, its imports are at depth one, and so on. An import that would exceed the limit is reported as an error at
+	// the import, and the imported environment is not evaluated. Imports via fn::import count towards the limit.
+	MaxImportDepth int
+}
+
+// An Observation describes the evaluation of a single expression.
+type Observation struct {
+	// Environment is the name of the environment that contains the expression.
+	Environment string
+
+	// Path is the path of the expression within its environment. The path is empty for expressions that are not
+	// addressable, such as the arguments to builtins.
+	Path string
+
+	// Value is the value of the expression. Secret values are redacted.
+	Value esc.Value
+
+	// Cached is true if the expression had already been evaluated and its previous value was reused.
+	Cached bool
+}
+
+// An OpenCapture collects the provider calls made by fn::open during evaluation.
+type OpenCapture struct {
+	Calls []OpenCall
+}
+
+// An OpenCall records a single call to fn::open. Provider outputs are not recorded.
+type OpenCall struct {
+	// Environment is the name of the environment that contains the call.
+	Environment string `json:"environment"`
+
+	// Range is the location of the call.
+	Range esc.Range `json:"range"`
+
+	// Provider is the name of the provider.
+	Provider string `json:"provider"`
+
+	// Inputs holds the inputs to the provider. Secret inputs are redacted.
+	Inputs esc.Value `json:"inputs"`
+
+	// Schema is the provider's output schema.
+	Schema *schema.Schema `json:"schema,omitempty"`
+}
+
+// EvalMetrics collects timing information about evaluation.
+type EvalMetrics struct {
+	// Builtins holds the timing of calls to each builtin by name (e.g. "fn::join"). The time spent in a call includes
+	// the time spent evaluating its arguments, so the timings of nested calls overlap.
+	Builtins map[string]*Timing `json:"builtins,omitempty"`
+
+	// Opens holds the timing of calls to each provider by name. Only the time spent within the provider's Open method
+	// is included.
+	Opens map[string]*Timing `json:"opens,omitempty"`
+}
+
+// A Timing records the number of times an operation was performed and the total time spent performing it.
+type Timing struct {
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+}
+
+// record records a single operation that began at the given time.
+func record(timings *map[string]*Timing, name string, start time.Time) {
+	if *timings == nil {
+		*timings = map[string]*Timing{}
+	}
+	t, ok := (*timings)[name]
+	if !ok {
+		t = &Timing{}
+		(*timings)[name] = t
+	}
+	t.Count++
+	t.Total += time.Since(start)
+}
+
+// firstOrDefault returns the first element of opts, or the zero value if opts is empty.
+func firstOrDefault(opts []EvalOptions) EvalOptions {
+	if len(opts) == 0 {
+		return EvalOptions{}
+	}
+	return opts[0]
+}
+
 // EvalEnvironment evaluates the given environment.
+//
+// Evaluation is best-effort: if a call to fn::open fails, the failure is reported as a diagnostic and the call
+// evaluates to an unknown value. Evaluation then continues, so the returned diagnostics describe every failure rather
+// than only the first.
 func EvalEnvironment(
 	ctx context.Context,
 	name string,
@@ -78,8 +263,9 @@ func EvalEnvironment(
 	providers ProviderLoader,
 	environments EnvironmentLoader,
 	execContext *esc.ExecContext,
+	opts ...EvalOptions,
 ) (*esc.Environment, syntax.Diagnostics) {
-	return evalEnvironment(ctx, false, name, env, decrypter, providers, environments, execContext, true)
+	return evalEnvironment(ctx, false, name, env, decrypter, providers, environments, execContext, true, firstOrDefault(opts))
 }
 
 // CheckEnvironment symbolically evaluates the given environment. Calls to fn::open are not invoked, and instead
@@ -93,8 +279,9 @@ func CheckEnvironment(
 	environments EnvironmentLoader,
 	execContext *esc.ExecContext,
 	showSecrets bool,
+	opts ...EvalOptions,
 ) (*esc.Environment, syntax.Diagnostics) {
-	return evalEnvironment(ctx, true, name, env, decrypter, providers, environments, execContext, showSecrets)
+	return evalEnvironment(ctx, true, name, env, decrypter, providers, environments, execContext, showSecrets, firstOrDefault(opts))
 }
 
 // evalEnvironment evaluates an environment and exports the result of evaluation.
@@ -108,12 +295,22 @@ func evalEnvironment(
 	envs EnvironmentLoader,
 	execContext *esc.ExecContext,
 	showSecrets bool,
+	opts EvalOptions,
 ) (*esc.Environment, syntax.Diagnostics) {
-	if env == nil || (len(env.Values.GetEntries()) == 0 && len(env.Imports.GetElements()) == 0) {
+	if env == nil || (len(env.Values.GetEntries()) == 0 && len(env.Imports.GetElements()) == 0 && env.Parameters == nil) {
 		return nil, nil
 	}
 
 	ec := newEvalContext(ctx, validating, name, env, decrypter, providers, envs, map[string]*imported{}, execContext, showSecrets)
+	ec.openCapture = opts.OpenCapture
+	ec.random = opts.Random
+	ec.observer = opts.Observer
+	ec.metrics = opts.Metrics
+	ec.coerceStrings = opts.CoerceStrings
+	ec.jsonPointers = opts.JSONPointerPaths
+	ec.parameters = opts.Parameters
+	ec.keys = opts.Keys
+	ec.maxDepth = opts.MaxImportDepth
 	v, diags := ec.evaluate()
 
 	s := schema.Never().Schema()
@@ -130,36 +327,98 @@ func evalEnvironment(
 		Schema:     ec.myContext.schema,
 	}
 
+	exportOpts := exportOptions{
+		argValues:   opts.BuiltinArgValues,
+		showSecrets: opts.ShowSecretArgValues,
+		provenance:  opts.Provenance,
+	}
+
+	properties := v.export(name)
+	if opts.OmitHidden {
+		properties, _ = v.exportVisible(name)
+	}
+
+	if opts.RejectUnknowns {
+		if paths := unknownPaths(properties, validationLoc{}, opts.JSONPointerPaths); len(paths) != 0 {
+			summary := fmt.Sprintf("the environment contains unknown values: %v", strings.Join(paths, ", "))
+			diags.Extend(syntax.Error(nil, summary, ""))
+		}
+	}
+
 	return &esc.Environment{
-		Exprs:            ec.root.export(name).Object,
-		Properties:       v.export(name).Value.(map[string]esc.Value),
+		Exprs:            ec.root.exportWithOptions(name, exportOpts).Object,
+		Properties:       properties.Value.(map[string]esc.Value),
 		Schema:           s,
 		ExecutionContext: executionContext,
 	}, diags
 }
 
+// unknownPaths returns the rendered paths of the unknown values within v, in lexical order. The contents of unknown
+// values are not traversed.
+func unknownPaths(v esc.Value, loc validationLoc, pointer bool) []string {
+	if v.Unknown {
+		return []string{loc.render(pointer)}
+	}
+
+	var paths []string
+	switch repr := v.Value.(type) {
+	case []esc.Value:
+		for i, e := range repr {
+			paths = append(paths, unknownPaths(e, validationLoc{path: loc.with(i)}, pointer)...)
+		}
+	case map[string]esc.Value:
+		keys := maps.Keys(repr)
+		sort.Strings(keys)
+		for _, k := range keys {
+			paths = append(paths, unknownPaths(repr[k], validationLoc{path: loc.with(k)}, pointer)...)
+		}
+	}
+	return paths
+}
+
 type imported struct {
 	evaluating bool
 	value      *value
+	height     int // the length of the longest chain of imports below the environment
 }
 
 // An evalContext carries the state necessary to evaluate an environment.
 type evalContext struct {
-	ctx          context.Context      // the cancellation context for evaluation
-	validating   bool                 // true if we are only checking the environment
-	showSecrets  bool                 // true if secrets should be decrypted during validation
-	name         string               // the name of the environment
-	env          *ast.EnvironmentDecl // the root of the environment AST
-	decrypter    Decrypter            // the decrypter to use for the environment
-	providers    ProviderLoader       // the provider loader to use
-	environments EnvironmentLoader    // the environment loader to use
-	imports      map[string]*imported // the shared set of imported environments
-	execContext  *esc.ExecContext     // evaluation context used for interpolation
-
-	myContext *value // evaluated context to be used to interpolate properties
-	myImports *value // directly-imported environments
-	root      *expr  // the root expression
-	base      *value // the base value
+	ctx           context.Context      // the cancellation context for evaluation
+	validating    bool                 // true if we are only checking the environment
+	showSecrets   bool                 // true if secrets should be decrypted during validation
+	name          string               // the name of the environment
+	env           *ast.EnvironmentDecl // the root of the environment AST
+	decrypter     Decrypter            // the decrypter to use for the environment
+	providers     ProviderLoader       // the provider loader to use
+	environments  EnvironmentLoader    // the environment loader to use
+	imports       map[string]*imported // the shared set of imported environments
+	execContext   *esc.ExecContext     // evaluation context used for interpolation
+	openCapture   *OpenCapture         // the capture for fn::open calls, if any
+	random        io.Reader            // the source of randomness for nondeterministic builtins, if any
+	observer      func(Observation)    // the observer for expression evaluation, if any
+	metrics       *EvalMetrics         // the metrics for evaluation, if any
+	coerceStrings bool                 // true if strings should be coerced to the types expected by builtins
+	jsonPointers  bool                 // true if validation errors should render paths as JSON Pointers
+	validated     *validationCache     // the successful validations shared by the environments in the evaluation
+	parameters    map[string]esc.Value // the values supplied for the environment's parameters
+	keys          []string             // the top-level properties to evaluate, if not all of them
+	depth         int                  // the import depth of the environment
+	maxDepth      int                  // the maximum import depth, if positive
+
+	myContext *value            // evaluated context to be used to interpolate properties
+	myImports *value            // directly-imported environments
+	myAliases map[string]*value // directly-imported environments by alias
+	myParams  *value            // the environment's parameters, if any
+	unrooted  string            // describes the declarations being evaluated before the root is declared, if any
+	root      *expr             // the root expression
+	base      *value            // the base value
+
+	anchors      map[*yaml.Node]anchorDecl // declared YAML anchors
+	builtinDepth int                       // the number of builtin calls enclosing the expression being declared
+	scope        *scope                    // the scope of the expression being declared, if any
+	accesses     []declaredAccess          // the property accesses declared by the root's properties
+	checked      bool                      // true once the root's property accesses have been checked
 
 	diags syntax.Diagnostics // diagnostics generated during evaluation
 }
@@ -187,6 +446,7 @@ func newEvalContext(
 		environments: environments,
 		imports:      imports,
 		execContext:  execContext.CopyForEnv(name),
+		validated:    newValidationCache(),
 	}
 }
 
@@ -215,27 +475,85 @@ func (e *evalContext) accessorErrorf(expr ast.Expr, accessor ast.PropertyAccesso
 	e.accessorError(expr, accessor, fmt.Sprintf(format, a...))
 }
 
+// An anchorDecl records the declaration of an expression that defines a YAML anchor.
+type anchorDecl struct {
+	x           *expr  // the declared expression
+	path        string // the path to the expression
+	addressable bool   // true if the expression can be referred to by path
+}
+
 type exprNode interface {
 	ast.Expr
 	comparable
 }
 
 // declare creates an expr from an ast.Expr, sets its representation and initial schema, and attaches it to the given
-// base. declare is also responsible for recursively declaring child exprs. declare may issue errors for duplicate keys
-// in objects.
+// base. declare is also responsible for recursively declaring child exprs. If an object contains duplicate keys, the
+// first definition wins. Duplicate keys are reported by the parser.
+//
+// Expressions that are YAML aliases share the declaration of their anchor so that the anchor's value is only
+// evaluated once. If the anchor is not nested within a builtin call, the alias is declared as a reference to the
+// anchor's path, and is exported as such.
 //
 // The mapping of ast.Exprs to exprReprs is:
 //
 // - {Null, Boolean, Number, String}Expr -> literalExpr
 // - InterpolateExpr                     -> interpolateExpr
 // - SymbolExpr                          -> symbolExpr
+// - AndExpr                             -> andExpr
+// - ArithmeticExpr                      -> arithmeticExpr
+// - AtPathExpr                          -> atPathExpr
+// - CapitalizeExpr                      -> capitalizeExpr
+// - ChangeCaseExpr                      -> changeCaseExpr
+// - ClampExpr                           -> clampExpr
+// - EncodeQueryExpr                     -> encodeQueryExpr
+// - EqualsExpr                          -> equalsExpr
+// - FilterExpr                          -> filterExpr
+// - FormatTimeExpr                      -> formatTimeExpr
 // - FromBase64Expr                      -> fromBase64Expr
+// - NotExpr                             -> notExpr
+// - OrExpr                              -> orExpr
+// - SemverCompareExpr                   -> semverCompareExpr
+// - FromHexExpr                         -> fromHexExpr
 // - FromJSONExpr                        -> fromJSONExpr
+// - GetOrExpr                           -> getOrExpr
+// - GroupByExpr                         -> groupByExpr
+// - HMACExpr                            -> hmacExpr
+// - ImportExpr                          -> importExpr
+// - ImportRawExpr                       -> importRawExpr
+// - IsEmptyExpr                         -> isEmptyExpr
 // - JoinExpr                            -> joinExpr
+// - JWTDecodeExpr                       -> jwtDecodeExpr
+// - LetExpr                             -> letExpr
+// - MaskExpr                            -> maskExpr
+// - MapExpr                             -> mapExpr
+// - NearestExpr                         -> nearestExpr
+// - ObjectDiffExpr                      -> objectDiffExpr
+// - ObjectFromKeysExpr                  -> objectFromKeysExpr
 // - OpenExpr                            -> openExpr
+// - PadExpr                             -> padExpr
+// - ParseArnExpr                        -> parseArnExpr
+// - ParseDurationExpr                   -> parseDurationExpr
+// - ParseSizeExpr                       -> parseSizeExpr
+// - RandomStringExpr                    -> randomStringExpr
+// - ReduceExpr                          -> reduceExpr
+// - RepeatExpr                          -> repeatExpr
+// - RequireExpr                         -> requireExpr
+// - SampleExpr                          -> sampleExpr
 // - SecretExpr                          -> secretExpr
+// - SecretIfExpr                        -> secretIfExpr
+// - SetExpr                             -> setExpr
+// - SwitchExpr                          -> switchExpr
+// - TitleExpr                           -> titleExpr
+// - ToArrayExpr                         -> toArrayExpr
 // - ToBase64Expr                        -> toBase64Expr
+// - ToHexExpr                           -> toHexExpr
 // - ToJSONExpr                          -> toJSONExpr
+// - TypeOfExpr                          -> typeOfExpr
+// - URLDecodeExpr                       -> urlDecodeExpr
+// - URLEncodeExpr                       -> urlEncodeExpr
+// - URLJoinExpr                         -> urlJoinExpr
+// - ZipExpr                             -> zipExpr
 // - ArrayExpr                           -> arrayExpr
 // - ObjectExpr                          -> objectExpr
 //
@@ -247,6 +565,38 @@ func declare[Expr exprNode](e *evalContext, path string, x Expr, base *value) *e
 		return newMissingExpr(path, base)
 	}
 
+	var yamlSyntax encoding.YAMLSyntax
+	if node := x.Syntax(); node != nil {
+		yamlSyntax, _ = node.Syntax().(encoding.YAMLSyntax)
+	}
+
+	if anchor, ok := e.anchors[yamlSyntax.AliasOf()]; ok {
+		if !anchor.addressable {
+			return anchor.x
+		}
+		if property, diags := ast.ParsePropertyPath(anchor.path); !diags.HasErrors() {
+			return declareExpr(e, path, ast.SymbolSyntax(x.Syntax(), property), base)
+		}
+	}
+
+	addressable := e.builtinDepth == 0 && path != ""
+	if _, ok := any(x).(ast.BuiltinExpr); ok {
+		e.builtinDepth++
+		defer func() { e.builtinDepth-- }()
+	}
+
+	result := declareExpr(e, path, x, base)
+	if _, ok := e.anchors[yamlSyntax.Node]; yamlSyntax.IsAnchor() && !ok {
+		if e.anchors == nil {
+			e.anchors = map[*yaml.Node]anchorDecl{}
+		}
+		e.anchors[yamlSyntax.Node] = anchorDecl{x: result, path: path, addressable: addressable}
+	}
+	return result
+}
+
+// declareExpr declares an expression. See declare for details.
+func declareExpr[Expr exprNode](e *evalContext, path string, x Expr, base *value) *expr {
 	switch x := any(x).(type) {
 	case *ast.NullExpr:
 		return newExpr(path, &literalExpr{node: x}, schema.Null().Schema(), base)
@@ -265,7 +615,8 @@ func declare[Expr exprNode](e *evalContext, path string, x Expr, base *value) *e
 				for i, a := range p.Value.Accessors {
 					accessors[i] = &propertyAccessor{accessor: a}
 				}
-				value = &propertyAccess{accessors: accessors}
+				value = &propertyAccess{scope: e.scope, accessors: accessors}
+				e.accesses = append(e.accesses, declaredAccess{syntax: x, access: value})
 			}
 			parts[i] = interpolation{syntax: p, value: value}
 		}
@@ -275,14 +626,224 @@ func declare[Expr exprNode](e *evalContext, path string, x Expr, base *value) *e
 		for i, a := range x.Property.Accessors {
 			accessors[i] = &propertyAccessor{accessor: a}
 		}
-		property := &propertyAccess{accessors: accessors}
+		property := &propertyAccess{scope: e.scope, accessors: accessors}
+		e.accesses = append(e.accesses, declaredAccess{syntax: x, access: property})
 		return newExpr(path, &symbolExpr{node: x, property: property}, schema.Always().Schema(), base)
+	case *ast.ArithmeticExpr:
+		repr := &arithmeticExpr{
+			node:  x,
+			left:  declare(e, "", x.Left, nil),
+			right: declare(e, "", x.Right, nil),
+		}
+		return newExpr(path, repr, schema.Number().Schema(), base)
+	case *ast.ClampExpr:
+		repr := &clampExpr{
+			node:  x,
+			value: declare(e, "", x.Value, nil),
+			min:   declare(e, "", x.Min, nil),
+			max:   declare(e, "", x.Max, nil),
+		}
+		return newExpr(path, repr, schema.Number().Schema(), base)
+	case *ast.RepeatExpr:
+		repr := &repeatExpr{
+			node:  x,
+			value: declare(e, "", x.Value, nil),
+			count: declare(e, "", x.Count, nil),
+		}
+		return newExpr(path, repr, schema.Array().Items(schema.Always()).Schema(), base)
+	case *ast.SetExpr:
+		repr := &setExpr{node: x, operands: declareOperands(e, x.Operands)}
+		return newExpr(path, repr, schema.Array().Items(schema.Always()).Schema(), base)
+	case *ast.AndExpr:
+		repr := &andExpr{node: x, operands: declareOperands(e, x.Operands)}
+		return newExpr(path, repr, schema.Boolean().Schema(), base)
+	case *ast.OrExpr:
+		repr := &orExpr{node: x, operands: declareOperands(e, x.Operands)}
+		return newExpr(path, repr, schema.Boolean().Schema(), base)
+	case *ast.NotExpr:
+		repr := &notExpr{node: x, operand: declare(e, "", x.Operand, nil)}
+		return newExpr(path, repr, schema.Boolean().Schema(), base)
+	case *ast.SemverCompareExpr:
+		repr := &semverCompareExpr{
+			node:  x,
+			left:  declare(e, "", x.Left, nil),
+			right: declare(e, "", x.Right, nil),
+		}
+		return newExpr(path, repr, schema.Number().Schema(), base)
+	case *ast.EqualsExpr:
+		repr := &equalsExpr{
+			node:  x,
+			left:  declare(e, "", x.Left, nil),
+			right: declare(e, "", x.Right, nil),
+		}
+		return newExpr(path, repr, schema.Boolean().Schema(), base)
+	case *ast.CapitalizeExpr:
+		repr := &capitalizeExpr{node: x, string: declare(e, "", x.String, nil)}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.TitleExpr:
+		repr := &titleExpr{node: x, string: declare(e, "", x.String, nil)}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.ChangeCaseExpr:
+		repr := &changeCaseExpr{node: x, style: x.Style, string: declare(e, "", x.String, nil)}
+		return newExpr(path, repr, schema.String().Schema(), base)
 	case *ast.FromBase64Expr:
 		repr := &fromBase64Expr{node: x, string: declare(e, "", x.String, nil)}
 		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.FromHexExpr:
+		repr := &fromHexExpr{node: x, string: declare(e, "", x.String, nil)}
+		return newExpr(path, repr, schema.String().Schema(), base)
 	case *ast.FromJSONExpr:
 		repr := &fromJSONExpr{node: x, string: declare(e, "", x.String, nil)}
 		return newExpr(path, repr, schema.Always(), base)
+	case *ast.LetExpr:
+		// Bindings are declared in the enclosing scope, so they may not refer to one another.
+		repr := &letExpr{node: x, bindings: make([]letBinding, len(x.Bindings))}
+		s := e.scope
+		for i, b := range x.Bindings {
+			binding := letBinding{name: b.Key.Value, expr: declare(e, "", b.Value, nil)}
+			repr.bindings[i], s = binding, &scope{parent: s, name: binding.name, expr: binding.expr}
+		}
+		repr.in = e.declareTemplate(s, x.In)
+		return newExpr(path, repr, schema.Always(), base)
+	case *ast.MapExpr:
+		repr := &mapExpr{node: x, scope: e.scope, items: declare(e, "", x.Items, nil)}
+		repr.each = e.declareTemplate(&scope{parent: repr.scope, name: x.BindingName()}, x.Each)
+		return newExpr(path, repr, schema.Array().Items(schema.Always()).Schema(), base)
+	case *ast.FilterExpr:
+		repr := &filterExpr{node: x, scope: e.scope, items: declare(e, "", x.Items, nil)}
+		repr.where = e.declareTemplate(&scope{parent: repr.scope, name: x.BindingName()}, x.Where)
+		return newExpr(path, repr, schema.Array().Items(schema.Always()).Schema(), base)
+	case *ast.ReduceExpr:
+		repr := &reduceExpr{
+			node:    x,
+			scope:   e.scope,
+			items:   declare(e, "", x.Items, nil),
+			initial: declare(e, "", x.Initial, nil),
+		}
+		repr.reducer = e.declareTemplate(reducerScope(repr, nil, nil), x.Reducer)
+		return newExpr(path, repr, schema.Always(), base)
+	case *ast.SwitchExpr:
+		repr := &switchExpr{
+			node:  x,
+			value: declare(e, "", x.Value, nil),
+			cases: make([]switchCase, len(x.Cases)),
+		}
+		for i, c := range x.Cases {
+			repr.cases[i] = switchCase{when: declare(e, "", c.When, nil), then: declare(e, "", c.Then, nil)}
+		}
+		if x.Default != nil {
+			repr.defaultValue = declare(e, "", x.Default, nil)
+		}
+		return newExpr(path, repr, schema.Always(), base)
+	case *ast.AtPathExpr:
+		repr := &atPathExpr{
+			node:  x,
+			value: declare(e, "", x.Value, nil),
+			path:  declare(e, "", x.Path, nil),
+		}
+		if x.Strict != nil {
+			repr.strict = declare(e, "", x.Strict, nil)
+		}
+		return newExpr(path, repr, schema.Always(), base)
+	case *ast.GetOrExpr:
+		repr := &getOrExpr{
+			node:         x,
+			from:         declare(e, "", x.From, nil),
+			path:         declare(e, "", x.Path, nil),
+			defaultValue: declare(e, "", x.Default, nil),
+		}
+		return newExpr(path, repr, schema.Always(), base)
+	case *ast.GroupByExpr:
+		repr := &groupByExpr{
+			node:  x,
+			items: declare(e, "", x.Items, nil),
+			key:   declare(e, "", x.Key, nil),
+		}
+		if x.Strict != nil {
+			repr.strict = declare(e, "", x.Strict, nil)
+		}
+		return newExpr(path, repr, schema.Object().AdditionalProperties(groupBySchema).Schema(), base)
+	case *ast.MaskExpr:
+		repr := &maskExpr{
+			node:    x,
+			string:  declare(e, "", x.String, nil),
+			visible: declare(e, "", x.Visible, nil),
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.NearestExpr:
+		repr := &nearestExpr{
+			node:    x,
+			value:   declare(e, "", x.Value, nil),
+			options: declare(e, "", x.Options, nil),
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.PadExpr:
+		repr := &padExpr{
+			node:   x,
+			string: declare(e, "", x.String, nil),
+			length: declare(e, "", x.Length, nil),
+		}
+		if x.Pad != nil {
+			repr.pad = declare(e, "", x.Pad, nil)
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.ParseArnExpr:
+		repr := &parseArnExpr{node: x, arn: declare(e, "", x.Arn, nil)}
+		return newExpr(path, repr, arnSchema, base)
+	case *ast.FormatTimeExpr:
+		repr := &formatTimeExpr{
+			node:         x,
+			time:         declare(e, "", x.Time, nil),
+			inputLayout:  declare(e, "", x.InputLayout, nil),
+			outputLayout: declare(e, "", x.OutputLayout, nil),
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.ParseDurationExpr:
+		repr := &parseDurationExpr{node: x, duration: declare(e, "", x.Duration, nil)}
+		if x.Unit != nil {
+			repr.unit = declare(e, "", x.Unit, nil)
+		}
+		return newExpr(path, repr, schema.Number().Schema(), base)
+	case *ast.ParseSizeExpr:
+		repr := &parseSizeExpr{node: x, size: declare(e, "", x.Size, nil)}
+		return newExpr(path, repr, schema.Number().Schema(), base)
+	case *ast.RequireExpr:
+		repr := &requireExpr{
+			node:    x,
+			value:   declare(e, "", x.Value, nil),
+			message: declare(e, "", x.Message, nil),
+		}
+		return newExpr(path, repr, schema.Always().Schema(), base)
+	case *ast.SampleExpr:
+		repr := &sampleExpr{node: x, array: declare(e, "", x.Array, nil)}
+		return newExpr(path, repr, schema.Always().Schema(), base)
+	case *ast.RandomStringExpr:
+		repr := &randomStringExpr{node: x, length: declare(e, "", x.Length, nil)}
+		if x.Charset != nil {
+			repr.charset = declare(e, "", x.Charset, nil)
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.HMACExpr:
+		repr := &hmacExpr{
+			node:      x,
+			algorithm: declare(e, "", x.Algorithm, nil),
+			key:       declare(e, "", x.Key, nil),
+			message:   declare(e, "", x.Message, nil),
+		}
+		if x.Encoding != nil {
+			repr.encoding = declare(e, "", x.Encoding, nil)
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.ImportExpr:
+		repr := &importExpr{
+			node:        x,
+			environment: declare(e, "", x.Environment, nil),
+			path:        declare(e, "", x.Path, nil),
+		}
+		return newExpr(path, repr, schema.Always(), base)
+	case *ast.ImportRawExpr:
+		repr := &importRawExpr{node: x, path: declare(e, "", x.Path, nil)}
+		return newExpr(path, repr, schema.Always(), base)
 	case *ast.JoinExpr:
 		repr := &joinExpr{
 			node:      x,
@@ -290,6 +851,25 @@ func declare[Expr exprNode](e *evalContext, path string, x Expr, base *value) *e
 			values:    declare(e, "", x.Values, nil),
 		}
 		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.JWTDecodeExpr:
+		repr := &jwtDecodeExpr{node: x, token: declare(e, "", x.Token, nil)}
+		return newExpr(path, repr, schema.Object().AdditionalProperties(schema.Always()).Schema(), base)
+	case *ast.ObjectDiffExpr:
+		repr := &objectDiffExpr{
+			node: x,
+			a:    declare(e, "", x.A, nil),
+			b:    declare(e, "", x.B, nil),
+		}
+		return newExpr(path, repr, objectDiffSchema, base)
+	case *ast.ObjectFromKeysExpr:
+		repr := &objectFromKeysExpr{node: x, keys: declare(e, "", x.Keys, nil)}
+		if x.Value != nil {
+			repr.value = declare(e, "", x.Value, nil)
+		}
+		if x.Values != nil {
+			repr.values = declare(e, "", x.Values, nil)
+		}
+		return newExpr(path, repr, schema.Object().AdditionalProperties(schema.Always()).Schema(), base)
 	case *ast.OpenExpr:
 		repr := &openExpr{
 			node:        x,
@@ -307,15 +887,61 @@ func declare[Expr exprNode](e *evalContext, path string, x Expr, base *value) *e
 		repr := &secretExpr{node: x, ciphertext: declare(e, "", x.Ciphertext, nil)}
 		repr.ciphertext.secret = true
 		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.SecretIfExpr:
+		repr := &secretIfExpr{node: x, condition: declare(e, "", x.Condition, nil), value: declare(e, "", x.Value, nil)}
+		return newExpr(path, repr, schema.Always().Schema(), base)
 	case *ast.ToBase64Expr:
 		repr := &toBase64Expr{node: x, value: declare(e, "", x.Value, nil)}
 		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.ToHexExpr:
+		repr := &toHexExpr{node: x, value: declare(e, "", x.Value, nil)}
+		return newExpr(path, repr, schema.String().Schema(), base)
 	case *ast.ToJSONExpr:
 		repr := &toJSONExpr{node: x, value: declare(e, "", x.Value, nil)}
 		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.ToArrayExpr:
+		repr := &toArrayExpr{node: x, value: declare(e, "", x.Value, nil)}
+		return newExpr(path, repr, schema.Array().Items(schema.Always()).Schema(), base)
+	case *ast.TypeOfExpr:
+		repr := &typeOfExpr{node: x, value: declare(e, "", x.Value, nil)}
+		return newExpr(path, repr, typeNameSchema, base)
+	case *ast.IsEmptyExpr:
+		repr := &isEmptyExpr{node: x, value: declare(e, "", x.Value, nil)}
+		return newExpr(path, repr, schema.Boolean().Schema(), base)
 	case *ast.ToStringExpr:
 		repr := &toStringExpr{node: x, value: declare(e, "", x.Value, nil)}
 		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.URLEncodeExpr:
+		repr := &urlEncodeExpr{node: x, value: declare(e, "", x.Value, nil)}
+		if x.Mode != nil {
+			repr.mode = declare(e, "", x.Mode, nil)
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.URLDecodeExpr:
+		repr := &urlDecodeExpr{node: x, value: declare(e, "", x.Value, nil)}
+		if x.Mode != nil {
+			repr.mode = declare(e, "", x.Mode, nil)
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.EncodeQueryExpr:
+		repr := &encodeQueryExpr{node: x, values: declare(e, "", x.Values, nil)}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.URLJoinExpr:
+		repr := &urlJoinExpr{
+			node:     x,
+			base:     declare(e, "", x.Base, nil),
+			segments: declare(e, "", x.Segments, nil),
+		}
+		return newExpr(path, repr, schema.String().Schema(), base)
+	case *ast.ZipExpr:
+		repr := &zipExpr{
+			node:   x,
+			arrays: declare(e, "", x.Arrays, nil),
+		}
+		if x.Pad != nil {
+			repr.pad = declare(e, "", x.Pad, nil)
+		}
+		return newExpr(path, repr, zipSchema, base)
 	case *ast.ArrayExpr:
 		elements := make([]*expr, len(x.Elements))
 		for i, x := range x.Elements {
@@ -324,26 +950,36 @@ func declare[Expr exprNode](e *evalContext, path string, x Expr, base *value) *e
 		repr := &arrayExpr{node: x, elements: elements}
 		return newExpr(path, repr, schema.Array().Items(schema.Always()).Schema(), base)
 	case *ast.ObjectExpr:
-		properties := make(map[string]*expr, len(x.Entries))
+		keys, properties := make([]*ast.StringExpr, 0, len(x.Entries)), make(map[string]*expr, len(x.Entries))
 		for _, entry := range x.Entries {
 			k := entry.Key.Value
-			if _, ok := properties[k]; ok {
-				e.errorf(entry.Key, "duplicate key %q", k)
-			} else {
+			if _, ok := properties[k]; !ok {
+				keys = append(keys, entry.Key)
 				properties[k] = declare(e, util.JoinKey(path, k), entry.Value, base.property(entry.Key, k))
 			}
 		}
-		repr := &objectExpr{node: x, properties: properties}
+		repr := &objectExpr{node: x, keys: keys, properties: properties}
 		return newExpr(path, repr, schema.Object().AdditionalProperties(schema.Always()).Schema(), base)
 	default:
 		panic(fmt.Sprintf("fatal: invalid expr type %v", reflect.TypeOf(x)))
 	}
 }
 
+// declareOperands declares the operands of a variadic builtin.
+func declareOperands(e *evalContext, xs []ast.Expr) []*expr {
+	operands := make([]*expr, len(xs))
+	for i, x := range xs {
+		operands[i] = declare(e, "", x, nil)
+	}
+	return operands
+}
+
 func (e *evalContext) isReserveTopLevelKey(k string) bool {
 	switch k {
 	case "imports", "context":
 		return true
+	case "parameters":
+		return e.env.Parameters != nil
 	default:
 		return false
 	}
@@ -351,41 +987,91 @@ func (e *evalContext) isReserveTopLevelKey(k string) bool {
 
 // evaluate drives the evaluation of the evalContext's environment.
 func (e *evalContext) evaluate() (*value, syntax.Diagnostics) {
+	// Mark the environment as evaluating for the duration of its evaluation so that cyclic imports are detected. This
+	// includes imports via fn::import, which are evaluated along with the environment's values.
+	mine := &imported{evaluating: true}
+	defer func() { mine.evaluating = false }()
+	e.imports[e.name] = mine
+
 	// Evaluate context. We prepare the context values to later evaluate interpolations.
 	e.evaluateContext()
 	// Evaluate imports. We do this prior to declaration so that we can plumb base values as part of declaration.
 	e.evaluateImports()
+	// Evaluate parameters. Parameters may refer to imports, so we do this after the imports have been evaluated.
+	e.evaluateParameters()
+	e.unrooted = ""
 
 	// Build the root value. We do this manually b/c the AST uses a declaration rather than an expression for the
 	// root.
-	properties := make(map[string]*expr, len(e.env.Values.GetEntries()))
+	root := &objectExpr{
+		node:       ast.Object(),
+		properties: make(map[string]*expr, len(e.env.Values.GetEntries())),
+	}
 	e.root = &expr{
 		path: "<" + e.name + ">",
-		repr: &objectExpr{
-			node:       ast.Object(),
-			properties: properties,
-		},
+		repr: root,
 		base: e.base,
 	}
 
 	// Declare the root value's properties.
+	e.accesses = nil
 	for _, entry := range e.env.Values.GetEntries() {
 		key := entry.Key.GetValue()
 
 		if e.isReserveTopLevelKey(key) {
 			e.errorf(entry.Key, "%q is a reserved key", key)
-		} else if _, ok := properties[key]; ok {
-			e.errorf(entry.Key, "duplicate key %q", key)
-		} else {
-			properties[key] = declare(e, key, entry.Value, e.base.property(entry.Key, key))
+		} else if _, ok := root.properties[key]; !ok {
+			keyExpr := entry.Key
+			if keyExpr == nil {
+				keyExpr = ast.String(key)
+			}
+			root.keys = append(root.keys, keyExpr)
+			root.properties[key] = declare(e, key, entry.Value, e.base.property(entry.Key, key))
 		}
 	}
 
-	// Evaluate the root value and return.
-	v := e.evaluateExpr(e.root)
+	// Check the declared property accesses before evaluating anything so that accesses that cannot resolve are reported
+	// even if evaluation never reaches them.
+	e.checkAccesses()
+
+	// Evaluate the root value. If the environment is secret, mark its entire output as secret.
+	var v *value
+	if len(e.keys) != 0 {
+		v = e.evaluateKeys(root)
+	} else {
+		v = e.evaluateExpr(e.root)
+	}
+	if e.env.Secret != nil && e.env.Secret.Value {
+		v = v.secretCopy()
+	}
 	return v, e.diags
 }
 
+// evaluateKeys evaluates the requested top-level properties of the root value. Other properties are only evaluated if
+// they are referred to by a requested property. Requested properties that are only defined by the environment's
+// imports are taken from its base value.
+func (e *evalContext) evaluateKeys(root *objectExpr) *value {
+	v := &value{def: e.root}
+
+	keys := slices.Clone(e.keys)
+	sort.Strings(keys)
+	keys = slices.Compact(keys)
+
+	object, properties := make(map[string]*value, len(keys)), make(schema.SchemaMap, len(keys))
+	for _, k := range keys {
+		var pv *value
+		if x, ok := root.properties[k]; ok {
+			pv = e.evaluateExpr(x)
+		} else if pv = e.base.property(root.node, k); pv == nil {
+			continue
+		}
+		object[k], properties[k] = pv, pv.schema
+	}
+
+	v.repr, v.schema = object, schema.Record(properties).Schema()
+	return v
+}
+
 func (e *evalContext) evaluateContext() {
 	def := declare(e, "", ast.Symbol(&ast.PropertyName{Name: "context"}), nil)
 	e.myContext = unexport(esc.NewValue(e.execContext.Values()), def)
@@ -393,15 +1079,44 @@ func (e *evalContext) evaluateContext() {
 
 // evaluateImports evaluates an environment's imports.
 func (e *evalContext) evaluateImports() {
-	mine := &imported{evaluating: true}
-	defer func() { mine.evaluating = false }()
-	e.imports[e.name] = mine
+	e.myAliases = map[string]*value{}
 
+	e.unrooted = "import conditions"
+
+	// The imports value is updated after each import so that import conditions may refer to previous imports.
 	myImports := map[string]*value{}
+	e.setMyImports(myImports)
 	for _, entry := range e.env.Imports.GetElements() {
 		e.evaluateImport(myImports, entry)
+		e.setMyImports(myImports)
+	}
+	e.checkAliasConflicts()
+}
+
+// checkAliasConflicts reports import aliases that conflict with a top-level key of a merged import. References to such
+// a key would otherwise silently resolve to the aliased import.
+func (e *evalContext) checkAliasConflicts() {
+	keys := e.base.keys()
+	if len(keys) == 0 {
+		return
+	}
+
+	reported := map[string]bool{}
+	for _, decl := range e.env.Imports.GetElements() {
+		if decl.Meta == nil || decl.Meta.As == nil {
+			continue
+		}
+		name := decl.Meta.As.Value
+		if _, ok := e.myAliases[name]; !ok || reported[name] || !slices.Contains(keys, name) {
+			continue
+		}
+		e.errorf(decl.Meta.As, "import alias %q conflicts with a key of a merged import", name)
+		reported[name] = true
 	}
+}
 
+// setMyImports sets the value of the environment's `imports` symbol.
+func (e *evalContext) setMyImports(myImports map[string]*value) {
 	properties := make(schema.SchemaMap, len(myImports))
 	for k, v := range myImports {
 		properties[k] = v.schema
@@ -421,6 +1136,114 @@ func (e *evalContext) evaluateImports() {
 	e.myImports = val
 }
 
+// evaluateParameters evaluates an environment's parameters. The value of each parameter is the value supplied by the
+// caller, if any, or the parameter's default. Supplied values are validated against the parameter's schema. When
+// checking an environment, required parameters that have not been supplied evaluate to unknown values.
+func (e *evalContext) evaluateParameters() {
+	e.unrooted = "parameters"
+
+	declared := map[string]bool{}
+	if e.env.Parameters != nil {
+		params, properties := map[string]*value{}, schema.SchemaMap{}
+		for _, entry := range e.env.Parameters.Entries {
+			name := entry.Key.Value
+			if declared[name] {
+				continue
+			}
+			declared[name] = true
+
+			v := e.evaluateParameter(entry.Key, entry.Value)
+			params[name], properties[name] = v, v.schema
+		}
+
+		def := declare(e, "", ast.Symbol(&ast.PropertyName{Name: "parameters"}), nil)
+		def.schema, def.state = schema.Record(properties).Schema(), exprDone
+		def.value = &value{def: def, schema: def.schema, repr: params}
+		e.myParams = def.value
+	}
+
+	names := maps.Keys(e.parameters)
+	sort.Strings(names)
+	for _, name := range names {
+		if !declared[name] {
+			e.diags.Extend(syntax.NodeError(e.parametersNode(), fmt.Sprintf("unknown parameter %q", name)))
+		}
+	}
+}
+
+// parametersNode returns the syntax node that best describes the environment's parameters for diagnostics.
+func (e *evalContext) parametersNode() syntax.Node {
+	if e.env.Parameters != nil && e.env.Parameters.Syntax() != nil {
+		return e.env.Parameters.Syntax()
+	}
+	return e.env.Syntax()
+}
+
+// evaluateParameter evaluates a single parameter.
+func (e *evalContext) evaluateParameter(key *ast.StringExpr, decl *ast.ParameterDecl) *value {
+	accept := schema.Always()
+	if decl != nil && decl.Schema != nil {
+		accept = e.evaluateParameterSchema(decl.Schema)
+	}
+
+	// Supplied values are defined by the parameter's key.
+	def := declare(e, "", key, nil)
+	def.schema, def.state = accept, exprDone
+
+	if supplied, ok := e.parameters[key.Value]; ok {
+		v := unexport(supplied, def)
+		if e.coerceStrings {
+			v = coerceStrings(v, accept)
+		}
+		v = normalizeEnums(v, accept)
+		vv := validator{jsonPointers: e.jsonPointers, cache: e.validated}
+		if !vv.validateValue(v, accept, validationLoc{x: def}) {
+			e.diags.Extend(vv.diags...)
+			v = &value{def: def, schema: accept, unknown: true}
+		}
+		markHidden(v, accept)
+		def.value = v
+		return v
+	}
+
+	if decl != nil && decl.Default != nil {
+		v, ok := e.evaluateTypedExpr(declare(e, "", decl.Default, nil), accept)
+		if !ok {
+			v = &value{def: def, schema: accept, unknown: true}
+		}
+		markHidden(v, accept)
+		def.value = v
+		return v
+	}
+
+	if !e.validating {
+		e.errorf(key, "missing value for required parameter %q", key.Value)
+	}
+	def.value = &value{def: def, schema: accept, unknown: true}
+	return def.value
+}
+
+// evaluateParameterSchema evaluates the schema of a parameter. If the schema is unknown or invalid, the parameter
+// accepts any value.
+func (e *evalContext) evaluateParameterSchema(x ast.Expr) *schema.Schema {
+	v := e.evaluateExpr(declare(e, "", x, nil))
+	if v.containsUnknowns() {
+		return schema.Always()
+	}
+
+	bytes, err := json.Marshal(v.export(e.name).ToJSON(false))
+	if err == nil {
+		var s schema.Schema
+		if err = json.Unmarshal(bytes, &s); err == nil {
+			if err = s.Compile(); err == nil {
+				return &s
+			}
+		}
+	}
+	e.errorf(x, "invalid parameter schema: %v", err)
+	return schema.Always()
+}
+
 // evaluateImport evaluates an imported environment.
 //
 // Each environment in the import closure is only evaluated once.
@@ -432,41 +1255,40 @@ func (e *evalContext) evaluateImport(myImports map[string]*value, decl *ast.Impo
 	}
 	name := decl.Environment.Value
 
-	merge := true
+	// Aliased imports are not merged by default, as the alias is the means by which their values are referenced.
+	var alias *ast.StringExpr
+	if decl.Meta != nil {
+		alias = decl.Meta.As
+	}
+	merge := alias == nil
 	if decl.Meta != nil && decl.Meta.Merge != nil {
 		merge = decl.Meta.Merge.Value
 	}
+	if alias != nil && !e.checkImportAlias(alias) {
+		return
+	}
+	if decl.Meta != nil && decl.Meta.If != nil && !e.evaluateImportCondition(decl.Meta.If) {
+		return
+	}
 
-	var val *value
-	if imported, ok := e.imports[name]; ok {
-		if imported.evaluating {
-			e.diags.Extend(syntax.Error(decl.Syntax().Syntax().Range(), fmt.Sprintf("cyclic import of %v", name), decl.Syntax().Syntax().Path()))
-			return
-		}
-		val = imported.value
-	} else {
-		bytes, dec, err := e.environments.LoadEnvironment(e.ctx, name)
-		if err != nil {
-			e.errorf(decl.Environment, "%s", err.Error())
-			return
-		}
-
-		env, diags, err := LoadYAMLBytes(name, bytes)
-		e.diags.Extend(diags...)
-		if err != nil {
-			e.errorf(decl.Environment, "%s", err.Error())
-			return
-		}
-
-		imp := newEvalContext(e.ctx, e.validating, name, env, dec, e.providers, e.environments, e.imports, e.execContext, e.showSecrets)
-		v, diags := imp.evaluate()
-		e.diags.Extend(diags...)
+	val, err := e.loadImport(name)
+	switch {
+	case errors.Is(err, errCyclicImport):
+		e.diags.Extend(syntax.Error(decl.Syntax().Syntax().Range(), fmt.Sprintf("cyclic import of %v", name), decl.Syntax().Syntax().Path()))
+		return
+	case err != nil:
+		e.errorf(decl.Environment, "%s", err.Error())
+		return
+	}
 
-		val = v
-		e.imports[name].value = val
+	if decl.Meta != nil && decl.Meta.Only != nil {
+		val = e.selectImportKeys(name, val, decl.Meta.Only.GetElements())
 	}
 
 	myImports[name] = val
+	if alias != nil {
+		e.myAliases[alias.Value] = val
+	}
 	if merge {
 		val = newCopier().copy(val)
 		val.merge(e.base)
@@ -474,16 +1296,139 @@ func (e *evalContext) evaluateImport(myImports map[string]*value, decl *ast.Impo
 	}
 }
 
-// evaluateExpr evaluates an expression. If the expression has already been evaluated, it returns the
-// previously-computed result. evaluateExpr is also responsible for updating the expression's schema to that of its
-// final, merged value.
-func (e *evalContext) evaluateExpr(x *expr) *value {
-	switch x.state {
-	case exprDone:
-		return x.value
-	case exprEvaluating:
-		e.errorf(x.repr.syntax(), "cyclic reference to %v", x.path)
-		return &value{
+// errCyclicImport is returned by loadImport if the environment to import is currently being evaluated.
+var errCyclicImport = errors.New("cyclic import")
+
+// loadImport evaluates the named environment and returns its value. Each environment in the import closure is only
+// evaluated once. If the environment is currently being evaluated, loadImport returns errCyclicImport. If importing
+// the environment would exceed the maximum import depth, loadImport returns an error.
+func (e *evalContext) loadImport(name string) (*value, error) {
+	imported, ok := e.imports[name]
+	if ok && imported.evaluating {
+		return nil, errCyclicImport
+	}
+
+	// An environment that has already been evaluated brings its own imports along, so the chains below it count
+	// towards the limit as well.
+	height := 0
+	if ok {
+		height = imported.height
+	}
+	if e.maxDepth > 0 && e.depth+1+height > e.maxDepth {
+		return nil, fmt.Errorf("importing %v exceeds the maximum import depth of %v", name, e.maxDepth)
+	}
+	if ok {
+		e.recordImportHeight(height)
+		return imported.value, nil
+	}
+
+	bytes, dec, err := e.environments.LoadEnvironment(e.ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	env, diags, err := LoadYAMLBytes(name, bytes)
+	e.diags.Extend(diags...)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := newEvalContext(e.ctx, e.validating, name, env, dec, e.providers, e.environments, e.imports, e.execContext, e.showSecrets)
+	imp.openCapture = e.openCapture
+	imp.random = e.random
+	imp.observer = e.observer
+	imp.metrics = e.metrics
+	imp.coerceStrings = e.coerceStrings
+	imp.jsonPointers = e.jsonPointers
+	imp.validated = e.validated
+	imp.depth, imp.maxDepth = e.depth+1, e.maxDepth
+	v, diags := imp.evaluate()
+	e.diags.Extend(diags...)
+
+	imported = e.imports[name]
+	imported.value = v
+	e.recordImportHeight(imported.height)
+	return v, nil
+}
+
+// recordImportHeight updates the height of the environment being evaluated to account for an import whose own height
+// is the given value.
+func (e *evalContext) recordImportHeight(height int) {
+	if mine := e.imports[e.name]; mine.height < height+1 {
+		mine.height = height + 1
+	}
+}
+
+// evaluateImportCondition evaluates the condition that guards an import. The import should proceed only if the result
+// is true. If the condition is unknown (e.g. because it depends on a provider that is not opened during checking), the
+// import is skipped.
+func (e *evalContext) evaluateImportCondition(cond ast.Expr) bool {
+	x := declare(e, "", cond, nil)
+	v, ok := e.evaluateTypedExpr(x, schema.Boolean().Schema())
+	if !ok || v.unknown {
+		return false
+	}
+	return v.repr.(bool)
+}
+
+// selectImportKeys returns a copy of an imported environment's value that contains only the given top-level keys. It
+// is an error to select a key that the imported environment does not define.
+func (e *evalContext) selectImportKeys(name string, val *value, keys []*ast.StringExpr) *value {
+	object, properties := make(map[string]*value, len(keys)), make(schema.SchemaMap, len(keys))
+	for _, k := range keys {
+		if k == nil {
+			continue
+		}
+		prop := val.property(k, k.Value)
+		if prop == nil {
+			e.errorf(k, "environment %v has no top-level key %q", name, k.Value)
+			continue
+		}
+		object[k.Value], properties[k.Value] = prop, prop.schema
+	}
+	return &value{
+		def:    val.def,
+		schema: schema.Record(properties).Schema(),
+		repr:   object,
+	}
+}
+
+// checkImportAlias checks that an import alias is valid. An alias must be non-empty and must not conflict with a
+// reserved key, a top-level key, or another alias.
+func (e *evalContext) checkImportAlias(alias *ast.StringExpr) bool {
+	name := alias.Value
+	switch {
+	case name == "":
+		e.errorf(alias, "import alias must not be empty")
+		return false
+	case e.isReserveTopLevelKey(name):
+		e.errorf(alias, "import alias %q is a reserved key", name)
+		return false
+	}
+	if _, ok := e.myAliases[name]; ok {
+		e.errorf(alias, "duplicate import alias %q", name)
+		return false
+	}
+	for _, entry := range e.env.Values.GetEntries() {
+		if entry.Key.GetValue() == name {
+			e.errorf(alias, "import alias %q conflicts with a top-level key", name)
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateExpr evaluates an expression. If the expression has already been evaluated, it returns the
+// previously-computed result. evaluateExpr is also responsible for updating the expression's schema to that of its
+// final, merged value.
+func (e *evalContext) evaluateExpr(x *expr) *value {
+	switch x.state {
+	case exprDone:
+		e.observe(x, x.value, true)
+		return x.value
+	case exprEvaluating:
+		e.errorf(x.repr.syntax(), "cyclic reference to %v", x.path)
+		return &value{
 			def:     x,
 			schema:  schema.Always().Schema(),
 			unknown: true,
@@ -495,6 +1440,12 @@ func (e *evalContext) evaluateExpr(x *expr) *value {
 		}()
 	}
 
+	if e.metrics != nil {
+		if builtin, ok := x.repr.syntax().(ast.BuiltinExpr); ok {
+			defer record(&e.metrics.Builtins, builtin.Name().Value, time.Now())
+		}
+	}
+
 	val := (*value)(nil)
 	switch repr := x.repr.(type) {
 	case *missingExpr:
@@ -513,23 +1464,117 @@ func (e *evalContext) evaluateExpr(x *expr) *value {
 	case *interpolateExpr:
 		val = e.evaluateInterpolate(x, repr)
 	case *symbolExpr:
-		val = e.evaluatePropertyAccess(x, repr.property.accessors)
+		val = e.evaluatePropertyAccess(x, repr.property)
+	case *arithmeticExpr:
+		val = e.evaluateBuiltinArithmetic(x, repr)
+	case *clampExpr:
+		val = e.evaluateBuiltinClamp(x, repr)
+	case *repeatExpr:
+		val = e.evaluateBuiltinRepeat(x, repr)
+	case *setExpr:
+		val = e.evaluateBuiltinSet(x, repr)
+	case *andExpr:
+		val = e.evaluateBuiltinLogical(x, repr.operands, false)
+	case *orExpr:
+		val = e.evaluateBuiltinLogical(x, repr.operands, true)
+	case *notExpr:
+		val = e.evaluateBuiltinNot(x, repr)
+	case *equalsExpr:
+		val = e.evaluateBuiltinEquals(x, repr)
+	case *semverCompareExpr:
+		val = e.evaluateBuiltinSemverCompare(x, repr)
+	case *atPathExpr:
+		val = e.evaluateBuiltinAtPath(x, repr)
+	case *getOrExpr:
+		val = e.evaluateBuiltinGetOr(x, repr)
+	case *groupByExpr:
+		val = e.evaluateBuiltinGroupBy(x, repr)
+	case *letExpr:
+		val = e.evaluateBuiltinLet(x, repr)
+	case *mapExpr:
+		val = e.evaluateBuiltinMap(x, repr)
+	case *filterExpr:
+		val = e.evaluateBuiltinFilter(x, repr)
+	case *reduceExpr:
+		val = e.evaluateBuiltinReduce(x, repr)
+	case *switchExpr:
+		val = e.evaluateBuiltinSwitch(x, repr)
+	case *importExpr:
+		val = e.evaluateBuiltinImport(x, repr)
+	case *importRawExpr:
+		val = e.evaluateBuiltinImportRaw(x, repr)
+	case *capitalizeExpr:
+		val = e.evaluateBuiltinCapitalize(x, repr)
+	case *titleExpr:
+		val = e.evaluateBuiltinTitle(x, repr)
+	case *changeCaseExpr:
+		val = e.evaluateBuiltinChangeCase(x, repr)
 	case *fromBase64Expr:
 		val = e.evaluateBuiltinFromBase64(x, repr)
+	case *fromHexExpr:
+		val = e.evaluateBuiltinFromHex(x, repr)
 	case *fromJSONExpr:
 		val = e.evaluateBuiltinFromJSON(x, repr)
+	case *hmacExpr:
+		val = e.evaluateBuiltinHMAC(x, repr)
 	case *joinExpr:
 		val = e.evaluateBuiltinJoin(x, repr)
+	case *jwtDecodeExpr:
+		val = e.evaluateBuiltinJWTDecode(x, repr)
+	case *maskExpr:
+		val = e.evaluateBuiltinMask(x, repr)
+	case *nearestExpr:
+		val = e.evaluateBuiltinNearest(x, repr)
+	case *padExpr:
+		val = e.evaluateBuiltinPad(x, repr)
+	case *objectDiffExpr:
+		val = e.evaluateBuiltinObjectDiff(x, repr)
+	case *objectFromKeysExpr:
+		val = e.evaluateBuiltinObjectFromKeys(x, repr)
 	case *openExpr:
 		val = e.evaluateBuiltinOpen(x, repr)
+	case *parseArnExpr:
+		val = e.evaluateBuiltinParseArn(x, repr)
+	case *parseDurationExpr:
+		val = e.evaluateBuiltinParseDuration(x, repr)
+	case *formatTimeExpr:
+		val = e.evaluateBuiltinFormatTime(x, repr)
+	case *parseSizeExpr:
+		val = e.evaluateBuiltinParseSize(x, repr)
+	case *randomStringExpr:
+		val = e.evaluateBuiltinRandomString(x, repr)
+	case *requireExpr:
+		val = e.evaluateBuiltinRequire(x, repr)
+	case *sampleExpr:
+		val = e.evaluateBuiltinSample(x, repr)
 	case *secretExpr:
 		val = e.evaluateBuiltinSecret(x, repr)
+	case *secretIfExpr:
+		val = e.evaluateBuiltinSecretIf(x, repr)
 	case *toBase64Expr:
 		val = e.evaluateBuiltinToBase64(x, repr)
+	case *toHexExpr:
+		val = e.evaluateBuiltinToHex(x, repr)
 	case *toJSONExpr:
 		val = e.evaluateBuiltinToJSON(x, repr)
 	case *toStringExpr:
 		val = e.evaluateBuiltinToString(x, repr)
+	case *typeOfExpr:
+		val = e.evaluateBuiltinTypeOf(x, repr)
+	case *isEmptyExpr:
+		val = e.evaluateBuiltinIsEmpty(x, repr)
+	case *toArrayExpr:
+		val = e.evaluateBuiltinToArray(x, repr)
+	case *urlEncodeExpr:
+		val = e.evaluateBuiltinURL(x, repr.value, repr.mode, false)
+	case *urlDecodeExpr:
+		val = e.evaluateBuiltinURL(x, repr.value, repr.mode, true)
+	case *urlJoinExpr:
+		val = e.evaluateBuiltinURLJoin(x, repr)
+	case *zipExpr:
+		val = e.evaluateBuiltinZip(x, repr)
+	case *encodeQueryExpr:
+		val = e.evaluateBuiltinEncodeQuery(x, repr)
 	case *arrayExpr:
 		val = e.evaluateArray(x, repr)
 	case *objectExpr:
@@ -545,14 +1590,32 @@ func (e *evalContext) evaluateExpr(x *expr) *value {
 
 	x.schema = val.schema
 	x.value = val
+	e.observe(x, val, false)
 	return val
 }
 
-// evaluateTypedExpr evaluates an expression and typechecks it against the given schema. Returns false if typechecking
-// fails.
+// observe reports the evaluation of an expression to the observer, if any.
+func (e *evalContext) observe(x *expr, v *value, cached bool) {
+	if e.observer == nil {
+		return
+	}
+	e.observer(Observation{
+		Environment: e.name,
+		Path:        x.path,
+		Value:       redactSecrets(v.export(e.name)),
+		Cached:      cached,
+	})
+}
+
+// evaluateTypedExpr evaluates an expression and typechecks it against the given schema. If string coercion is enabled,
+// the value is coerced to the types expected by the schema prior to typechecking. Returns false if typechecking fails.
 func (e *evalContext) evaluateTypedExpr(x *expr, accept *schema.Schema) (*value, bool) {
 	v := e.evaluateExpr(x)
-	vv := validator{}
+	if e.coerceStrings {
+		v = coerceStrings(v, accept)
+	}
+	v = normalizeEnums(v, accept)
+	vv := validator{jsonPointers: e.jsonPointers, cache: e.validated}
 	ok := vv.validateValue(v, accept, validationLoc{x: x})
 	e.diags.Extend(vv.diags...)
 	return v, ok
@@ -601,7 +1664,7 @@ func (e *evalContext) evaluateInterpolate(x *expr, repr *interpolateExpr) *value
 		b.WriteString(i.syntax.Text)
 
 		if i.value != nil {
-			pv := e.evaluatePropertyAccess(x, i.value.accessors)
+			pv := e.evaluatePropertyAccess(x, i.value)
 			s, unknown, secret := pv.toString()
 			v.unknown, v.secret = v.containsUnknowns() || unknown, v.containsSecrets() || secret
 			if !unknown {
@@ -619,35 +1682,184 @@ func (e *evalContext) evaluateInterpolate(x *expr, repr *interpolateExpr) *value
 }
 
 // evaluatePropertyAccess evaluates a property access.
-func (e *evalContext) evaluatePropertyAccess(x *expr, accessors []*propertyAccessor) *value {
+func (e *evalContext) evaluatePropertyAccess(x *expr, access *propertyAccess) *value {
+	// Accesses that were found to be invalid prior to evaluation have already been reported.
+	if access.invalid {
+		v := newCopier().copy(access.accessors[len(access.accessors)-1].value)
+		v.def = x
+		return v
+	}
+
 	// We make a copy of the resolved value here because evaluateExpr will merge it with its base, which mutates the
 	// value. We also stamp over the def with the provided expression in order to maintain proper error reporting.
-	v := newCopier().copy(e.evaluateExprAccess(x, accessors))
+	v := newCopier().copy(e.evaluateExprAccess(x, access.scope, access.accessors))
 	v.def = x
 	return v
 }
 
+// A declaredAccess records a property access along with the expression that contains it.
+type declaredAccess struct {
+	syntax ast.Expr
+	access *propertyAccess
+}
+
+// checkAccesses checks the property accesses declared by the root's properties against the declared expressions and
+// their schemas. An access that traverses a value that is known to be a scalar can never resolve, so it is reported
+// and marked invalid. The accessors of an invalid access are resolved as they would be by evaluateExprAccess. Accesses
+// that cannot be resolved statically are left to evaluation.
+func (e *evalContext) checkAccesses() {
+	for _, a := range e.accesses {
+		e.checkAccess(a.syntax, a.access)
+	}
+	e.accesses, e.checked = nil, true
+}
+
+// checkAccess checks a single property access. See checkAccesses for details.
+func (e *evalContext) checkAccess(syntax ast.Expr, access *propertyAccess) {
+	accessors := access.accessors
+
+	// Names bound by a scope, imports, parameters, context, and aliased imports are only known during evaluation.
+	k, ok := e.objectKey(syntax, accessors[0].accessor, false)
+	if !ok {
+		return
+	}
+	if _, isBound := access.scope.lookup(k); isBound {
+		return
+	}
+	if _, isAlias := e.myAliases[k]; isAlias || k == "imports" || k == "context" || k == "parameters" && e.myParams != nil {
+		return
+	}
+
+	receiver, resolved := e.root, make([]*value, 0, len(accessors))
+	for i := 0; i < len(accessors); {
+		switch repr := receiver.repr.(type) {
+		case *arrayExpr:
+			sub, ok := accessors[i].accessor.(*ast.PropertySubscript)
+			if !ok {
+				return
+			}
+			index, ok := sub.Index.(int)
+			if index < 0 {
+				index += len(repr.elements)
+			}
+			if !ok || index < 0 || index >= len(repr.elements) {
+				return
+			}
+			receiver = repr.elements[index]
+		case *objectExpr:
+			key, ok := e.objectKey(syntax, accessors[i].accessor, false)
+			if !ok {
+				return
+			}
+			prop, ok := repr.properties[key]
+			if !ok {
+				return
+			}
+			receiver = prop
+		case *secretExpr:
+			// Secret expressions are transparent to accessors.
+			if repr.plaintext == nil {
+				return
+			}
+			receiver = repr.plaintext
+			continue
+		default:
+			if !alwaysScalar(receiver.schema) {
+				return
+			}
+			e.accessorError(syntax, accessors[i].accessor, "receiver must be an array or an object")
+			for j, v := range resolved {
+				accessors[j].value = v
+			}
+			e.invalidPropertyAccess(syntax, accessors[i:])
+			access.invalid = true
+			return
+		}
+
+		// Synthesize a value for the accessor in case the access turns out to be invalid.
+		resolved = append(resolved, &value{
+			def:    receiver,
+			base:   receiver.base,
+			schema: receiver.schema,
+		})
+		i++
+	}
+}
+
+// alwaysScalar returns true if every value that conforms to the given schema is a scalar.
+func alwaysScalar(s *schema.Schema) bool {
+	if s == nil || s.Always || s.Never || len(s.AnyOf) != 0 || len(s.OneOf) != 0 {
+		return false
+	}
+	switch s.Type {
+	case "string", "number", "integer", "boolean", "null":
+		return true
+	default:
+		return false
+	}
+}
+
 // evaluateExprAccess is the primary entrypoint for access evaluation, and begins with the assumption that the receiver
 // is an expression. If the receiver is a list, object, or secret  expression, it is _not evaluated_. If the receiver
 // is any other type of expression, it is evaluated and the result is passed to evaluateValueAccess. Once all accessors
 // have been processed, the resolved expression is evaluated.
-func (e *evalContext) evaluateExprAccess(x *expr, accessors []*propertyAccessor) *value {
+//
+// Names bound by the given scope take precedence over the environment's top-level properties.
+func (e *evalContext) evaluateExprAccess(x *expr, scope *scope, accessors []*propertyAccessor) *value {
 	receiver := e.root
 
 	k, ok := e.objectKey(x.repr.syntax(), accessors[0].accessor, false)
 
+	// Check for a name bound by an enclosing fn::map or fn::let.
+	if s, isBound := scope.lookup(k); ok && isBound {
+		bound := s.value
+		if s.expr != nil {
+			bound = e.evaluateExpr(s.expr)
+		}
+		if bound == nil {
+			// The template is being declared outside of any element's scope.
+			return e.invalidPropertyAccess(x.repr.syntax(), accessors)
+		}
+		accessors[0].value = bound
+		return e.evaluateValueAccess(x.repr.syntax(), bound, accessors[1:])
+	}
+
 	// Check for an imports access.
 	if ok && k == "imports" {
 		accessors[0].value = e.myImports
 		return e.evaluateValueAccess(x.repr.syntax(), e.myImports, accessors[1:])
 	}
 
+	// Check for a parameter access.
+	if ok && k == "parameters" && e.myParams != nil {
+		accessors[0].value = e.myParams
+		return e.evaluateValueAccess(x.repr.syntax(), e.myParams, accessors[1:])
+	}
+
 	// Check for context interpolation.
 	if ok && k == "context" {
 		accessors[0].value = e.myContext
 		return e.evaluateValueAccess(x.repr.syntax(), e.myContext, accessors[1:])
 	}
 
+	// Check for a qualified reference to an aliased import.
+	if aliased, isAlias := e.myAliases[k]; ok && isAlias {
+		accessors[0].value = aliased
+		return e.evaluateValueAccess(x.repr.syntax(), aliased, accessors[1:])
+	}
+
+	// The root is not available while imports are being evaluated (e.g. within an import condition).
+	if receiver == nil {
+		e.errorf(x.repr.syntax(), "%v may only refer to imports or context", e.unrooted)
+		return e.invalidPropertyAccess(x.repr.syntax(), accessors)
+	}
+
+	// If the top-level key is not defined but a similar key is, the key is probably misspelled.
+	if suggestion, misspelled := e.suggestTopLevelKey(scope, k); ok && misspelled {
+		e.accessorErrorf(x.repr.syntax(), accessors[0].accessor, "unknown property %q; did you mean %q?", k, suggestion)
+		return e.invalidPropertyAccess(x.repr.syntax(), accessors)
+	}
+
 	for len(accessors) > 0 {
 		accessor := accessors[0]
 		if receiver == nil {
@@ -700,6 +1912,29 @@ func (e *evalContext) evaluateExprAccess(x *expr, accessors []*propertyAccessor)
 	return e.evaluateExpr(receiver)
 }
 
+// suggestTopLevelKey returns a defined top-level key that is similar to the undefined top-level key k, if any.
+// Candidates include the environment's own keys, the keys of its imports, the names of aliased imports, and the names
+// bound by the given scope.
+func (e *evalContext) suggestTopLevelKey(scope *scope, k string) (string, bool) {
+	root := e.root.repr.(*objectExpr)
+	if _, ok := root.properties[k]; ok {
+		return "", false
+	}
+
+	baseKeys := e.base.keys()
+	for _, b := range baseKeys {
+		if b == k {
+			return "", false
+		}
+	}
+
+	candidates := append(maps.Keys(root.properties), baseKeys...)
+	candidates = append(candidates, "imports", "context")
+	candidates = append(candidates, maps.Keys(e.myAliases)...)
+	candidates = append(candidates, scope.names()...)
+	return yamldiags.Suggest(candidates, k)
+}
+
 // evaluateValueAccess evaluates a list of accessors relative to a value receiver.
 func (e *evalContext) evaluateValueAccess(syntax ast.Expr, receiver *value, accessors []*propertyAccessor) *value {
 	for len(accessors) > 0 {
@@ -805,8 +2040,10 @@ func (e *evalContext) invalidPropertyAccess(syntax ast.Expr, accessors []*proper
 	return accessors[len(accessors)-1].value
 }
 
-// arrayIndex extracts an array index from an accessor. If the accessor is not an integer or is out of bounds,
-// arrayIndex generates an appropriate error and returns false.
+// arrayIndex extracts an array index from an accessor. Negative indices count back from the end of the array, so -1
+// refers to the last element. If the length of the array is not known (i.e. len is negative), negative indices are
+// returned as-is. If the accessor is not an integer or is out of bounds, arrayIndex generates an appropriate error and
+// returns false.
 func (e *evalContext) arrayIndex(expr ast.Expr, accessor ast.PropertyAccessor, len int) (int, bool) {
 	sub, ok := accessor.(*ast.PropertySubscript)
 	if !ok {
@@ -818,15 +2055,19 @@ func (e *evalContext) arrayIndex(expr ast.Expr, accessor ast.PropertyAccessor, l
 		e.accessorError(expr, accessor, "cannot access an array element using a property name")
 		return 0, false
 	}
-	if index < 0 {
-		e.accessorError(expr, accessor, "array indices must not be negative")
-		return 0, false
+	if len < 0 {
+		return index, true
 	}
-	if len >= 0 && index >= len {
+
+	resolved := index
+	if resolved < 0 {
+		resolved += len
+	}
+	if resolved < 0 || resolved >= len {
 		e.accessorErrorf(expr, accessor, "array index %v out-of-bounds for array of length %v", index, len)
 		return 0, false
 	}
-	return index, true
+	return resolved, true
 }
 
 // objectKey extracts an object key from an accessor. If the accessor is not a string, objectKey generates an
@@ -881,6 +2122,24 @@ func (e *evalContext) evaluateBuiltinSecret(x *expr, repr *secretExpr) *value {
 	return v
 }
 
+// evaluateBuiltinSecretIf evaluates a call to the fn::secretIf builtin. If the condition is true, the result is a
+// secret copy of the value. If the condition is false, the result is a copy of the value. If the condition is unknown,
+// the value is conservatively treated as secret.
+func (e *evalContext) evaluateBuiltinSecretIf(x *expr, repr *secretIfExpr) *value {
+	condition, conditionOK := e.evaluateTypedExpr(repr.condition, schema.Boolean().Schema())
+	arg := e.evaluateExpr(repr.value)
+
+	// We make a copy of the result here for the same reasons as evaluatePropertyAccess.
+	var result *value
+	if !conditionOK || condition.unknown || condition.repr.(bool) {
+		result = arg.secretCopy()
+	} else {
+		result = newCopier().copy(arg)
+	}
+	result.def = x
+	return result
+}
+
 // evaluateBuiltinOpen evaluates a call to the fn::open builtin. This involves loading the provider, fetching its
 // schemata, evaluating the inputs, and when not validating, opening the provider with the given inputs. During
 // validation, the result is an unknown value with the output schema.
@@ -894,9 +2153,14 @@ func (e *evalContext) evaluateBuiltinOpen(x *expr, repr *openExpr) *value {
 		return v
 	}
 
-	provider, err := e.providers.LoadProvider(e.ctx, repr.node.Provider.GetValue())
+	name := repr.node.Provider.GetValue()
+	provider, err := e.providers.LoadProvider(e.ctx, name)
 	if err != nil {
-		e.errorf(repr.syntax(), "%v", err)
+		if suggestion, misspelled := e.suggestProvider(name); misspelled {
+			e.errorf(repr.node.Provider, "unknown provider %q; did you mean %q?", name, suggestion)
+		} else {
+			e.errorf(repr.syntax(), "%v", err)
+		}
 	} else {
 		inputSchema, outputSchema := provider.Schema()
 		if err := inputSchema.Compile(); err != nil {
@@ -914,18 +2178,130 @@ func (e *evalContext) evaluateBuiltinOpen(x *expr, repr *openExpr) *value {
 	v.schema = x.schema
 
 	inputs, ok := e.evaluateTypedExpr(repr.inputs, repr.inputSchema)
+	if ok && err == nil {
+		e.captureOpen(repr, inputs, x.schema)
+	}
 	if !ok || inputs.containsUnknowns() || e.validating || err != nil {
 		v.unknown = true
 		return v
 	}
 
-	output, err := provider.Open(e.ctx, inputs.export("").Value.(map[string]esc.Value), e.execContext)
+	args := inputs.export("").Value.(map[string]esc.Value)
+	applyInputDefaults(args, repr.inputSchema)
+
+	var start time.Time
+	if e.metrics != nil {
+		start = time.Now()
+	}
+	output, err := openProvider(e.ctx, provider, args, e.execContext)
+	if e.metrics != nil {
+		record(&e.metrics.Opens, repr.node.Provider.GetValue(), start)
+	}
 	if err != nil {
-		e.errorf(repr.syntax(), "%s", err.Error())
+		e.openError(repr, inputs, err)
 		v.unknown = true
 		return v
 	}
-	return unexport(output, x)
+
+	v = unexport(output, x)
+	markHidden(v, x.schema)
+	return v
+}
+
+// openProvider calls the given provider's Open method. Providers are implemented outside of the evaluator, so a panic
+// within a provider is recovered and returned as an error. This reports the failure on the call to fn::open rather
+// than crashing the process, and allows the rest of the environment to evaluate.
+func openProvider(
+	ctx context.Context,
+	provider esc.Provider,
+	inputs map[string]esc.Value,
+	execContext esc.EnvExecContext,
+) (output esc.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("provider panicked: %v", r)
+		}
+	}()
+	return provider.Open(ctx, inputs, execContext)
+}
+
+// suggestProvider returns a known provider name that is similar to the unknown provider name, if any. Suggestions are
+// only available if the evaluator's ProviderLoader is a ProviderLister.
+func (e *evalContext) suggestProvider(name string) (string, bool) {
+	lister, ok := e.providers.(ProviderLister)
+	if !ok {
+		return "", false
+	}
+	names := lister.ProviderNames()
+	if slices.Contains(names, name) {
+		return "", false
+	}
+	return yamldiags.Suggest(names, name)
+}
+
+// applyInputDefaults fills in any properties that are absent from a provider's inputs with the defaults from the
+// provider's input schema. Defaults are applied recursively to nested objects. Inputs that are present (including
+// inputs that are explicitly null) are never replaced.
+func applyInputDefaults(inputs map[string]esc.Value, s *schema.Schema) {
+	if s == nil {
+		return
+	}
+	if ref := s.GetRef(); ref != nil {
+		applyInputDefaults(inputs, ref)
+	}
+
+	for k, ps := range s.Properties {
+		if v, ok := inputs[k]; ok {
+			if obj, ok := v.Value.(map[string]esc.Value); ok {
+				applyInputDefaults(obj, ps)
+			}
+			continue
+		}
+
+		if ps.Default == nil && ps.GetRef() != nil {
+			ps = ps.GetRef()
+		}
+		if ps.Default != nil {
+			if v, err := esc.FromJSON(ps.Default, false); err == nil {
+				inputs[k] = v
+			}
+		}
+	}
+}
+
+// captureOpen records a call to fn::open in the evalContext's capture, if any. Secret inputs are redacted. Calls are
+// recorded during validation even though the provider is not invoked.
+func (e *evalContext) captureOpen(repr *openExpr, inputs *value, outputSchema *schema.Schema) {
+	if e.openCapture == nil {
+		return
+	}
+	e.openCapture.Calls = append(e.openCapture.Calls, OpenCall{
+		Environment: e.name,
+		Range:       convertRange(repr.syntax().Syntax().Syntax().Range(), e.name),
+		Provider:    repr.node.Provider.GetValue(),
+		Inputs:      redactSecrets(inputs.export(e.name)),
+		Schema:      outputSchema,
+	})
+}
+
+// openError records an error returned by a provider. The resulting diagnostic names the provider and includes the
+// inputs that were passed to the provider in its detail. Secret inputs are redacted. If the provider's input schema
+// has examples, the first example is included in the detail as a hint.
+func (e *evalContext) openError(repr *openExpr, inputs *value, err error) {
+	diag := ast.ExprError(repr.syntax(), fmt.Sprintf("opening provider %q: %v", repr.node.Provider.GetValue(), err))
+
+	var detail []string
+	if b, err := json.Marshal(inputs.export("").ToJSON(true)); err == nil {
+		detail = append(detail, fmt.Sprintf("inputs: %s", b))
+	}
+	if repr.inputSchema != nil && len(repr.inputSchema.Examples) != 0 {
+		if b, err := json.Marshal(repr.inputSchema.Examples[0]); err == nil {
+			detail = append(detail, fmt.Sprintf("expected shape like: %s", b))
+		}
+	}
+	diag.Detail = strings.Join(detail, "\n")
+
+	e.diags.Extend(diag)
 }
 
 // evaluateBuiltinJoin evaluates a call to the fn::join builtin.
@@ -950,85 +2326,1895 @@ func (e *evalContext) evaluateBuiltinJoin(x *expr, repr *joinExpr) *value {
 	return v
 }
 
-// evaluateBuiltinFromBase64 evaluates a call from the fn::fromBase64 builtin.
-func (e *evalContext) evaluateBuiltinFromBase64(x *expr, repr *fromBase64Expr) *value {
+// evaluateBuiltinArithmetic evaluates a call to the fn::add, fn::sub, fn::mul, or fn::div builtins. Arithmetic is
+// performed exactly using arbitrary-precision rational numbers. Integral results are rendered as integers and other
+// results with a terminating decimal expansion are rendered exactly. Quotients without a terminating decimal expansion
+// are rounded to a precision sized from the operands (see formatRat).
+func (e *evalContext) evaluateBuiltinArithmetic(x *expr, repr *arithmeticExpr) *value {
 	v := &value{def: x, schema: x.schema}
 
-	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
-	if !ok {
+	left, leftOK := e.evaluateTypedExpr(repr.left, schema.Number().Schema())
+	right, rightOK := e.evaluateTypedExpr(repr.right, schema.Number().Schema())
+	if !leftOK || !rightOK {
 		v.unknown = true
 		return v
 	}
 
-	v.combine(str)
-	if !v.unknown {
-		b, err := base64.StdEncoding.DecodeString(str.repr.(string))
-		if err != nil {
-			e.errorf(repr.syntax(), "decoding base64 string: %v", err)
-			v.unknown = true
-			return v
-		}
-		v.repr = string(b)
+	v.combine(left, right)
+	if v.unknown {
+		return v
 	}
-	return v
-}
-
-// evaluateBuiltinFromJSON evaluates a call from the fn::fromJSON builtin.
-func (e *evalContext) evaluateBuiltinFromJSON(x *expr, repr *fromJSONExpr) *value {
-	v := &value{def: x, schema: x.schema}
 
-	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
-	if !ok {
+	l, lok := new(big.Rat).SetString(string(left.repr.(json.Number)))
+	r, rok := new(big.Rat).SetString(string(right.repr.(json.Number)))
+	if !lok || !rok {
+		e.errorf(repr.syntax(), "internal error: invalid number")
 		v.unknown = true
 		return v
 	}
 
-	v.combine(str)
-	if !v.unknown {
-		dec := json.NewDecoder(strings.NewReader(str.repr.(string)))
-		dec.UseNumber()
-
-		var jv any
-		if err := dec.Decode(&jv); err != nil {
-			e.errorf(repr.syntax(), "decoding JSON string: %v", err)
+	var result big.Rat
+	switch repr.node.Op {
+	case ast.ArithmeticAdd:
+		result.Add(l, r)
+	case ast.ArithmeticSub:
+		result.Sub(l, r)
+	case ast.ArithmeticMul:
+		result.Mul(l, r)
+	case ast.ArithmeticDiv:
+		if r.Sign() == 0 {
+			e.errorf(repr.right.repr.syntax(), "division by zero")
 			v.unknown = true
 			return v
 		}
+		result.Quo(l, r)
+	}
 
-		ev, err := esc.FromJSON(jv, v.secret)
-		if err != nil {
-			e.errorf(repr.syntax(), "internal error: decoding JSON value: %v", err)
-			v.unknown = true
-			return v
+	v.repr = json.Number(formatRat(&result, l, r))
+	return v
+}
+
+// formatRat renders the result of an arithmetic operation on the given operands as a canonical JSON number. Integers
+// are rendered without an exponent, and numbers with a terminating decimal expansion are rendered exactly. Other numbers
+// are rounded to a binary precision that can represent every digit of the operands (but no less than 64 bits) and
+// rendered as the shortest decimal that uniquely identifies the rounded value.
+func formatRat(x, left, right *big.Rat) string {
+	if x.IsInt() {
+		return x.Num().String()
+	}
+
+	// A fraction in lowest terms has a terminating decimal expansion iff its denominator has no prime factors other
+	// than 2 and 5. The number of digits after the decimal point is the larger of the two multiplicities.
+	denom, twos, fives := new(big.Int).Set(x.Denom()), 0, 0
+	for denom.Bit(0) == 0 {
+		denom.Rsh(denom, 1)
+		twos++
+	}
+	five, mod := big.NewInt(5), new(big.Int)
+	for {
+		q, m := new(big.Int).QuoRem(denom, five, mod)
+		if m.Sign() != 0 {
+			break
 		}
+		denom = q
+		fives++
+	}
+	if denom.IsInt64() && denom.Int64() == 1 {
+		return x.FloatString(max(twos, fives))
+	}
 
-		return unexport(ev, x)
+	digits := 0
+	for _, operand := range []*big.Rat{left, right} {
+		digits += len(operand.Num().String()) + len(operand.Denom().String())
 	}
-	return v
+	prec := max(uint(64), uint(math.Ceil(float64(digits)*math.Log2(10))))
+	return new(big.Float).SetPrec(prec).SetRat(x).Text('g', -1)
 }
 
-// evaluateBuiltinToBase64 evaluates a call to the fn::toBase64 builtin.
-func (e *evalContext) evaluateBuiltinToBase64(x *expr, repr *toBase64Expr) *value {
+// evaluateBuiltinClamp evaluates a call to the fn::clamp builtin. The result is min if the value is less than min, max
+// if the value is greater than max, and the value otherwise. It is an error for min to be greater than max.
+func (e *evalContext) evaluateBuiltinClamp(x *expr, repr *clampExpr) *value {
 	v := &value{def: x, schema: x.schema}
 
-	str, ok := e.evaluateTypedExpr(repr.value, schema.String().Schema())
-	if !ok {
+	value, valueOK := e.evaluateTypedExpr(repr.value, schema.Number().Schema())
+	min, minOK := e.evaluateTypedExpr(repr.min, schema.Number().Schema())
+	max, maxOK := e.evaluateTypedExpr(repr.max, schema.Number().Schema())
+	if !valueOK || !minOK || !maxOK {
 		v.unknown = true
 		return v
 	}
 
-	v.combine(str)
-	if !v.unknown {
-		v.repr = base64.StdEncoding.EncodeToString([]byte(str.repr.(string)))
+	v.combine(value, min, max)
+	if v.unknown {
+		return v
+	}
+
+	n, _, nerr := big.ParseFloat(string(value.repr.(json.Number)), 10, 0, big.ToNearestEven)
+	lo, _, loerr := big.ParseFloat(string(min.repr.(json.Number)), 10, 0, big.ToNearestEven)
+	hi, _, hierr := big.ParseFloat(string(max.repr.(json.Number)), 10, 0, big.ToNearestEven)
+	if nerr != nil || loerr != nil || hierr != nil {
+		e.errorf(repr.syntax(), "internal error: invalid number")
+		v.unknown = true
+		return v
+	}
+	if lo.Cmp(hi) > 0 {
+		e.errorf(repr.syntax(), "min (%v) must not be greater than max (%v)", min.repr, max.repr)
+		v.unknown = true
+		return v
+	}
+
+	switch {
+	case n.Cmp(lo) < 0:
+		v.repr = min.repr
+	case n.Cmp(hi) > 0:
+		v.repr = max.repr
+	default:
+		v.repr = value.repr
 	}
 	return v
 }
 
-// evaluateBuiltinToJSON evaluates a call to the fn::toJSON builtin.
-func (e *evalContext) evaluateBuiltinToJSON(x *expr, repr *toJSONExpr) *value {
-	v := &value{def: x, schema: x.schema}
+// maxGeneratedElements is the largest number of elements that a builtin may generate from a count (e.g. the elements
+// generated by fn::repeat or the characters generated by fn::padLeft). This prevents a small environment from producing
+// an arbitrarily large value.
+const maxGeneratedElements = 10000
 
-	value := e.evaluateExpr(repr.value)
+// evaluateBuiltinRepeat evaluates a call to the fn::repeat builtin. The result is an array that contains count copies
+// of the value. The count must be a non-negative integer no greater than maxGeneratedElements. The result is known if
+// the count is known, even if the value is not.
+func (e *evalContext) evaluateBuiltinRepeat(x *expr, repr *repeatExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	element := e.evaluateExpr(repr.value)
+	count, countOK := e.evaluateTypedExpr(repr.count, schema.Number().Schema())
+	if !countOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(count)
+	if v.unknown {
+		return v
+	}
+
+	n, err := count.repr.(json.Number).Int64()
+	if err != nil || n < 0 {
+		e.errorf(repr.count.repr.syntax(), "count must be a non-negative integer")
+		v.unknown = true
+		return v
+	}
+	if n > maxGeneratedElements {
+		e.errorf(repr.count.repr.syntax(), "count must not exceed %v", maxGeneratedElements)
+		v.unknown = true
+		return v
+	}
+
+	elements := make([]*value, n)
+	for i := range elements {
+		elements[i] = newCopier().copy(element)
+		elements[i].def = x
+	}
+
+	v.repr, v.schema = elements, schema.Array().Items(element.schema).Schema()
+	return v
+}
+
+// evaluateBuiltinSet evaluates a call to the fn::union, fn::intersection, or fn::difference builtins. Each operand is
+// treated as a set of distinct values, and values are compared structurally. The elements of the result are distinct
+// and appear in the order in which they first appear in the operands: the result of fn::union contains the elements of
+// every operand, the result of fn::intersection contains the elements of the first operand that are present in every
+// other operand, and the result of fn::difference contains the elements of the first operand that are not present in
+// any other operand.
+func (e *evalContext) evaluateBuiltinSet(x *expr, repr *setExpr) *value {
+	v := &value{def: x, schema: x.schema}
+	if len(repr.operands) == 0 {
+		// The argument was invalid. The error has already been reported by the parser.
+		v.unknown = true
+		return v
+	}
+
+	ok, sets := true, make([][]*value, len(repr.operands))
+	for i, operand := range repr.operands {
+		set, setOK := e.evaluateTypedExpr(operand, schema.Array().Items(schema.Always()).Schema())
+		if !setOK {
+			ok = false
+			continue
+		}
+		v.combine(set)
+		if !set.unknown {
+			sets[i] = set.repr.([]*value)
+		}
+	}
+	if !ok {
+		v.unknown = true
+		return v
+	}
+	if v.unknown {
+		return v
+	}
+
+	contains := func(set []*value, element *value) bool {
+		for _, e := range set {
+			if e.equals(element) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var candidates []*value
+	switch repr.node.Op {
+	case ast.SetUnion:
+		for _, set := range sets {
+			candidates = append(candidates, set...)
+		}
+	default:
+		candidates = sets[0]
+	}
+
+	result, schemas := []*value{}, []schema.Builder{}
+	for _, element := range candidates {
+		if contains(result, element) {
+			continue
+		}
+
+		switch repr.node.Op {
+		case ast.SetIntersection:
+			include := true
+			for _, set := range sets[1:] {
+				include = include && contains(set, element)
+			}
+			if !include {
+				continue
+			}
+		case ast.SetDifference:
+			exclude := false
+			for _, set := range sets[1:] {
+				exclude = exclude || contains(set, element)
+			}
+			if exclude {
+				continue
+			}
+		}
+
+		result, schemas = append(result, newCopier().copy(element)), append(schemas, element.schema)
+	}
+
+	v.repr, v.schema = result, schema.Tuple(schemas...).Schema()
+	return v
+}
+
+// evaluateBuiltinLogical evaluates a call to the fn::and or fn::or builtins. Operands are evaluated in order until an
+// operand's value is equal to shortCircuit, at which point evaluation stops and the result is shortCircuit. Later
+// operands are not evaluated, so any side effects they have (e.g. opening a provider) do not occur.
+func (e *evalContext) evaluateBuiltinLogical(x *expr, operands []*expr, shortCircuit bool) *value {
+	v := &value{def: x, schema: x.schema}
+	if len(operands) == 0 {
+		// The argument was invalid. The error has already been reported by the parser.
+		v.unknown = true
+		return v
+	}
+
+	for _, operand := range operands {
+		b, ok := e.evaluateTypedExpr(operand, schema.Boolean().Schema())
+		if !ok {
+			v.unknown = true
+			return v
+		}
+
+		v.combine(b)
+		if !b.unknown && b.repr.(bool) == shortCircuit {
+			// The result is known regardless of any unknown operands that preceded this one.
+			v.unknown, v.repr = false, shortCircuit
+			return v
+		}
+	}
+
+	if !v.unknown {
+		v.repr = !shortCircuit
+	}
+	return v
+}
+
+// evaluateBuiltinNot evaluates a call to the fn::not builtin.
+func (e *evalContext) evaluateBuiltinNot(x *expr, repr *notExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	b, ok := e.evaluateTypedExpr(repr.operand, schema.Boolean().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(b)
+	if !v.unknown {
+		v.repr = !b.repr.(bool)
+	}
+	return v
+}
+
+// evaluateBuiltinEquals evaluates a call to the fn::equals builtin. Values are compared structurally. Numbers are
+// compared by numeric value rather than by representation.
+func (e *evalContext) evaluateBuiltinEquals(x *expr, repr *equalsExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	left, right := e.evaluateExpr(repr.left), e.evaluateExpr(repr.right)
+
+	v.combine(left, right)
+	if !v.unknown {
+		v.repr = left.equals(right)
+	}
+	return v
+}
+
+// objectDiffSchema is the schema of the result of a call to the fn::objectDiff builtin.
+var objectDiffSchema = schema.Record(schema.BuilderMap{
+	"added":   schema.Object().AdditionalProperties(schema.Always()),
+	"removed": schema.Object().AdditionalProperties(schema.Always()),
+	"changed": schema.Object().AdditionalProperties(schema.Always()),
+}).Schema()
+
+// evaluateBuiltinObjectDiff evaluates a call to the fn::objectDiff builtin. The result describes the properties that
+// were added to, removed from, or changed between a and b. Values are compared structurally (see fn::equals).
+func (e *evalContext) evaluateBuiltinObjectDiff(x *expr, repr *objectDiffExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	objectSchema := schema.Object().AdditionalProperties(schema.Always()).Schema()
+	a, aOK := e.evaluateTypedExpr(repr.a, objectSchema)
+	b, bOK := e.evaluateTypedExpr(repr.b, objectSchema)
+	if !aOK || !bOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(a, b)
+	if v.unknown {
+		return v
+	}
+
+	diff := diffObjects(x, a, b)
+	diff.secret = v.secret
+	return diff
+}
+
+// groupBySchema is the schema of the items argument to the fn::groupBy builtin.
+var groupBySchema = schema.Array().Items(schema.Object().AdditionalProperties(schema.Always())).Schema()
+
+// evaluateBuiltinGroupBy evaluates a call to the fn::groupBy builtin. Elements are grouped by the value of the named
+// property, which must be a string, number, or boolean. Numbers and booleans are grouped by their string
+// representations. Elements whose property is missing or null are grouped under the empty string unless strict is
+// true, in which case they are reported as errors. The order of the elements within each group is preserved.
+func (e *evalContext) evaluateBuiltinGroupBy(x *expr, repr *groupByExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	items, itemsOK := e.evaluateTypedExpr(repr.items, groupBySchema)
+	key, keyOK := e.evaluateTypedExpr(repr.key, schema.String().Schema())
+	strict, strictOK := &value{repr: false}, true
+	if repr.strict != nil {
+		strict, strictOK = e.evaluateTypedExpr(repr.strict, schema.Boolean().Schema())
+	}
+	if !itemsOK || !keyOK || !strictOK {
+		v.unknown = true
+		return v
+	}
+
+	v.unknown = items.unknown || key.unknown || strict.unknown
+	v.secret = items.secret || key.secret
+	if v.unknown {
+		return v
+	}
+
+	name, ok := key.repr.(string), true
+	groups := map[string][]*value{}
+	for i, element := range items.repr.([]*value) {
+		if element.unknown {
+			v.unknown = true
+			continue
+		}
+
+		group := ""
+		switch property := element.property(repr.key.repr.syntax(), name); {
+		case property == nil || property.repr == nil && !property.unknown:
+			if strict.repr.(bool) {
+				e.errorf(repr.items.repr.syntax(), "element %v is missing the property %q", i, name)
+				ok = false
+				continue
+			}
+		case property.unknown:
+			v.unknown = true
+			continue
+		default:
+			switch property.repr.(type) {
+			case string, json.Number, bool:
+				// The group names become property names, so if any group name is secret, the entire result is secret.
+				group, _, _ = property.toString()
+				v.secret = v.secret || property.secret
+			default:
+				e.errorf(repr.items.repr.syntax(), "property %q of element %v must be a string, number, or boolean, not %v",
+					name, i, property.typeName())
+				ok = false
+				continue
+			}
+		}
+
+		element = newCopier().copy(element)
+		element.def = x
+		groups[group] = append(groups[group], element)
+	}
+	if !ok {
+		v.unknown = true
+		return v
+	}
+	if v.unknown {
+		return v
+	}
+
+	object, properties := make(map[string]*value, len(groups)), make(schema.SchemaMap, len(groups))
+	for group, elements := range groups {
+		schemas := make([]schema.Builder, len(elements))
+		for i, element := range elements {
+			schemas[i] = element.schema
+		}
+		s := schema.Tuple(schemas...).Schema()
+		object[group], properties[group] = &value{def: x, schema: s, repr: elements}, s
+	}
+
+	v.repr, v.schema = object, schema.Record(properties).Schema()
+	return v
+}
+
+// objectFromKeysSchema is the schema of the keys argument to the fn::objectFromKeys builtin.
+var objectFromKeysSchema = schema.Array().Items(schema.String()).Schema()
+
+// evaluateBuiltinObjectFromKeys evaluates a call to the fn::objectFromKeys builtin. The result is an object that maps
+// each key either to a copy of the shared value or to the element of the values array at the same index. Keys must be
+// distinct, and the values array must have the same length as the keys. The result is unknown if the keys are unknown
+// or if the values array or its length is unknown.
+func (e *evalContext) evaluateBuiltinObjectFromKeys(x *expr, repr *objectFromKeysExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	keys, keysOK := e.evaluateTypedExpr(repr.keys, objectFromKeysSchema)
+
+	var shared, values *value
+	valuesOK := true
+	switch {
+	case repr.value != nil:
+		shared = e.evaluateExpr(repr.value)
+	case repr.values != nil:
+		values, valuesOK = e.evaluateTypedExpr(repr.values, schema.Array().Items(schema.Always()).Schema())
+	default:
+		valuesOK = false
+	}
+	if !keysOK || !valuesOK {
+		v.unknown = true
+		return v
+	}
+
+	// The keys become property names, so if any key is secret, the entire result is secret.
+	v.combine(keys)
+	if values != nil {
+		v.unknown, v.secret = v.unknown || values.unknown, v.secret || values.secret
+	}
+	if v.unknown {
+		return v
+	}
+
+	keyElements := keys.repr.([]*value)
+	names := make([]string, len(keyElements))
+	for i, k := range keyElements {
+		v.combine(k)
+		if !k.unknown {
+			names[i] = k.repr.(string)
+		}
+	}
+	if v.unknown {
+		return v
+	}
+
+	var elements []*value
+	if values != nil {
+		elements = values.repr.([]*value)
+		if len(elements) != len(names) {
+			e.errorf(repr.values.repr.syntax(), "expected %v values to match the keys, but got %v", len(names), len(elements))
+			v.unknown = true
+			return v
+		}
+	}
+
+	object, properties := make(map[string]*value, len(names)), make(schema.SchemaMap, len(names))
+	for i, name := range names {
+		if _, has := object[name]; has {
+			e.errorf(repr.keys.repr.syntax(), "duplicate key %q", name)
+			v.unknown = true
+			return v
+		}
+
+		source := shared
+		if elements != nil {
+			source = elements[i]
+		}
+		property := newCopier().copy(source)
+		property.def = x
+
+		object[name], properties[name] = property, property.schema
+	}
+
+	v.repr, v.schema = object, schema.Record(properties).Schema()
+	return v
+}
+
+// diffObjects returns an object that describes the differences between the objects a and b:
+//
+//   - added contains the properties of b that are not present in a
+//   - removed contains the properties of a that are not present in b
+//   - changed contains the properties that are present in both a and b but have different values. If both values are
+//     objects, the property's entry is the diff of the two values. Otherwise, the entry is an object with the
+//     properties from (the value in a) and to (the value in b).
+func diffObjects(x *expr, a, b *value) *value {
+	newObject := func(properties map[string]*value) *value {
+		schemas := make(schema.SchemaMap, len(properties))
+		for k, p := range properties {
+			schemas[k] = p.schema
+		}
+		return &value{def: x, repr: properties, schema: schema.Record(schemas).Schema()}
+	}
+	copyValue := func(v *value) *value {
+		c := newCopier().copy(v)
+		c.def = x
+		return c
+	}
+
+	aKeys, bKeys := a.keys(), b.keys()
+	inA := make(map[string]bool, len(aKeys))
+	for _, k := range aKeys {
+		inA[k] = true
+	}
+
+	added, removed, changed := map[string]*value{}, map[string]*value{}, map[string]*value{}
+	for _, k := range bKeys {
+		bv := b.property(nil, k)
+		if !inA[k] {
+			added[k] = copyValue(bv)
+			continue
+		}
+		delete(inA, k)
+
+		av := a.property(nil, k)
+		switch {
+		case av.equals(bv):
+			// unchanged
+		case av.isObject() && bv.isObject():
+			changed[k] = diffObjects(x, av, bv)
+		default:
+			changed[k] = newObject(map[string]*value{"from": copyValue(av), "to": copyValue(bv)})
+		}
+	}
+	for _, k := range aKeys {
+		if inA[k] {
+			removed[k] = copyValue(a.property(nil, k))
+		}
+	}
+
+	return newObject(map[string]*value{
+		"added":   newObject(added),
+		"removed": newObject(removed),
+		"changed": newObject(changed),
+	})
+}
+
+// declareTemplate declares a fresh copy of the template of a call to fn::map, fn::filter, or fn::reduce (or the body
+// of a call to fn::let) within the given scope. Each element requires its own copy of the template, as expressions
+// cache the result of their evaluation.
+//
+// The property accesses within a template are checked along with the rest of the root's accesses when the root is
+// declared. Copies that are declared during evaluation are checked as they are declared so that they resolve in the
+// same way, but any errors have already been reported by the original check and are discarded.
+func (e *evalContext) declareTemplate(s *scope, template ast.Expr) *expr {
+	outer := e.scope
+	defer func() { e.scope = outer }()
+
+	e.scope = s
+	n := len(e.accesses)
+	x := declare(e, "", template, nil)
+	if e.checked {
+		diags := len(e.diags)
+		for _, a := range e.accesses[n:] {
+			e.checkAccess(a.syntax, a.access)
+		}
+		e.diags, e.accesses = e.diags[:diags], e.accesses[:n]
+	}
+	return x
+}
+
+// reportOnce removes any diagnostics reported since the given start index that duplicate a diagnostic reported since
+// then. Errors in the template of a call to fn::map or fn::filter are likely to recur for each element, so each
+// distinct error is only reported once.
+func (e *evalContext) reportOnce(start int) {
+	seen := map[string]bool{}
+	diags := e.diags[start:]
+	e.diags = e.diags[:start:start]
+	for _, d := range diags {
+		key := fmt.Sprintf("%v: %v", d.Subject, d.Summary)
+		if !seen[key] {
+			seen[key] = true
+			e.diags = append(e.diags, d)
+		}
+	}
+}
+
+// evaluateBuiltinLet evaluates a call to the fn::let builtin. Bindings are not evaluated here: each binding is
+// evaluated when it is first accessed from within the body, and the result is shared by all accesses.
+func (e *evalContext) evaluateBuiltinLet(x *expr, repr *letExpr) *value {
+	// We make a copy of the result here for the same reasons as evaluatePropertyAccess.
+	result := newCopier().copy(e.evaluateExpr(repr.in))
+	result.def = x
+	return result
+}
+
+// evaluateBuiltinMap evaluates a call to the fn::map builtin. The template is evaluated once for each element of the
+// list, with the element bound to the call's binding name (by default, "item").
+func (e *evalContext) evaluateBuiltinMap(x *expr, repr *mapExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	items, ok := e.evaluateTypedExpr(repr.items, schema.Array().Items(schema.Always()).Schema())
+	if !ok || items.unknown {
+		v.unknown = true
+		return v
+	}
+
+	elements := items.repr.([]*value)
+	results, schemas := make([]*value, len(elements)), make([]schema.Builder, len(elements))
+	start := len(e.diags)
+	for i, element := range elements {
+		each := e.declareTemplate(&scope{parent: repr.scope, name: repr.node.BindingName(), value: element}, repr.node.Each)
+		result := e.evaluateExpr(each)
+		results[i], schemas[i] = result, result.schema
+	}
+	e.reportOnce(start)
+
+	v.repr, v.schema, v.secret = results, schema.Tuple(schemas...).Schema(), items.secret
+	return v
+}
+
+// evaluateBuiltinFilter evaluates a call to the fn::filter builtin. The predicate is evaluated once for each element
+// of the list, with the element bound to the call's binding name (by default, "item"). The result contains the elements
+// for which the predicate is true, in their original order. If the predicate is unknown for any element, the result is
+// unknown. If the predicate is secret for any element, the result is secret.
+func (e *evalContext) evaluateBuiltinFilter(x *expr, repr *filterExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	items, ok := e.evaluateTypedExpr(repr.items, schema.Array().Items(schema.Always()).Schema())
+	if !ok || items.unknown {
+		v.unknown = true
+		return v
+	}
+	v.secret = items.secret
+
+	elements := items.repr.([]*value)
+	var results []*value
+	var schemas []schema.Builder
+	start := len(e.diags)
+	predicateSchema := schema.Boolean().Schema()
+	for i, element := range elements {
+		where := e.declareTemplate(&scope{parent: repr.scope, name: repr.node.BindingName(), value: element}, repr.node.Where)
+		include := e.evaluateExpr(where)
+		if e.coerceStrings {
+			include = coerceStrings(include, predicateSchema)
+		}
+
+		// Type errors are attributed to their element so that reportOnce does not merge them.
+		vv := validator{jsonPointers: e.jsonPointers, cache: e.validated}
+		if !vv.validateValue(include, predicateSchema, validationLoc{x: where}) {
+			for _, d := range vv.diags {
+				d.Summary = fmt.Sprintf("predicate for element %v: %s", i, d.Summary)
+			}
+			e.diags.Extend(vv.diags...)
+			v.unknown = true
+			continue
+		}
+		if include.unknown {
+			v.unknown = true
+			continue
+		}
+		v.secret = v.secret || include.secret
+
+		if include.repr.(bool) {
+			// We make a copy of the element here for the same reasons as evaluatePropertyAccess.
+			result := newCopier().copy(element)
+			results, schemas = append(results, result), append(schemas, result.schema)
+		}
+	}
+	e.reportOnce(start)
+
+	if !v.unknown {
+		v.repr, v.schema = append([]*value{}, results...), schema.Tuple(schemas...).Schema()
+	}
+	return v
+}
+
+// reducerScope returns the scope for the reducer of a call to fn::reduce, which binds both the accumulated value and
+// the current element.
+func reducerScope(repr *reduceExpr, acc, element *value) *scope {
+	accScope := &scope{parent: repr.scope, name: ast.AccumulatorName, value: acc}
+	return &scope{parent: accScope, name: repr.node.BindingName(), value: element}
+}
+
+// evaluateBuiltinReduce evaluates a call to the fn::reduce builtin. The reducer is evaluated once for each element of
+// the list, with the element bound to the call's binding name (by default, "item") and the result of the previous
+// evaluation bound to "acc". The initial value is bound to "acc" for the first element, and is returned as-is if the
+// list is empty.
+func (e *evalContext) evaluateBuiltinReduce(x *expr, repr *reduceExpr) *value {
+	items, ok := e.evaluateTypedExpr(repr.items, schema.Array().Items(schema.Always()).Schema())
+	if !ok || items.unknown {
+		return &value{def: x, schema: x.schema, unknown: true}
+	}
+
+	acc := e.evaluateExpr(repr.initial)
+	start := len(e.diags)
+	for _, element := range items.repr.([]*value) {
+		acc = e.evaluateExpr(e.declareTemplate(reducerScope(repr, acc, element), repr.node.Reducer))
+	}
+	e.reportOnce(start)
+
+	// We make a copy of the result here for the same reasons as evaluatePropertyAccess.
+	result := newCopier().copy(acc)
+	result.def, result.secret = x, result.secret || items.secret
+	return result
+}
+
+// evaluateBuiltinSwitch evaluates a call to the fn::switch builtin. Each case's condition is compared to the value in
+// order using structural equality (as per fn::equals), and the result of the first matching case is returned. If no
+// case matches, the default is returned. Only the selected result is evaluated, so errors in the results of other
+// cases are not reported. Conditions after the first match are not evaluated, either.
+//
+// Because the selected result reveals information about the value and the conditions, the result is secret if any
+// of the compared values are secret.
+func (e *evalContext) evaluateBuiltinSwitch(x *expr, repr *switchExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	// Can happen if there are parse errors.
+	if repr.node.Cases == nil {
+		v.unknown = true
+		return v
+	}
+
+	value := e.evaluateExpr(repr.value)
+	v.combine(value)
+	if v.unknown {
+		return v
+	}
+
+	selected := repr.defaultValue
+	for _, c := range repr.cases {
+		when := e.evaluateExpr(c.when)
+		v.combine(when)
+		if v.unknown {
+			return v
+		}
+		if value.equals(when) {
+			selected = c.then
+			break
+		}
+	}
+	if selected == nil {
+		e.errorf(repr.value.repr.syntax(), "no case matches the value, and no default was provided")
+		v.unknown = true
+		return v
+	}
+
+	// We make a copy of the result here for the same reasons as evaluatePropertyAccess.
+	result := newCopier().copy(e.evaluateExpr(selected))
+	result.def, result.secret = x, result.secret || v.secret
+	return result
+}
+
+// evaluateBuiltinSemverCompare evaluates a call to the fn::semverCompare builtin. The result is -1 if the first version
+// precedes the second, 0 if the versions have the same precedence, and 1 if the first version follows the second.
+func (e *evalContext) evaluateBuiltinSemverCompare(x *expr, repr *semverCompareExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	left, leftOK := e.evaluateTypedExpr(repr.left, schema.String().Schema())
+	right, rightOK := e.evaluateTypedExpr(repr.right, schema.String().Schema())
+	if !leftOK || !rightOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(left, right)
+	if v.unknown {
+		return v
+	}
+
+	l, lok := e.parseSemver(repr.left, left)
+	r, rok := e.parseSemver(repr.right, right)
+	if !lok || !rok {
+		v.unknown = true
+		return v
+	}
+
+	v.repr = json.Number(strconv.Itoa(l.Compare(r)))
+	return v
+}
+
+// parseSemver parses the semantic version in the given string value, reporting an error at x's range if the version is
+// invalid. A leading "v" is permitted.
+func (e *evalContext) parseSemver(x *expr, v *value) (semver.Version, bool) {
+	version, err := semver.Parse(strings.TrimPrefix(v.repr.(string), "v"))
+	if err != nil {
+		e.errorf(x.repr.syntax(), "invalid semantic version: %v", err)
+		return semver.Version{}, false
+	}
+	return version, true
+}
+
+// evaluateBuiltinGetOr evaluates a call to the fn::getOr builtin. The path is resolved against the value using the same
+// rules as Query. If the path does not resolve or resolves to null, the default is evaluated and returned. The default
+// is not evaluated otherwise.
+func (e *evalContext) evaluateBuiltinGetOr(x *expr, repr *getOrExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	from := e.evaluateExpr(repr.from)
+	path, ok := e.evaluateTypedExpr(repr.path, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(path)
+	if v.unknown {
+		return v
+	}
+
+	access, diags := ast.ParsePropertyPath(path.repr.(string))
+	if diags.HasErrors() {
+		for _, d := range diags {
+			e.errorf(repr.path.repr.syntax(), "invalid property path: %v", d.Summary)
+		}
+		v.unknown = true
+		return v
+	}
+
+	result, err := QueryAccess(from.export(e.name), access)
+	switch {
+	case err == nil && result.Unknown:
+		v.unknown = true
+		return v
+	case err == nil && result.Value != nil:
+		return unexport(result, x)
+	case err != nil && !errors.Is(err, ErrNotFound):
+		e.errorf(repr.syntax(), "%v", err)
+		v.unknown = true
+		return v
+	}
+
+	// We make a copy of the default here for the same reasons as evaluatePropertyAccess.
+	def := newCopier().copy(e.evaluateExpr(repr.defaultValue))
+	def.def = x
+	return def
+}
+
+// atPathSchema is the schema of the path argument to the fn::atPath builtin.
+var atPathSchema = schema.Array().Items(schema.AnyOf(schema.String(), schema.Number())).Schema()
+
+// evaluateBuiltinAtPath evaluates a call to the fn::atPath builtin. Strings in the path are property names and numbers
+// are array indices. If the path does not resolve to a value, the result is null, or an error if strict is true.
+func (e *evalContext) evaluateBuiltinAtPath(x *expr, repr *atPathExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	from := e.evaluateExpr(repr.value)
+	path, pathOK := e.evaluateTypedExpr(repr.path, atPathSchema)
+	strict, strictOK := &value{repr: false}, true
+	if repr.strict != nil {
+		strict, strictOK = e.evaluateTypedExpr(repr.strict, schema.Boolean().Schema())
+	}
+	if !pathOK || !strictOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(path, strict)
+	if v.unknown {
+		return v
+	}
+
+	segments := path.repr.([]*value)
+	access := &ast.PropertyAccess{Accessors: make([]ast.PropertyAccessor, len(segments))}
+	for i, segment := range segments {
+		switch repr := segment.repr.(type) {
+		case string:
+			access.Accessors[i] = &ast.PropertySubscript{Index: repr}
+		case json.Number:
+			index, err := repr.Int64()
+			if err != nil {
+				e.errorf(segment.def.repr.syntax(), "array indices must be integers")
+				v.unknown = true
+				return v
+			}
+			access.Accessors[i] = &ast.PropertySubscript{Index: int(index)}
+		}
+	}
+
+	result, err := QueryAccess(from.export(e.name), access)
+	switch {
+	case err == nil && result.Unknown:
+		v.unknown = true
+		return v
+	case err == nil:
+		return unexport(result, x)
+	case strict.repr.(bool):
+		e.errorf(repr.path.repr.syntax(), "%v", err)
+		v.unknown = true
+		return v
+	}
+	return v
+}
+
+// evaluateBuiltinMask evaluates a call to the fn::mask builtin. Lengths are measured in Unicode code points. If the
+// string has no more than the given number of visible characters, the entire string is masked. The result is always
+// secret.
+func (e *evalContext) evaluateBuiltinMask(x *expr, repr *maskExpr) *value {
+	v := &value{def: x, schema: x.schema, secret: true}
+
+	str, strOK := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	visible, visibleOK := e.evaluateTypedExpr(repr.visible, schema.Number().Schema())
+	if !strOK || !visibleOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str, visible)
+	if v.unknown {
+		return v
+	}
+
+	n, err := visible.repr.(json.Number).Int64()
+	if err != nil || n < 0 {
+		e.errorf(repr.visible.repr.syntax(), "visible must be a non-negative integer")
+		v.unknown = true
+		return v
+	}
+
+	runes := []rune(str.repr.(string))
+	masked := len(runes)
+	if int64(len(runes)) > n {
+		masked = len(runes) - int(n)
+	}
+	v.repr = strings.Repeat("*", masked) + string(runes[masked:])
+	return v
+}
+
+// evaluateBuiltinNearest evaluates a call to the fn::nearest builtin. The result is the option with the smallest edit
+// distance from the value. Ties are broken in favor of the lexically smallest option.
+func (e *evalContext) evaluateBuiltinNearest(x *expr, repr *nearestExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, strOK := e.evaluateTypedExpr(repr.value, schema.String().Schema())
+	options, optionsOK := e.evaluateTypedExpr(repr.options, schema.Array().Items(schema.String()).Schema())
+	if !strOK || !optionsOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str, options)
+	if v.unknown {
+		return v
+	}
+
+	elements := options.repr.([]*value)
+	words := make([]string, len(elements))
+	for i, o := range elements {
+		words[i] = o.repr.(string)
+	}
+
+	nearest, ok := yamldiags.Nearest(words, str.repr.(string))
+	if !ok {
+		e.errorf(repr.options.repr.syntax(), "options must not be empty")
+		v.unknown = true
+		return v
+	}
+	v.repr = nearest
+	return v
+}
+
+// evaluateBuiltinPad evaluates a call to the fn::padLeft or fn::padRight builtins. Lengths are measured in Unicode code
+// points. If the string is already at least as long as the target length, it is returned unchanged. The target length
+// must be no greater than maxGeneratedElements.
+func (e *evalContext) evaluateBuiltinPad(x *expr, repr *padExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, strOK := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	length, lengthOK := e.evaluateTypedExpr(repr.length, schema.Number().Schema())
+	pad, padOK := &value{repr: " "}, true
+	if repr.pad != nil {
+		pad, padOK = e.evaluateTypedExpr(repr.pad, schema.String().Schema())
+	}
+	if !strOK || !lengthOK || !padOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str, length, pad)
+	if v.unknown {
+		return v
+	}
+
+	n, err := length.repr.(json.Number).Int64()
+	if err != nil {
+		e.errorf(repr.length.repr.syntax(), "length must be an integer")
+		v.unknown = true
+		return v
+	}
+	if n > maxGeneratedElements {
+		e.errorf(repr.length.repr.syntax(), "length must not exceed %v", maxGeneratedElements)
+		v.unknown = true
+		return v
+	}
+
+	padRunes := []rune(pad.repr.(string))
+	if len(padRunes) != 1 {
+		e.errorf(repr.pad.repr.syntax(), "pad must be a single character")
+		v.unknown = true
+		return v
+	}
+
+	s := str.repr.(string)
+	if count := int64(len([]rune(s))); count < n {
+		padding := strings.Repeat(string(padRunes), int(n-count))
+		if repr.node.Side == ast.PadLeft {
+			s = padding + s
+		} else {
+			s += padding
+		}
+	}
+	v.repr = s
+	return v
+}
+
+var durationUnitSchema = schema.String().Enum("seconds", "milliseconds").Schema()
+
+// arnSchema is the schema of the result of a call to the fn::parseArn builtin.
+var arnSchema = schema.Record(schema.SchemaMap{
+	"partition": schema.String().Schema(),
+	"service":   schema.String().Schema(),
+	"region":    schema.String().Schema(),
+	"accountId": schema.String().Schema(),
+	"resource":  schema.String().Schema(),
+}).Schema()
+
+// evaluateBuiltinParseArn evaluates a call to the fn::parseArn builtin.
+func (e *evalContext) evaluateBuiltinParseArn(x *expr, repr *parseArnExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	arn, ok := e.evaluateTypedExpr(repr.arn, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(arn)
+	if v.unknown {
+		return v
+	}
+
+	components, err := parseArn(arn.repr.(string))
+	if err != nil {
+		e.errorf(repr.arn.repr.syntax(), "invalid ARN: %v", err)
+		v.unknown = true
+		return v
+	}
+
+	properties := make(map[string]*value, len(components))
+	for k, c := range components {
+		properties[k] = &value{def: x, schema: schema.String().Schema(), repr: c, secret: v.secret}
+	}
+	v.repr = properties
+	return v
+}
+
+// parseArn parses an ARN of the form arn:<partition>:<service>:<region>:<accountId>:<resource> into its components.
+// The region and account ID may be empty, as they are for e.g. S3 buckets and IAM roles. The resource may itself
+// contain colons and slashes.
+func parseArn(s string) (map[string]string, error) {
+	parts := strings.SplitN(s, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return nil, fmt.Errorf("%q is not of the form arn:partition:service:region:accountId:resource", s)
+	}
+	for i, name := range []string{"partition", "service", "", "", "resource"} {
+		if name != "" && parts[i+1] == "" {
+			return nil, fmt.Errorf("%q has an empty %v", s, name)
+		}
+	}
+	return map[string]string{
+		"partition": parts[1],
+		"service":   parts[2],
+		"region":    parts[3],
+		"accountId": parts[4],
+		"resource":  parts[5],
+	}, nil
+}
+
+// evaluateBuiltinParseDuration evaluates a call to the fn::parseDuration builtin. The duration is parsed using Go's
+// duration syntax and its length is returned in seconds unless milliseconds are requested.
+func (e *evalContext) evaluateBuiltinParseDuration(x *expr, repr *parseDurationExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	duration, durationOK := e.evaluateTypedExpr(repr.duration, schema.String().Schema())
+	unit, unitOK := &value{repr: "seconds"}, true
+	if repr.unit != nil {
+		unit, unitOK = e.evaluateTypedExpr(repr.unit, durationUnitSchema)
+	}
+	if !durationOK || !unitOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(duration, unit)
+	if v.unknown {
+		return v
+	}
+
+	d, err := time.ParseDuration(duration.repr.(string))
+	if err != nil {
+		e.errorf(repr.duration.repr.syntax(), "invalid duration: %v", err)
+		v.unknown = true
+		return v
+	}
+
+	n := d.Seconds()
+	if unit.repr.(string) == "milliseconds" {
+		n = float64(d) / float64(time.Millisecond)
+	}
+	v.repr = json.Number(strconv.FormatFloat(n, 'f', -1, 64))
+	return v
+}
+
+// timeLayouts maps the names of the layouts accepted by the fn::formatTime builtin to their Go time layouts.
+var timeLayouts = map[string]string{
+	"ANSIC":       time.ANSIC,
+	"DateOnly":    time.DateOnly,
+	"DateTime":    time.DateTime,
+	"Kitchen":     time.Kitchen,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC822":      time.RFC822,
+	"RFC822Z":     time.RFC822Z,
+	"RFC850":      time.RFC850,
+	"RubyDate":    time.RubyDate,
+	"TimeOnly":    time.TimeOnly,
+	"UnixDate":    time.UnixDate,
+}
+
+// timeLayoutSchema is the schema of the layout arguments to the fn::formatTime builtin.
+var timeLayoutSchema = func() *schema.Schema {
+	names := maps.Keys(timeLayouts)
+	sort.Strings(names)
+	return schema.String().Enum(names...).Schema()
+}()
+
+// evaluateBuiltinFormatTime evaluates a call to the fn::formatTime builtin. The time is parsed using the input layout
+// and formatted using the output layout. Layouts are named by the constants in Go's time package (e.g. "RFC3339" or
+// "DateTime"). Times without a zone offset are interpreted as UTC.
+func (e *evalContext) evaluateBuiltinFormatTime(x *expr, repr *formatTimeExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	t, timeOK := e.evaluateTypedExpr(repr.time, schema.String().Schema())
+	inputLayout, inputOK := e.evaluateTypedExpr(repr.inputLayout, timeLayoutSchema)
+	outputLayout, outputOK := e.evaluateTypedExpr(repr.outputLayout, timeLayoutSchema)
+	if !timeOK || !inputOK || !outputOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(t, inputLayout, outputLayout)
+	if v.unknown {
+		return v
+	}
+
+	parsed, err := time.Parse(timeLayouts[inputLayout.repr.(string)], t.repr.(string))
+	if err != nil {
+		e.errorf(repr.time.repr.syntax(), "invalid time for layout %v: %v", inputLayout.repr, err)
+		v.unknown = true
+		return v
+	}
+
+	v.repr = parsed.Format(timeLayouts[outputLayout.repr.(string)])
+	return v
+}
+
+// evaluateBuiltinParseSize evaluates a call to the fn::parseSize builtin.
+func (e *evalContext) evaluateBuiltinParseSize(x *expr, repr *parseSizeExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	size, ok := e.evaluateTypedExpr(repr.size, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(size)
+	if v.unknown {
+		return v
+	}
+
+	n, err := parseSize(size.repr.(string))
+	if err != nil {
+		e.errorf(repr.size.repr.syntax(), "invalid size: %v", err)
+		v.unknown = true
+		return v
+	}
+	v.repr = json.Number(n.String())
+	return v
+}
+
+// sizeUnits maps size suffixes to their multipliers. Suffixes ending in "i" (optionally followed by "B") are binary
+// units, and are powers of 1024. All other suffixes are decimal (SI) units, and are powers of 1000. Both "k" and "K"
+// denote kilobytes.
+var sizeUnits = func() map[string]int64 {
+	units := map[string]int64{"": 1, "B": 1}
+	decimal, binary := int64(1), int64(1)
+	for _, prefix := range []string{"K", "M", "G", "T", "P", "E"} {
+		decimal, binary = decimal*1000, binary*1024
+		units[prefix], units[prefix+"B"] = decimal, decimal
+		units[prefix+"i"], units[prefix+"iB"] = binary, binary
+	}
+	units["k"], units["kB"] = 1000, 1000
+	return units
+}()
+
+// parseSize parses a size of the form <number><unit> into a number of bytes. The number may be fractional, but the
+// resulting size must be a whole number of bytes.
+func parseSize(s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	end := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+	if end == -1 {
+		end = len(s)
+	}
+	number, unit := s[:end], strings.TrimSpace(s[end:])
+	if number == "" {
+		return nil, fmt.Errorf("%q does not begin with a number", s)
+	}
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", unit)
+	}
+
+	r, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, fmt.Errorf("invalid number %q", number)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(multiplier))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("%q is not a whole number of bytes", s)
+	}
+	return r.Num(), nil
+}
+
+// defaultRandomCharset is the charset used by fn::randomString if none is specified.
+const defaultRandomCharset = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// evaluateBuiltinRandomString evaluates a call to the fn::randomString builtin. The result is always secret. During
+// validation the result is unknown.
+func (e *evalContext) evaluateBuiltinRandomString(x *expr, repr *randomStringExpr) *value {
+	v := &value{def: x, schema: x.schema, secret: true}
+
+	length, lengthOK := e.evaluateTypedExpr(repr.length, schema.Number().Schema())
+	charset, charsetOK := &value{repr: defaultRandomCharset}, true
+	if repr.charset != nil {
+		charset, charsetOK = e.evaluateTypedExpr(repr.charset, schema.String().Schema())
+	}
+	if !lengthOK || !charsetOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(length, charset)
+	if v.unknown {
+		return v
+	}
+
+	n, err := length.repr.(json.Number).Int64()
+	if err != nil || n < 0 {
+		e.errorf(repr.length.repr.syntax(), "length must be a non-negative integer")
+		v.unknown = true
+		return v
+	}
+	if n > maxGeneratedElements {
+		e.errorf(repr.length.repr.syntax(), "length must not exceed %v", maxGeneratedElements)
+		v.unknown = true
+		return v
+	}
+
+	chars := []rune(charset.repr.(string))
+	if len(chars) == 0 {
+		e.errorf(repr.charset.repr.syntax(), "charset must not be empty")
+		v.unknown = true
+		return v
+	}
+
+	if e.random == nil {
+		e.errorf(repr.syntax(), "fn::randomString is not enabled in this context")
+		v.unknown = true
+		return v
+	}
+	if e.validating {
+		v.unknown = true
+		return v
+	}
+
+	s, err := randomString(e.random, int(n), chars)
+	if err != nil {
+		e.errorf(repr.syntax(), "generating random string: %v", err)
+		v.unknown = true
+		return v
+	}
+	v.repr = s
+	return v
+}
+
+// evaluateBuiltinRequire evaluates a call to the fn::require builtin. If the value is not null, it is returned
+// unchanged. Otherwise, the message is reported as an error at the value and the result is unknown. Secret messages
+// are redacted.
+func (e *evalContext) evaluateBuiltinRequire(x *expr, repr *requireExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	// We make a copy of the value here for the same reasons as evaluatePropertyAccess.
+	arg := newCopier().copy(e.evaluateExpr(repr.value))
+	message, ok := e.evaluateTypedExpr(repr.message, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+	if arg.unknown {
+		v.unknown, v.schema, v.secret = true, arg.schema, arg.secret
+		return v
+	}
+	if arg.repr != nil {
+		arg.def = x
+		return arg
+	}
+
+	v.unknown = true
+	switch {
+	case message.unknown:
+		// The message is not known, but the value is still missing.
+		e.errorf(repr.value.repr.syntax(), "a value is required")
+	case message.secret:
+		e.errorf(repr.value.repr.syntax(), "[secret]")
+	default:
+		e.errorf(repr.value.repr.syntax(), "%v", message.repr)
+	}
+	return v
+}
+
+// randomString returns a string of n characters chosen uniformly from chars using randomness read from r.
+func randomString(r io.Reader, n int, chars []rune) (string, error) {
+	result := make([]rune, 0, n)
+	for len(result) < n {
+		i, err := randomIndex(r, len(chars))
+		if err != nil {
+			return "", err
+		}
+		result = append(result, chars[i])
+	}
+	return string(result), nil
+}
+
+// randomIndex returns an integer chosen uniformly from [0, n) using randomness read from r. Rejection sampling is used
+// to avoid biasing the result towards zero.
+func randomIndex(r io.Reader, n int) (int, error) {
+	// limit is the largest multiple of n that fits in a uint32. Samples at or above it are discarded.
+	size := uint64(n)
+	limit := (1 << 32) / size * size
+
+	var buf [4]byte
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, err
+		}
+		sample := uint64(binary.BigEndian.Uint32(buf[:]))
+		if sample < limit {
+			return int(sample % size), nil
+		}
+	}
+}
+
+// evaluateBuiltinSample evaluates a call to the fn::sample builtin. The result is an element of the array chosen
+// uniformly at random, and is secret if the array is secret. During validation the result is unknown.
+func (e *evalContext) evaluateBuiltinSample(x *expr, repr *sampleExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	array, ok := e.evaluateTypedExpr(repr.array, schema.Array().Items(schema.Always()).Schema())
+	if !ok || array.unknown {
+		v.unknown, v.secret = true, array != nil && array.secret
+		return v
+	}
+
+	elements := array.repr.([]*value)
+	if len(elements) == 0 {
+		e.errorf(repr.array.repr.syntax(), "cannot sample from an empty array")
+		v.unknown = true
+		return v
+	}
+
+	if e.random == nil {
+		e.errorf(repr.syntax(), "fn::sample is not enabled in this context")
+		v.unknown = true
+		return v
+	}
+	if e.validating {
+		v.unknown, v.secret = true, array.secret
+		return v
+	}
+
+	i, err := randomIndex(e.random, len(elements))
+	if err != nil {
+		e.errorf(repr.syntax(), "sampling array: %v", err)
+		v.unknown = true
+		return v
+	}
+
+	// We make a copy of the element here for the same reasons as evaluatePropertyAccess.
+	element := newCopier().copy(elements[i])
+	element.def = x
+	if array.secret {
+		element = element.secretCopy()
+	}
+	return element
+}
+
+var (
+	hmacAlgorithmSchema = schema.String().Enum("sha256", "sha512").Schema()
+	hmacEncodingSchema  = schema.String().Enum("hex", "base64").Schema()
+)
+
+// evaluateBuiltinHMAC evaluates a call to the fn::hmac builtin. The result is encoded as hex unless base64 encoding
+// is requested, and is always secret.
+func (e *evalContext) evaluateBuiltinHMAC(x *expr, repr *hmacExpr) *value {
+	v := &value{def: x, schema: x.schema, secret: true}
+
+	algorithm, algorithmOK := e.evaluateTypedExpr(repr.algorithm, hmacAlgorithmSchema)
+	key, keyOK := e.evaluateTypedExpr(repr.key, schema.String().Schema())
+	message, messageOK := e.evaluateTypedExpr(repr.message, schema.String().Schema())
+	encoding, encodingOK := &value{repr: "hex"}, true
+	if repr.encoding != nil {
+		encoding, encodingOK = e.evaluateTypedExpr(repr.encoding, hmacEncodingSchema)
+	}
+	if !algorithmOK || !keyOK || !messageOK || !encodingOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(algorithm, key, message, encoding)
+	if v.unknown {
+		return v
+	}
+
+	hash := sha256.New
+	if algorithm.repr.(string) == "sha512" {
+		hash = sha512.New
+	}
+	mac := hmac.New(hash, []byte(key.repr.(string)))
+	mac.Write([]byte(message.repr.(string)))
+	sum := mac.Sum(nil)
+
+	if encoding.repr.(string) == "base64" {
+		v.repr = base64.StdEncoding.EncodeToString(sum)
+	} else {
+		v.repr = hex.EncodeToString(sum)
+	}
+	return v
+}
+
+// evaluateBuiltinImport evaluates a call to the fn::import builtin. The named environment is evaluated (or its
+// previously-evaluated value is reused) and the path is resolved against its value using the same rules as Query.
+func (e *evalContext) evaluateBuiltinImport(x *expr, repr *importExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	name, nameOK := e.evaluateTypedExpr(repr.environment, schema.String().Schema())
+	path, pathOK := e.evaluateTypedExpr(repr.path, schema.String().Schema())
+	if !nameOK || !pathOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(name, path)
+	if v.unknown {
+		return v
+	}
+
+	access, diags := ast.ParsePropertyPath(path.repr.(string))
+	if diags.HasErrors() {
+		for _, d := range diags {
+			e.errorf(repr.path.repr.syntax(), "invalid property path: %v", d.Summary)
+		}
+		v.unknown = true
+		return v
+	}
+
+	env := name.repr.(string)
+	imported, err := e.loadImport(env)
+	switch {
+	case errors.Is(err, errCyclicImport):
+		e.errorf(repr.environment.repr.syntax(), "cyclic import of %v", env)
+		v.unknown = true
+		return v
+	case err != nil:
+		e.errorf(repr.environment.repr.syntax(), "%s", err.Error())
+		v.unknown = true
+		return v
+	}
+
+	result, err := QueryAccess(imported.export(env), access)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		e.errorf(repr.path.repr.syntax(), "environment %v has no value at path %q", env, path.repr)
+		v.unknown = true
+		return v
+	case err != nil:
+		e.errorf(repr.syntax(), "%v", err)
+		v.unknown = true
+		return v
+	case result.Unknown:
+		v.unknown = true
+		return v
+	}
+	return unexport(result, x)
+}
+
+// evaluateBuiltinImportRaw evaluates a call to the fn::importRaw builtin. The path is resolved against the merged values
+// of the environment's imports, so the result is the value the environment inherits at that path regardless of any
+// value the environment itself defines there.
+func (e *evalContext) evaluateBuiltinImportRaw(x *expr, repr *importRawExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	path, ok := e.evaluateTypedExpr(repr.path, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(path)
+	if v.unknown {
+		return v
+	}
+
+	access, diags := ast.ParsePropertyPath(path.repr.(string))
+	if diags.HasErrors() {
+		for _, d := range diags {
+			e.errorf(repr.path.repr.syntax(), "invalid property path: %v", d.Summary)
+		}
+		v.unknown = true
+		return v
+	}
+
+	err := ErrNotFound
+	var result esc.Value
+	if e.base != nil {
+		result, err = QueryAccess(e.base.export(e.name), access)
+	}
+	switch {
+	case errors.Is(err, ErrNotFound):
+		e.errorf(repr.path.repr.syntax(), "imports have no value at path %q", path.repr)
+		v.unknown = true
+		return v
+	case err != nil:
+		e.errorf(repr.syntax(), "%v", err)
+		v.unknown = true
+		return v
+	case result.Unknown:
+		v.unknown = true
+		return v
+	}
+	return unexport(result, x)
+}
+
+// evaluateBuiltinJWTDecode evaluates a call to the fn::jwtDecode builtin. The token's payload is decoded into an object
+// of claims. The token's signature is _not_ verified.
+func (e *evalContext) evaluateBuiltinJWTDecode(x *expr, repr *jwtDecodeExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	token, ok := e.evaluateTypedExpr(repr.token, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(token)
+	if !v.unknown {
+		claims, err := decodeJWTClaims(token.repr.(string))
+		if err != nil {
+			e.errorf(repr.token.repr.syntax(), "decoding JWT: %v", err)
+			v.unknown = true
+			return v
+		}
+
+		ev, err := esc.FromJSON(claims, v.secret)
+		if err != nil {
+			e.errorf(repr.syntax(), "internal error: decoding JWT claims: %v", err)
+			v.unknown = true
+			return v
+		}
+
+		return unexport(ev, x)
+	}
+	return v
+}
+
+// decodeJWTClaims decodes the claims from the payload of a JWT in compact serialization form.
+func decodeJWTClaims(token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("expected a token with 3 parts, got %v", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("decoding payload: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var claims map[string]any
+	if err := dec.Decode(&claims); err != nil {
+		return nil, fmt.Errorf("decoding claims: %w", err)
+	}
+	if claims == nil {
+		return nil, errors.New("claims must be an object")
+	}
+	return claims, nil
+}
+
+// evaluateBuiltinCapitalize evaluates a call to the fn::capitalize builtin. The first Unicode code point of the string
+// is mapped to title case using the Unicode character tables. The rest of the string is unchanged.
+func (e *evalContext) evaluateBuiltinCapitalize(x *expr, repr *capitalizeExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if !v.unknown {
+		s := str.repr.(string)
+		if r, size := utf8.DecodeRuneInString(s); r != utf8.RuneError {
+			s = string(unicode.ToTitle(r)) + s[size:]
+		}
+		v.repr = s
+	}
+	return v
+}
+
+// evaluateBuiltinTitle evaluates a call to the fn::title builtin. The first letter of each word is mapped to title case
+// and the remaining letters are mapped to lower case. Casing is language-insensitive: language-specific rules (e.g.
+// the Turkish dotted and dotless i, or Dutch "ij") are not applied.
+func (e *evalContext) evaluateBuiltinTitle(x *expr, repr *titleExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if !v.unknown {
+		v.repr = cases.Title(language.Und).String(str.repr.(string))
+	}
+	return v
+}
+
+// evaluateBuiltinChangeCase evaluates a call to the fn::camelCase, fn::snakeCase, or fn::kebabCase builtins. The
+// string is split into words as described by splitWords. fn::snakeCase and fn::kebabCase join the lower-cased words
+// with underscores and hyphens, respectively. fn::camelCase lower-cases the first word and capitalizes the rest, so
+// acronyms are only capitalized at their first letter (e.g. "user_ID" becomes "userId").
+func (e *evalContext) evaluateBuiltinChangeCase(x *expr, repr *changeCaseExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if v.unknown {
+		return v
+	}
+
+	words := splitWords(str.repr.(string))
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if repr.style == ast.CaseCamel && i != 0 {
+			r, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(r)) + word[size:]
+		}
+		words[i] = word
+	}
+
+	switch repr.style {
+	case ast.CaseCamel:
+		v.repr = strings.Join(words, "")
+	case ast.CaseSnake:
+		v.repr = strings.Join(words, "_")
+	case ast.CaseKebab:
+		v.repr = strings.Join(words, "-")
+	}
+	return v
+}
+
+// splitWords splits an identifier into words. Any character that is neither a letter nor a digit is a delimiter and is
+// discarded. Within a run of letters and digits, a new word begins at an upper-case letter that follows a lower-case
+// letter or a digit (e.g. "fooBar" and "ec2Instance"), or at the last upper-case letter of an acronym if that letter is
+// followed by a lower-case letter (e.g. "HTTPServer"). Digits never begin a word: they belong to the word that
+// precedes them, if any (e.g. "version2beta" is a single word).
+func splitWords(s string) []string {
+	runes := []rune(s)
+
+	var words []string
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start != -1 {
+				words, start = append(words, string(runes[start:i])), -1
+			}
+			continue
+		}
+
+		if start == -1 {
+			start = i
+			continue
+		}
+
+		if unicode.IsUpper(r) {
+			prev := runes[i-1]
+			acronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || acronymEnd {
+				words, start = append(words, string(runes[start:i])), i
+			}
+		}
+	}
+	if start != -1 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// evaluateBuiltinFromBase64 evaluates a call from the fn::fromBase64 or fn::fromBase64URL builtins.
+func (e *evalContext) evaluateBuiltinFromBase64(x *expr, repr *fromBase64Expr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if !v.unknown {
+		var b []byte
+		var err error
+		if repr.node.URLSafe {
+			b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(str.repr.(string), "="))
+		} else {
+			b, err = base64.StdEncoding.DecodeString(str.repr.(string))
+		}
+		if err != nil {
+			e.errorf(repr.syntax(), "decoding base64 string: %v", err)
+			v.unknown = true
+			return v
+		}
+		v.repr = string(b)
+	}
+	return v
+}
+
+// evaluateBuiltinFromHex evaluates a call from the fn::fromHex builtin. The string must contain an even number of
+// hexadecimal digits.
+func (e *evalContext) evaluateBuiltinFromHex(x *expr, repr *fromHexExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if !v.unknown {
+		b, err := hex.DecodeString(str.repr.(string))
+		if err != nil {
+			e.errorf(repr.syntax(), "decoding hex string: %v", err)
+			v.unknown = true
+			return v
+		}
+		v.repr = string(b)
+	}
+	return v
+}
+
+// evaluateBuiltinFromJSON evaluates a call from the fn::fromJSON builtin.
+func (e *evalContext) evaluateBuiltinFromJSON(x *expr, repr *fromJSONExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.string, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if !v.unknown {
+		dec := json.NewDecoder(strings.NewReader(str.repr.(string)))
+		dec.UseNumber()
+
+		var jv any
+		if err := dec.Decode(&jv); err != nil {
+			e.errorf(repr.syntax(), "decoding JSON string: %v", err)
+			v.unknown = true
+			return v
+		}
+
+		ev, err := esc.FromJSON(jv, v.secret)
+		if err != nil {
+			e.errorf(repr.syntax(), "internal error: decoding JSON value: %v", err)
+			v.unknown = true
+			return v
+		}
+
+		return unexport(ev, x)
+	}
+	return v
+}
+
+// evaluateBuiltinToBase64 evaluates a call to the fn::toBase64 or fn::toBase64URL builtins.
+func (e *evalContext) evaluateBuiltinToBase64(x *expr, repr *toBase64Expr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.value, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if !v.unknown {
+		if repr.node.URLSafe {
+			v.repr = base64.RawURLEncoding.EncodeToString([]byte(str.repr.(string)))
+		} else {
+			v.repr = base64.StdEncoding.EncodeToString([]byte(str.repr.(string)))
+		}
+	}
+	return v
+}
+
+// evaluateBuiltinToHex evaluates a call to the fn::toHex builtin. The result uses lowercase hexadecimal digits.
+func (e *evalContext) evaluateBuiltinToHex(x *expr, repr *toHexExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	str, ok := e.evaluateTypedExpr(repr.value, schema.String().Schema())
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(str)
+	if !v.unknown {
+		v.repr = hex.EncodeToString([]byte(str.repr.(string)))
+	}
+	return v
+}
+
+// evaluateBuiltinToJSON evaluates a call to the fn::toJSON builtin.
+func (e *evalContext) evaluateBuiltinToJSON(x *expr, repr *toJSONExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	value := e.evaluateExpr(repr.value)
 
 	v.combine(value)
 	if !v.unknown {
@@ -1043,6 +4229,72 @@ func (e *evalContext) evaluateBuiltinToJSON(x *expr, repr *toJSONExpr) *value {
 	return v
 }
 
+// evaluateBuiltinToArray evaluates a call to the fn::toArray builtin. Lists are returned unchanged, null is converted
+// to an empty list, and any other value is wrapped in a single-element list.
+func (e *evalContext) evaluateBuiltinToArray(x *expr, repr *toArrayExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	// We make a copy of the argument here for the same reasons as evaluatePropertyAccess.
+	arg := newCopier().copy(e.evaluateExpr(repr.value))
+	if arg.unknown {
+		v.unknown, v.secret = true, arg.secret
+		return v
+	}
+
+	switch arg.repr.(type) {
+	case []*value:
+		arg.def = x
+		return arg
+	case nil:
+		v.repr, v.schema, v.secret = []*value{}, schema.Tuple().Schema(), arg.secret
+	default:
+		v.repr, v.schema = []*value{arg}, schema.Tuple(arg.schema).Schema()
+	}
+	return v
+}
+
+var typeNameSchema = schema.String().Enum("null", "boolean", "number", "string", "array", "object").Schema()
+
+// evaluateBuiltinTypeOf evaluates a call to the fn::typeOf builtin. The result is unknown if the argument is unknown.
+func (e *evalContext) evaluateBuiltinTypeOf(x *expr, repr *typeOfExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	value := e.evaluateExpr(repr.value)
+
+	v.unknown, v.secret = value.unknown, value.secret
+	if !v.unknown {
+		v.repr = value.typeName()
+	}
+	return v
+}
+
+// evaluateBuiltinIsEmpty evaluates a call to the fn::isEmpty builtin. Null, empty strings, empty arrays, and empty
+// objects are empty. Numbers and booleans are never empty. The result is unknown if the argument is unknown.
+func (e *evalContext) evaluateBuiltinIsEmpty(x *expr, repr *isEmptyExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	arg := e.evaluateExpr(repr.value)
+
+	v.unknown, v.secret = arg.unknown, arg.secret
+	if v.unknown {
+		return v
+	}
+
+	switch r := arg.repr.(type) {
+	case nil:
+		v.repr = true
+	case string:
+		v.repr = r == ""
+	case []*value:
+		v.repr = len(r) == 0
+	case map[string]*value:
+		v.repr = len(arg.keys()) == 0
+	default:
+		v.repr = false
+	}
+	return v
+}
+
 // evaluateBuiltinToString evaluates a call to the fn::toString builtin.
 func (e *evalContext) evaluateBuiltinToString(x *expr, repr *toStringExpr) *value {
 	v := &value{def: x, schema: x.schema}
@@ -1056,3 +4308,206 @@ func (e *evalContext) evaluateBuiltinToString(x *expr, repr *toStringExpr) *valu
 	}
 	return v
 }
+
+var urlModeSchema = schema.String().Enum("query", "path").Schema()
+
+// evaluateBuiltinURL evaluates a call to the fn::urlEncode or fn::urlDecode builtins. In "query" mode (the default),
+// the value is escaped as a URL query component, with spaces encoded as '+'. In "path" mode, the value is escaped as a
+// URL path segment, with spaces encoded as "%20" and '/' escaped.
+func (e *evalContext) evaluateBuiltinURL(x *expr, valueX, modeX *expr, decode bool) *value {
+	v := &value{def: x, schema: x.schema}
+
+	input, inputOK := e.evaluateTypedExpr(valueX, schema.String().Schema())
+	mode, modeOK := &value{repr: "query"}, true
+	if modeX != nil {
+		mode, modeOK = e.evaluateTypedExpr(modeX, urlModeSchema)
+	}
+	if !inputOK || !modeOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(input, mode)
+	if v.unknown {
+		return v
+	}
+
+	s, path := input.repr.(string), mode.repr.(string) == "path"
+	switch {
+	case !decode && path:
+		v.repr = url.PathEscape(s)
+	case !decode:
+		v.repr = url.QueryEscape(s)
+	default:
+		var decoded string
+		var err error
+		if path {
+			decoded, err = url.PathUnescape(s)
+		} else {
+			decoded, err = url.QueryUnescape(s)
+		}
+		if err != nil {
+			e.errorf(valueX.repr.syntax(), "decoding URL-encoded string: %v", err)
+			v.unknown = true
+			return v
+		}
+		v.repr = decoded
+	}
+	return v
+}
+
+var queryValuesSchema = schema.Object().
+	AdditionalProperties(schema.AnyOf(schema.String(), schema.Array().Items(schema.String()))).
+	Schema()
+
+// evaluateBuiltinEncodeQuery evaluates a call to the fn::encodeQuery builtin. Each property of the argument is encoded
+// as a query parameter. Properties whose values are lists of strings are encoded as one parameter per element, in
+// order. Parameters are sorted by key so that the result is deterministic.
+func (e *evalContext) evaluateBuiltinEncodeQuery(x *expr, repr *encodeQueryExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	values, ok := e.evaluateTypedExpr(repr.values, queryValuesSchema)
+	if !ok {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(values)
+	if v.unknown {
+		return v
+	}
+
+	query := url.Values{}
+	for _, k := range values.keys() {
+		switch pv := values.property(repr.values.repr.syntax(), k).repr.(type) {
+		case string:
+			query.Add(k, pv)
+		case []*value:
+			for _, e := range pv {
+				query.Add(k, e.repr.(string))
+			}
+		}
+	}
+
+	v.repr = query.Encode()
+	return v
+}
+
+// evaluateBuiltinURLJoin evaluates a call to the fn::urlJoin builtin. The base must be an absolute URL. Leading and
+// trailing slashes are trimmed from each segment so that exactly one slash separates each part of the resulting path.
+// Empty segments are ignored. The result ends in a slash only if the last non-empty segment does. Segments are
+// inserted as-is, and must already be escaped if necessary (e.g. using fn::urlEncode).
+func (e *evalContext) evaluateBuiltinURLJoin(x *expr, repr *urlJoinExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	base, baseOK := e.evaluateTypedExpr(repr.base, schema.String().Schema())
+	segments, segmentsOK := e.evaluateTypedExpr(repr.segments, schema.Array().Items(schema.String()).Schema())
+	if !baseOK || !segmentsOK {
+		v.unknown = true
+		return v
+	}
+
+	v.combine(base, segments)
+	if v.unknown {
+		return v
+	}
+
+	u, err := url.Parse(base.repr.(string))
+	if err == nil && (u.Scheme == "" || u.Host == "") {
+		err = errors.New("URL must be absolute")
+	}
+	if err != nil {
+		e.errorf(repr.base.repr.syntax(), "invalid base URL: %v", err)
+		v.unknown = true
+		return v
+	}
+
+	p, trailingSlash := strings.TrimRight(u.EscapedPath(), "/"), strings.HasSuffix(u.EscapedPath(), "/")
+	for _, segment := range segments.repr.([]*value) {
+		s := strings.Trim(segment.repr.(string), "/")
+		if s == "" {
+			continue
+		}
+		p, trailingSlash = p+"/"+s, strings.HasSuffix(segment.repr.(string), "/")
+	}
+	if trailingSlash {
+		p += "/"
+	}
+
+	path, err := url.PathUnescape(p)
+	if err != nil {
+		e.errorf(repr.segments.repr.syntax(), "invalid path segment: %v", err)
+		v.unknown = true
+		return v
+	}
+	u.Path, u.RawPath = path, p
+
+	v.repr = u.String()
+	return v
+}
+
+// zipSchema is the schema of the arrays argument to and the result of a call to the fn::zip builtin.
+var zipSchema = schema.Array().Items(schema.Array()).Schema()
+
+// evaluateBuiltinZip evaluates a call to the fn::zip builtin. The i'th element of the result is a tuple of the i'th
+// elements of the input arrays. The result is as long as the shortest input array, or, if pad is true, as long as the
+// longest input array, in which case missing elements are null.
+func (e *evalContext) evaluateBuiltinZip(x *expr, repr *zipExpr) *value {
+	v := &value{def: x, schema: x.schema}
+
+	arrays, arraysOK := e.evaluateTypedExpr(repr.arrays, zipSchema)
+	pad, padOK := &value{repr: false}, true
+	if repr.pad != nil {
+		pad, padOK = e.evaluateTypedExpr(repr.pad, schema.Boolean().Schema())
+	}
+	if !arraysOK || !padOK {
+		v.unknown = true
+		return v
+	}
+
+	// The elements of the input arrays may be unknown or secret without affecting the shape of the result, so only
+	// the arrays themselves contribute to the result's unknown-ness and secret-ness.
+	v.combine(pad)
+	v.unknown, v.secret = v.unknown || arrays.unknown, v.secret || arrays.secret
+	if v.unknown {
+		return v
+	}
+	inputs := arrays.repr.([]*value)
+	for _, input := range inputs {
+		v.unknown, v.secret = v.unknown || input.unknown, v.secret || input.secret
+	}
+	if v.unknown {
+		return v
+	}
+
+	length := 0
+	for i, input := range inputs {
+		n := len(input.repr.([]*value))
+		switch {
+		case i == 0:
+			length = n
+		case pad.repr.(bool) && n > length, !pad.repr.(bool) && n < length:
+			length = n
+		}
+	}
+
+	rows, rowSchemas := make([]*value, length), make([]schema.Builder, length)
+	for i := range rows {
+		row, elementSchemas := make([]*value, len(inputs)), make([]schema.Builder, len(inputs))
+		for j, input := range inputs {
+			elements := input.repr.([]*value)
+			if i < len(elements) {
+				row[j] = newCopier().copy(elements[i])
+				row[j].def = x
+			} else {
+				row[j] = &value{def: x, schema: schema.Null().Schema()}
+			}
+			elementSchemas[j] = row[j].schema
+		}
+		rows[i] = &value{def: x, repr: row, schema: schema.Tuple(elementSchemas...).Schema()}
+		rowSchemas[i] = rows[i].schema
+	}
+
+	v.repr, v.schema = rows, schema.Tuple(rowSchemas...).Schema()
+	return v
+}
@@ -0,0 +1,222 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pulumi/esc/schema"
+)
+
+// coerceStrings returns a copy of v in which each string that accept expects to be a number or boolean is
+// replaced with the value it represents. Strings that do not represent a value of the expected type are left as-is so
+// that validation reports the usual type error. v itself is never modified, as it may be shared with other
+// expressions.
+//
+// Numbers must be written using JSON syntax, and booleans must be exactly "true" or "false".
+func coerceStrings(v *value, accept *schema.Schema) *value {
+	for accept != nil && accept.Type == "" && accept.GetRef() != nil {
+		accept = accept.GetRef()
+	}
+	if v == nil || accept == nil || v.unknown {
+		return v
+	}
+
+	switch repr := v.repr.(type) {
+	case string:
+		coerced, typ, ok := coerceString(repr, accept.Type)
+		if !ok {
+			return v
+		}
+		return &value{def: v.def, schema: typ, secret: v.secret, repr: coerced}
+	case []*value:
+		if accept.Type != "array" {
+			return v
+		}
+
+		var elements []*value
+		for i, e := range repr {
+			items := accept.Items
+			if i < len(accept.PrefixItems) {
+				items = accept.PrefixItems[i]
+			}
+			if c := coerceStrings(e, items); c != e {
+				if elements == nil {
+					elements = make([]*value, len(repr))
+					copy(elements, repr)
+				}
+				elements[i] = c
+			}
+		}
+		if elements == nil {
+			return v
+		}
+		return &value{def: v.def, base: v.base, schema: v.schema, secret: v.secret, repr: elements}
+	case map[string]*value:
+		if accept.Type != "object" {
+			return v
+		}
+
+		keys := v.keys()
+
+		var properties map[string]*value
+		for _, k := range keys {
+			p, ok := accept.Properties[k]
+			if !ok {
+				p = accept.AdditionalProperties
+			}
+			pv := v.property(nil, k)
+			if c := coerceStrings(pv, p); c != pv {
+				if properties == nil {
+					properties = make(map[string]*value, len(keys))
+					for _, k := range keys {
+						properties[k] = v.property(nil, k)
+					}
+				}
+				properties[k] = c
+			}
+		}
+		if properties == nil {
+			return v
+		}
+		return &value{def: v.def, base: v.base, schema: v.schema, secret: v.secret, repr: properties}
+	default:
+		return v
+	}
+}
+
+// coerceString attempts to convert s to a value of the given schema type. If the conversion succeeds, coerceString
+// returns the converted value and its schema.
+func coerceString(s, typ string) (any, *schema.Schema, bool) {
+	switch typ {
+	case "boolean":
+		switch s {
+		case "true":
+			return true, schema.Boolean().Schema(), true
+		case "false":
+			return false, schema.Boolean().Schema(), true
+		}
+	case "number":
+		if isJSONNumber(s) {
+			return json.Number(s), schema.Number().Schema(), true
+		}
+	}
+	return nil, nil, false
+}
+
+// isJSONNumber returns true if s is a number in JSON syntax.
+func isJSONNumber(s string) bool {
+	if s == "" || s != strings.TrimSpace(s) || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) {
+		return false
+	}
+	var n json.Number
+	return json.Unmarshal([]byte(s), &n) == nil
+}
+
+// normalizeEnums returns a copy of v in which each string that accept matches case-insensitively against an enum is
+// replaced with the casing of the enum member it matches. Only schemas with EnumCaseInsensitive set are considered.
+// v itself is never modified, as it may be shared with other expressions.
+func normalizeEnums(v *value, accept *schema.Schema) *value {
+	for accept != nil && accept.Type == "" && accept.GetRef() != nil {
+		accept = accept.GetRef()
+	}
+	if v == nil || accept == nil || v.unknown {
+		return v
+	}
+
+	switch repr := v.repr.(type) {
+	case string:
+		if !accept.EnumCaseInsensitive {
+			return v
+		}
+		canonical, ok := foldEnum(repr, accept.Enum)
+		if !ok || canonical == repr {
+			return v
+		}
+		return &value{def: v.def, schema: schema.String().Const(canonical).Schema(), secret: v.secret, repr: canonical}
+	case []*value:
+		if accept.Type != "array" {
+			return v
+		}
+
+		var elements []*value
+		for i, e := range repr {
+			items := accept.Items
+			if i < len(accept.PrefixItems) {
+				items = accept.PrefixItems[i]
+			}
+			if c := normalizeEnums(e, items); c != e {
+				if elements == nil {
+					elements = make([]*value, len(repr))
+					copy(elements, repr)
+				}
+				elements[i] = c
+			}
+		}
+		if elements == nil {
+			return v
+		}
+		return &value{def: v.def, base: v.base, schema: v.schema, secret: v.secret, repr: elements}
+	case map[string]*value:
+		if accept.Type != "object" {
+			return v
+		}
+
+		keys := v.keys()
+
+		var properties map[string]*value
+		for _, k := range keys {
+			p, ok := accept.Properties[k]
+			if !ok {
+				p = accept.AdditionalProperties
+			}
+			pv := v.property(nil, k)
+			if c := normalizeEnums(pv, p); c != pv {
+				if properties == nil {
+					properties = make(map[string]*value, len(keys))
+					for _, k := range keys {
+						properties[k] = v.property(nil, k)
+					}
+				}
+				properties[k] = c
+			}
+		}
+		if properties == nil {
+			return v
+		}
+		return &value{def: v.def, base: v.base, schema: v.schema, secret: v.secret, repr: properties}
+	default:
+		return v
+	}
+}
+
+// foldEnum returns the member of enum that is equal to s under Unicode case-folding, if any. An exact match is
+// preferred over a case-insensitive match.
+func foldEnum(s string, enum []any) (string, bool) {
+	folded, found := "", false
+	for _, c := range enum {
+		c, ok := c.(string)
+		switch {
+		case !ok:
+			continue
+		case c == s:
+			return c, true
+		case !found && strings.EqualFold(c, s):
+			folded, found = c, true
+		}
+	}
+	return folded, found
+}
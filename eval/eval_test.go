@@ -20,20 +20,26 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/pgavlin/fx"
 	"github.com/pulumi/esc"
+	"github.com/pulumi/esc/ast"
 	"github.com/pulumi/esc/schema"
 	"github.com/pulumi/esc/syntax"
+	"github.com/pulumi/esc/syntax/encoding"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/maps"
 )
 
 func accept() bool {
@@ -81,11 +87,15 @@ func (testSchemaProvider) Schema() (*schema.Schema, *schema.Schema) {
 			// Complex cases
 			"const-array":  &schema.Schema{Type: "array", Const: []any{"hello", json.Number("42")}},
 			"const-object": &schema.Schema{Type: "object", Const: map[string]any{"hello": "world"}},
-			"enum":         schema.String().Enum("foo", "bar"),
-			"never":        schema.Never(),
-			"always":       schema.Always(),
-			"double":       schema.Tuple(schema.String(), schema.Number()),
-			"triple":       schema.Tuple(schema.String(), schema.Number(), schema.Boolean()),
+			"const-nested": &schema.Schema{Type: "object", Const: map[string]any{
+				"tags":    map[string]any{"environment": "prod", "team": "platform"},
+				"regions": []any{"us-west-2", "us-east-1"},
+			}},
+			"enum":   schema.String().Enum("foo", "bar"),
+			"never":  schema.Never(),
+			"always": schema.Always(),
+			"double": schema.Tuple(schema.String(), schema.Number()),
+			"triple": schema.Tuple(schema.String(), schema.Number(), schema.Boolean()),
 			"dependentReq": schema.Object().
 				Properties(schema.BuilderMap{
 					"foo": schema.String(),
@@ -99,6 +109,9 @@ func (testSchemaProvider) Schema() (*schema.Schema, *schema.Schema) {
 			"minLength":        schema.String().MinLength(1),
 			"maxLength":        schema.String().MaxLength(1),
 			"pattern":          schema.String().Pattern(`^foo[0-9]+$`),
+			"base64":           schema.String().ContentEncoding("base64"),
+			"json":             schema.String().ContentMediaType("application/json"),
+			"base64JSON":       schema.String().ContentEncoding("base64").ContentMediaType("application/json"),
 			"minItems":         schema.Array().MinItems(3),
 			"maxItems":         schema.Array().MaxItems(2),
 			"minProperties":    schema.Object().MinProperties(1),
@@ -113,6 +126,29 @@ func (testSchemaProvider) Open(ctx context.Context, inputs map[string]esc.Value,
 	return esc.NewValue(inputs), nil
 }
 
+type testDefaultsProvider struct{}
+
+func (testDefaultsProvider) Schema() (*schema.Schema, *schema.Schema) {
+	inputs := schema.Object().
+		Properties(schema.BuilderMap{
+			"name":     schema.String(),
+			"greeting": schema.String().Default("hello"),
+			"options": schema.Object().
+				Properties(schema.BuilderMap{
+					"retries": schema.Number().Default("3"),
+					"verbose": schema.Boolean().Default(false),
+				}).
+				Default(map[string]any{"retries": json.Number("3"), "verbose": false}),
+		}).
+		Required("name").
+		Schema()
+	return inputs, schema.Always()
+}
+
+func (testDefaultsProvider) Open(ctx context.Context, inputs map[string]esc.Value, context esc.EnvExecContext) (esc.Value, error) {
+	return esc.NewValue(inputs), nil
+}
+
 type benchProvider struct {
 	delay time.Duration
 }
@@ -146,6 +182,8 @@ func (tp testProviders) LoadProvider(ctx context.Context, name string) (esc.Prov
 		return errorProvider{}, nil
 	case "schema":
 		return testSchemaProvider{}, nil
+	case "defaults":
+		return testDefaultsProvider{}, nil
 	case "test":
 		return testProvider{}, nil
 	case "bench":
@@ -243,8 +281,11 @@ func normalize[T any](t *testing.T, v T) T {
 
 func TestEval(t *testing.T) {
 	type testOverrides struct {
-		ShowSecrets     bool   `json:"showSecrets,omitempty"`
-		RootEnvironment string `json:"rootEnvironment,omitempty"`
+		ShowSecrets     bool              `json:"showSecrets,omitempty"`
+		RootEnvironment string            `json:"rootEnvironment,omitempty"`
+		RandomSeed      *int64            `json:"randomSeed,omitempty"`
+		CoerceStrings   bool              `json:"coerceStrings,omitempty"`
+		Parameters      map[string]string `json:"parameters,omitempty"`
 	}
 
 	type expectedData struct {
@@ -296,17 +337,32 @@ func TestEval(t *testing.T) {
 			}
 			showSecrets := overrides.ShowSecrets
 
+			// Each evaluation gets a fresh seeded source so that check and eval see the same random values.
+			evalOptions := func() EvalOptions {
+				opts := EvalOptions{CoerceStrings: overrides.CoerceStrings}
+				if overrides.Parameters != nil {
+					opts.Parameters = make(map[string]esc.Value, len(overrides.Parameters))
+					for k, v := range overrides.Parameters {
+						opts.Parameters[k] = esc.NewValue(v)
+					}
+				}
+				if overrides.RandomSeed != nil {
+					opts.Random = rand.New(rand.NewSource(*overrides.RandomSeed))
+				}
+				return opts
+			}
+
 			if accept() {
 				env, loadDiags, err := LoadYAMLBytes(environmentName, envBytes)
 				require.NoError(t, err)
 				sortEnvironmentDiagnostics(loadDiags)
 
 				check, checkDiags := CheckEnvironment(context.Background(), environmentName, env, rot128{}, testProviders{},
-					&testEnvironments{basePath}, execContext, showSecrets)
+					&testEnvironments{basePath}, execContext, showSecrets, evalOptions())
 				sortEnvironmentDiagnostics(checkDiags)
 
 				actual, evalDiags := EvalEnvironment(context.Background(), environmentName, env, rot128{}, testProviders{},
-					&testEnvironments{basePath}, execContext)
+					&testEnvironments{basePath}, execContext, evalOptions())
 				sortEnvironmentDiagnostics(evalDiags)
 
 				var checkJSON any
@@ -355,12 +411,12 @@ func TestEval(t *testing.T) {
 			require.Equal(t, expected.LoadDiags, diags)
 
 			check, diags := CheckEnvironment(context.Background(), environmentName, env, rot128{}, testProviders{},
-				&testEnvironments{basePath}, execContext, showSecrets)
+				&testEnvironments{basePath}, execContext, showSecrets, evalOptions())
 			sortEnvironmentDiagnostics(diags)
 			require.Equal(t, expected.CheckDiags, diags)
 
 			actual, diags := EvalEnvironment(context.Background(), environmentName, env, rot128{}, testProviders{},
-				&testEnvironments{basePath}, execContext)
+				&testEnvironments{basePath}, execContext, evalOptions())
 			sortEnvironmentDiagnostics(diags)
 			require.Equal(t, expected.EvalDiags, diags)
 
@@ -442,3 +498,1421 @@ func BenchmarkEvalEnvLoad(b *testing.B) {
 func BenchmarkEvalAll(b *testing.B) {
 	benchmarkEval(b, 10*time.Millisecond, 10*time.Millisecond)
 }
+
+// BenchmarkValidateLargeArray validates a large array against a schema whose items schema is shared by all elements.
+// The items schema is compiled once rather than once per element. The "fresh" variant uses a distinct items schema for
+// each element for comparison.
+func BenchmarkValidateLargeArray(b *testing.B) {
+	const n = 10000
+
+	elements := make([]*value, n)
+	for i := range elements {
+		elements[i] = &value{repr: fmt.Sprintf("item%d", i), schema: schema.String().Schema()}
+	}
+	v := &value{repr: elements, schema: schema.Array().Items(schema.String()).Schema()}
+	loc := validationLoc{x: &expr{repr: &literalExpr{node: ast.String("")}}}
+
+	b.Run("shared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			accept := schema.Array().Items(schema.String().Pattern("^item[0-9]+$")).Schema()
+
+			var validator validator
+			if !validator.validateElement(v, accept, loc) {
+				b.Fatal(validator.diags)
+			}
+		}
+	})
+
+	b.Run("fresh", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			items := make([]schema.Builder, n)
+			for j := range items {
+				items[j] = schema.String().Pattern("^item[0-9]+$")
+			}
+			accept := schema.Tuple(items...).Schema()
+
+			var validator validator
+			if !validator.validateElement(v, accept, loc) {
+				b.Fatal(validator.diags)
+			}
+		}
+	})
+}
+
+// BenchmarkValidateSharedImport validates a large subtree of an imported environment against the same schema in
+// several places. The subtree is validated once; subsequent validations are satisfied by the validation cache.
+func BenchmarkValidateSharedImport(b *testing.B) {
+	const servers, uses = 1000, 20
+
+	var shared strings.Builder
+	shared.WriteString("values:\n  servers:\n")
+	for i := 0; i < servers; i++ {
+		fmt.Fprintf(&shared, "    - name: server%d\n      port: %d\n", i, 8000+i)
+	}
+
+	var def strings.Builder
+	def.WriteString("imports:\n  - shared\nvalues:\n")
+	for i := 0; i < uses; i++ {
+		fmt.Fprintf(&def, "  deploy%d:\n    fn::open::deploy:\n      servers: ${servers}\n", i)
+	}
+
+	registry := NewProviderRegistry()
+	registry.Register("deploy",
+		schema.Record(schema.BuilderMap{
+			"servers": schema.Array().Items(schema.Record(schema.BuilderMap{
+				"name": schema.String().Pattern("^server[0-9]+$"),
+				"port": schema.Number().Minimum("1").Maximum("65535"),
+			})),
+		}).Schema(),
+		schema.String().Schema(),
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.NewValue("deployed"), nil
+		})
+
+	envs := &benchEnvironments{defs: map[string][]byte{"shared": []byte(shared.String())}}
+
+	env, diags, err := LoadYAMLBytes("bench", []byte(def.String()))
+	require.NoError(b, err)
+	require.Empty(b, diags)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, diags := EvalEnvironment(context.Background(), "bench", env, rot128{}, registry, envs, &esc.ExecContext{})
+		if len(diags) != 0 {
+			b.Fatal(diags)
+		}
+	}
+}
+
+func TestValidateScalarItems(t *testing.T) {
+	element := func(v any, unknown bool) *value {
+		x := &expr{repr: &literalExpr{node: ast.String(fmt.Sprint(v))}}
+		return &value{def: x, repr: v, unknown: unknown, schema: schema.Always()}
+	}
+	elements := []*value{
+		element(json.Number("1"), false),
+		element(json.Number("-1"), false),
+		element("two", false),
+		element(nil, false),
+		element(nil, true),
+	}
+	loc := validationLoc{x: &expr{repr: &literalExpr{node: ast.String("")}}}
+
+	accept := schema.Array().Items(schema.Number().Minimum("0")).Schema()
+	require.NoError(t, accept.Compile())
+	require.True(t, isScalarSchema(accept.Items))
+
+	// The fast path must report the same diagnostics as the general path.
+	var fast, general validator
+	assert.False(t, fast.validateArray(elements, accept, loc))
+	assert.False(t, general.validateItems(elements, accept, loc))
+	assert.Equal(t, general.diags, fast.diags)
+	assert.Len(t, fast.diags, 3)
+}
+
+func TestValidatePropertyCounts(t *testing.T) {
+	object := func(keys ...string) *value {
+		x := &expr{repr: &literalExpr{node: ast.String("")}}
+		properties := make(map[string]*value, len(keys))
+		for _, k := range keys {
+			properties[k] = &value{def: x, repr: k, schema: schema.String().Schema()}
+		}
+		return &value{def: x, repr: properties, schema: schema.Always()}
+	}
+
+	cases := []struct {
+		accept   *schema.Schema
+		value    *value
+		expected string
+	}{
+		{
+			accept:   schema.Object().MaxProperties(3).Schema(),
+			value:    object("a", "b", "c", "d", "e"),
+			expected: "expected an object with at most 3 properties, but it has 5",
+		},
+		{
+			accept:   schema.Object().MinProperties(2).Schema(),
+			value:    object("a"),
+			expected: "expected an object with at least 2 properties, but it has 1",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expected, func(t *testing.T) {
+			var validator validator
+			assert.False(t, validator.validateElement(c.value, c.accept, validationLoc{x: c.value.def}))
+			require.Len(t, validator.diags, 1)
+			assert.Equal(t, c.expected, validator.diags[0].Summary)
+		})
+	}
+}
+
+func TestValidateRequiredNonNull(t *testing.T) {
+	x := &expr{repr: &literalExpr{node: ast.String("")}}
+	object := &value{def: x, repr: map[string]*value{
+		"roleArn":     {def: x, schema: schema.Null().Schema()},
+		"sessionName": {def: x, repr: "session", schema: schema.String().Schema()},
+	}, schema: schema.Always()}
+
+	accept := func(nonNull bool) *schema.Schema {
+		return schema.Object().
+			Properties(schema.BuilderMap{
+				"roleArn":     schema.Always(),
+				"sessionName": schema.Always(),
+			}).
+			Required("roleArn", "sessionName").
+			RequiredNonNull(nonNull).
+			Schema()
+	}
+
+	t.Run("null allowed", func(t *testing.T) {
+		var validator validator
+		assert.True(t, validator.validateElement(object, accept(false), validationLoc{x: x}))
+		assert.Empty(t, validator.diags)
+	})
+
+	t.Run("null is missing", func(t *testing.T) {
+		var validator validator
+		assert.False(t, validator.validateElement(object, accept(true), validationLoc{x: x}))
+		require.Len(t, validator.diags, 1)
+		assert.Equal(t, "missing required properties: roleArn", validator.diags[0].Summary)
+	})
+}
+
+func TestValidateEnumCaseInsensitive(t *testing.T) {
+	x := &expr{repr: &literalExpr{node: ast.String("")}}
+	stage := &value{def: x, repr: "PROD", schema: schema.String().Const("PROD").Schema()}
+
+	accept := func(caseInsensitive bool) *schema.Schema {
+		return schema.String().Enum("dev", "prod").EnumCaseInsensitive(caseInsensitive).Schema()
+	}
+
+	t.Run("case-sensitive", func(t *testing.T) {
+		var validator validator
+		assert.False(t, validator.validateElement(stage, accept(false), validationLoc{x: x}))
+		require.Len(t, validator.diags, 1)
+		assert.Equal(t, normalizeEnums(stage, accept(false)), stage)
+	})
+
+	t.Run("case-insensitive", func(t *testing.T) {
+		var validator validator
+		assert.True(t, validator.validateElement(stage, accept(true), validationLoc{x: x}))
+		assert.Empty(t, validator.diags)
+
+		normalized := normalizeEnums(stage, accept(true))
+		assert.Equal(t, "prod", normalized.repr)
+		assert.Equal(t, "PROD", stage.repr)
+	})
+
+	t.Run("provider inputs", func(t *testing.T) {
+		const def = `values:
+  stage:
+    fn::open::deploy:
+      stage: PROD
+`
+
+		var received map[string]esc.Value
+		registry := NewProviderRegistry()
+		registry.Register("deploy",
+			schema.Record(schema.BuilderMap{"stage": schema.String().Enum("dev", "prod").EnumCaseInsensitive(true)}).Schema(),
+			schema.String().Schema(),
+			func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+				received = inputs
+				return inputs["stage"], nil
+			})
+
+		env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+		require.NoError(t, err)
+		require.Empty(t, diags)
+
+		actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Empty(t, diags)
+		assert.Equal(t, "prod", received["stage"].Value)
+		assert.Equal(t, "prod", actual.Properties["stage"].Value)
+	})
+}
+
+func TestValidateRefCycle(t *testing.T) {
+	x := &expr{repr: &literalExpr{node: ast.String("")}}
+	v := &value{def: x, repr: "loop", schema: schema.String().Schema()}
+
+	// Each definition refers to the next through a chain of in-place applicators, so no step consumes any part of the
+	// value.
+	var accept schema.Schema
+	err := json.Unmarshal([]byte(`{
+		"$ref": "#/$defs/a",
+		"$defs": {
+			"a": {"anyOf": [{"$ref": "#/$defs/b"}, {"type": "string"}]},
+			"b": {"oneOf": [{"$ref": "#/$defs/c"}]},
+			"c": {"$ref": "#/$defs/a"}
+		}
+	}`), &accept)
+	require.NoError(t, err)
+
+	var validator validator
+	assert.False(t, validator.validateElement(v, &accept, validationLoc{x: x}))
+	require.Len(t, validator.diags, 1)
+	assert.Equal(t, "internal error: invalid schema: schema contains an unresolvable reference cycle",
+		validator.diags[0].Summary)
+}
+
+func TestValidationCache(t *testing.T) {
+	x := &expr{repr: &literalExpr{node: ast.String("")}}
+	v := &value{def: x, repr: "hello", schema: schema.String().Schema()}
+	loc := validationLoc{x: x}
+
+	cache := newValidationCache()
+	validate := func(v *value, accept *schema.Schema, jsonPointers bool) (bool, syntax.Diagnostics) {
+		validator := validator{jsonPointers: jsonPointers, cache: cache}
+		ok := validator.validateValue(v, accept, loc)
+		return ok, validator.diags
+	}
+
+	// Successful validations are cached. Separately built schemas with the same contents share cached validations.
+	ok, diags := validate(v, schema.String().MinLength(1).Schema(), false)
+	assert.True(t, ok)
+	assert.Empty(t, diags)
+	assert.Equal(t, 0, cache.hits)
+	assert.Equal(t, 1, cache.misses)
+
+	ok, diags = validate(v, schema.String().MinLength(1).Schema(), false)
+	assert.True(t, ok)
+	assert.Empty(t, diags)
+	assert.Equal(t, 1, cache.hits)
+	assert.Equal(t, 1, cache.misses)
+
+	// Copies share the identity of the value they were copied from, and therefore share its cached validations.
+	c := newCopier().copy(v)
+	assert.Same(t, v, c.identity())
+	assert.Same(t, v, newCopier().copy(c).identity())
+
+	ok, _ = validate(c, schema.String().MinLength(1).Schema(), false)
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.hits)
+
+	// Validations against different schemas or with different settings are not satisfied by the cache.
+	ok, _ = validate(v, schema.String().MinLength(2).Schema(), false)
+	assert.True(t, ok)
+	ok, _ = validate(v, schema.String().MinLength(1).Schema(), true)
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.hits)
+	assert.Equal(t, 3, cache.misses)
+
+	// Failed validations are not cached, and report their errors each time.
+	for i := 0; i < 2; i++ {
+		ok, diags = validate(v, schema.String().MaxLength(1).Schema(), false)
+		assert.False(t, ok)
+		assert.Len(t, diags, 1)
+	}
+	assert.Equal(t, 2, cache.hits)
+	assert.Equal(t, 5, cache.misses)
+
+	// Schemas with references are identified by address, as the same reference may resolve differently in each.
+	refSchema := func(minLength int) *schema.Schema {
+		var s schema.Schema
+		err := json.Unmarshal([]byte(fmt.Sprintf(`{"$ref": "#/$defs/a", "$defs": {"a": {"type": "string", "minLength": %d}}}`,
+			minLength)), &s)
+		require.NoError(t, err)
+		return &s
+	}
+	accept := refSchema(1)
+	ok, _ = validate(v, accept, false)
+	assert.True(t, ok)
+	ok, _ = validate(v, refSchema(1), false)
+	assert.True(t, ok)
+	assert.Equal(t, 2, cache.hits)
+	ok, _ = validate(v, accept, false)
+	assert.True(t, ok)
+	assert.Equal(t, 3, cache.hits)
+	assert.Equal(t, 7, cache.misses)
+}
+
+func TestValidationLocRender(t *testing.T) {
+	cases := []struct {
+		path    []any
+		dotted  string
+		pointer string
+	}{
+		{path: nil, dotted: "", pointer: ""},
+		{path: []any{"a", "b", 0}, dotted: "a.b[0]", pointer: "/a/b/0"},
+		{path: []any{0, "a"}, dotted: "[0].a", pointer: "/0/a"},
+		{path: []any{"a/b", "c~d"}, dotted: `["a/b"]["c~d"]`, pointer: "/a~1b/c~0d"},
+		{path: []any{"~1", "/~"}, dotted: `["~1"]["/~"]`, pointer: "/~01/~1~0"},
+		{path: []any{""}, dotted: "", pointer: "/"},
+	}
+	for _, c := range cases {
+		t.Run(c.pointer, func(t *testing.T) {
+			loc := validationLoc{path: c.path}
+			assert.Equal(t, c.dotted, loc.render(false))
+			assert.Equal(t, c.pointer, loc.render(true))
+		})
+	}
+}
+
+func TestJSONPointerPaths(t *testing.T) {
+	const def = `values:
+  proxy:
+    fn::open::proxy:
+      fn::fromJSON: '{"api/v1": {"weights~canary": ["stable", "beta"]}}'
+`
+
+	registry := NewProviderRegistry()
+	registry.Register("proxy",
+		&schema.Schema{Const: map[string]any{"api/v1": map[string]any{"weights~canary": []any{"stable", "canary"}}}},
+		nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.NewValue("proxy"), nil
+		})
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	cases := []struct {
+		pointers bool
+		expected string
+	}{
+		{pointers: false, expected: `["api/v1"]["weights~canary"][1]: expected "canary"`},
+		{pointers: true, expected: `/api~1v1/weights~0canary/1: expected "canary"`},
+	}
+	for _, c := range cases {
+		t.Run(fmt.Sprint(c.pointers), func(t *testing.T) {
+			_, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+				&testEnvironments{}, &esc.ExecContext{}, EvalOptions{JSONPointerPaths: c.pointers})
+			require.Len(t, diags, 1)
+			assert.Equal(t, c.expected, diags[0].Summary)
+		})
+	}
+}
+
+func TestValidateAdditionalProperties(t *testing.T) {
+	x := &expr{repr: &literalExpr{node: ast.String("")}}
+	object := &value{def: x, repr: map[string]*value{
+		"name":  {def: x, repr: json.Number("42"), schema: schema.Number().Schema()},
+		"count": {def: x, repr: json.Number("1"), schema: schema.Number().Schema()},
+		"owner": {def: x, repr: "platform", schema: schema.String().Schema()},
+	}, schema: schema.Always()}
+
+	accept := schema.Object().
+		Properties(schema.BuilderMap{"count": schema.Number()}).
+		AdditionalProperties(schema.String()).
+		Schema()
+
+	var validator validator
+	assert.False(t, validator.validateElement(object, accept, validationLoc{x: x}))
+	require.Len(t, validator.diags, 1)
+	assert.Equal(t, `additional property "name": expected string, got number`, validator.diags[0].Summary)
+}
+
+func TestValidatePropertyTitle(t *testing.T) {
+	x := &expr{repr: &literalExpr{node: ast.String("")}}
+	object := &value{def: x, repr: map[string]*value{
+		"roleArn":     {def: x, repr: json.Number("42"), schema: schema.Number().Schema()},
+		"sessionName": {def: x, repr: json.Number("42"), schema: schema.Number().Schema()},
+		"duration":    {def: x, repr: "1h", schema: schema.String().Schema()},
+	}, schema: schema.Always()}
+
+	accept := schema.Record(schema.BuilderMap{
+		"roleArn":     schema.String().Title("the IAM role to assume"),
+		"sessionName": schema.String().Description("the name of the session"),
+		"duration":    schema.Number(),
+	}).Schema()
+
+	var validator validator
+	assert.False(t, validator.validateElement(object, accept, validationLoc{x: x}))
+
+	summaries := make([]string, len(validator.diags))
+	for i, d := range validator.diags {
+		summaries[i] = d.Summary
+	}
+	assert.ElementsMatch(t, []string{
+		"roleArn (the IAM role to assume): expected string, got number",
+		"sessionName (the name of the session): expected string, got number",
+		"expected number, got string",
+	}, summaries)
+}
+
+// BenchmarkValidateScalarItems compares the fast path for arrays of scalars against the general path on a large numeric
+// array that satisfies its minItems and maxItems bounds.
+func BenchmarkValidateScalarItems(b *testing.B) {
+	const n = 100000
+
+	elements := make([]*value, n)
+	for i := range elements {
+		x := &expr{repr: &literalExpr{node: ast.Number(json.Number(strconv.Itoa(i)))}}
+		elements[i] = &value{def: x, repr: json.Number(strconv.Itoa(i)), schema: schema.Number().Schema()}
+	}
+	loc := validationLoc{x: &expr{repr: &literalExpr{node: ast.String("")}}}
+
+	accept := schema.Array().
+		Items(schema.Number().Minimum("0")).
+		MinItems(n).
+		MaxItems(n).
+		Schema()
+	require.NoError(b, accept.Compile())
+
+	b.Run("fast", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var validator validator
+			if !validator.validateArray(elements, accept, loc) {
+				b.Fatal(validator.diags)
+			}
+		}
+	})
+
+	b.Run("general", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var validator validator
+			if !validator.validateItems(elements, accept, loc) {
+				b.Fatal(validator.diags)
+			}
+		}
+	})
+}
+
+type recordingProvider struct {
+	opened *[]string
+}
+
+func (recordingProvider) Schema() (*schema.Schema, *schema.Schema) {
+	return schema.Always(), schema.Always()
+}
+
+func (p recordingProvider) Open(ctx context.Context, inputs map[string]esc.Value, context esc.EnvExecContext) (esc.Value, error) {
+	*p.opened = append(*p.opened, inputs["name"].Value.(string))
+	return esc.NewValue(inputs), nil
+}
+
+type recordingProviders struct {
+	opened []string
+}
+
+func (rp *recordingProviders) LoadProvider(ctx context.Context, name string) (esc.Provider, error) {
+	return recordingProvider{opened: &rp.opened}, nil
+}
+
+func TestDependentOpenOrder(t *testing.T) {
+	const def = `values:
+  a:
+    fn::open::record:
+      name: a
+      source: ${b.token}
+  b:
+    fn::open::record:
+      name: b
+      token: ${c}
+  c:
+    fn::open::record:
+      name: c
+`
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	providers := &recordingProviders{}
+	actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, providers,
+		&testEnvironments{}, &esc.ExecContext{})
+	require.Empty(t, diags)
+
+	// Each open must be invoked after the opens it depends upon, and each must be invoked exactly once.
+	assert.Equal(t, []string{"c", "b", "a"}, providers.opened)
+	assert.Equal(t, "c", actual.Properties["a"].Value.(map[string]esc.Value)["source"].
+		Value.(map[string]esc.Value)["name"].Value)
+}
+
+func TestLogicalShortCircuit(t *testing.T) {
+	const def = `values:
+  and-skipped:
+    fn::and:
+      - false
+      - fn::equals: [{fn::open::record: {name: and-skipped}}, {name: and-skipped}]
+  and-evaluated:
+    fn::and:
+      - true
+      - fn::equals: [{fn::open::record: {name: and-evaluated}}, {name: and-evaluated}]
+  or-skipped:
+    fn::or:
+      - true
+      - fn::equals: [{fn::open::record: {name: or-skipped}}, {name: or-skipped}]
+  or-evaluated:
+    fn::or:
+      - false
+      - fn::equals: [{fn::open::record: {name: or-evaluated}}, {name: or-evaluated}]
+`
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	providers := &recordingProviders{}
+	actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, providers,
+		&testEnvironments{}, &esc.ExecContext{})
+	require.Empty(t, diags)
+
+	// Operands following the short-circuiting operand must not be evaluated.
+	assert.ElementsMatch(t, []string{"and-evaluated", "or-evaluated"}, providers.opened)
+	assert.Equal(t, false, actual.Properties["and-skipped"].Value)
+	assert.Equal(t, true, actual.Properties["and-evaluated"].Value)
+	assert.Equal(t, true, actual.Properties["or-skipped"].Value)
+	assert.Equal(t, true, actual.Properties["or-evaluated"].Value)
+}
+
+type awsOIDCProvider struct{}
+
+func (awsOIDCProvider) Schema() (*schema.Schema, *schema.Schema) {
+	inputs := schema.Record(schema.BuilderMap{
+		"roleArn":     schema.String(),
+		"sessionName": schema.String(),
+	}).Schema()
+	outputs := schema.Record(schema.BuilderMap{
+		"accessKeyId":     schema.String(),
+		"secretAccessKey": schema.String(),
+	}).Schema()
+	return inputs, outputs
+}
+
+func (awsOIDCProvider) Open(ctx context.Context, inputs map[string]esc.Value, context esc.EnvExecContext) (esc.Value, error) {
+	return esc.NewValue(map[string]esc.Value{
+		"accessKeyId":     esc.NewValue("AKIA"),
+		"secretAccessKey": esc.NewSecret("shh"),
+	}), nil
+}
+
+type awsOIDCProviders struct {
+	testProviders
+}
+
+func (p awsOIDCProviders) LoadProvider(ctx context.Context, name string) (esc.Provider, error) {
+	if name == "aws-oidc" {
+		return awsOIDCProvider{}, nil
+	}
+	return p.testProviders.LoadProvider(ctx, name)
+}
+
+func TestOverlay(t *testing.T) {
+	environments := &benchEnvironments{defs: map[string][]byte{
+		"base": []byte(`values:
+  region: us-west-2
+  app:
+    name: site
+    replicas: 1
+`),
+		"prod": []byte(`values:
+  app:
+    replicas: 3
+`),
+		"dev": []byte(`values:
+  app:
+    debug: true
+`),
+	}}
+
+	eval := func(names ...string) map[string]any {
+		actual, diags := EvalEnvironment(context.Background(), "<overlay>", ast.Overlay(names...), rot128{},
+			testProviders{}, environments, &esc.ExecContext{})
+		require.Empty(t, diags)
+		return esc.NewValue(actual.Properties).ToJSON(false).(map[string]any)
+	}
+
+	assert.Equal(t, map[string]any{
+		"region": "us-west-2",
+		"app":    map[string]any{"name": "site", "replicas": json.Number("3")},
+	}, eval("base", "prod"))
+
+	assert.Equal(t, map[string]any{
+		"region": "us-west-2",
+		"app":    map[string]any{"name": "site", "replicas": json.Number("1"), "debug": true},
+	}, eval("base", "dev"))
+}
+
+func TestSecretEnvironment(t *testing.T) {
+	environments := &benchEnvironments{defs: map[string][]byte{
+		"base": []byte(`values:
+  region: us-west-2
+  tags:
+    team: platform
+`),
+		"secret": []byte(`secret: true
+imports:
+  - base
+values:
+  port: 5432
+  hosts: [db1, db2]
+  tags:
+    env: prod
+`),
+		"public": []byte(`imports:
+  - secret
+values:
+  greeting: hello
+`),
+	}}
+
+	var assertLeaves func(t *testing.T, path string, v esc.Value, secret bool)
+	assertLeaves = func(t *testing.T, path string, v esc.Value, secret bool) {
+		switch pv := v.Value.(type) {
+		case []esc.Value:
+			for i, e := range pv {
+				assertLeaves(t, fmt.Sprintf("%v[%v]", path, i), e, secret)
+			}
+		case map[string]esc.Value:
+			for k, p := range pv {
+				assertLeaves(t, path+"."+k, p, secret)
+			}
+		default:
+			assert.Equal(t, secret, v.Secret, path)
+		}
+	}
+
+	eval := func(name string) *esc.Environment {
+		env, diags, err := LoadYAMLBytes(name, environments.defs[name])
+		require.NoError(t, err)
+		require.Empty(t, diags)
+
+		actual, diags := EvalEnvironment(context.Background(), name, env, rot128{}, testProviders{}, environments,
+			&esc.ExecContext{})
+		require.Empty(t, diags)
+		return actual
+	}
+
+	t.Run("secret", func(t *testing.T) {
+		actual := eval("secret")
+		assert.ElementsMatch(t, []string{"region", "tags", "port", "hosts"}, maps.Keys(actual.Properties))
+		for k, v := range actual.Properties {
+			assert.True(t, v.Secret, k)
+			assertLeaves(t, k, v, true)
+		}
+	})
+
+	t.Run("import", func(t *testing.T) {
+		actual := eval("public")
+		for k, v := range actual.Properties {
+			assertLeaves(t, k, v, k != "greeting")
+		}
+	})
+
+	t.Run("base", func(t *testing.T) {
+		actual := eval("base")
+		for k, v := range actual.Properties {
+			assertLeaves(t, k, v, false)
+		}
+	})
+}
+
+func TestObserver(t *testing.T) {
+	const def = `values:
+  region: us-west-2
+  password:
+    fn::secret: hunter2
+  greeting: hello from ${region}
+  config:
+    region: ${region}
+    password: ${password}
+`
+
+	env, diags, err := LoadYAMLBytes("test", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	var observations []Observation
+	opts := EvalOptions{Observer: func(o Observation) { observations = append(observations, o) }}
+
+	expected, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, testProviders{},
+		&testEnvironments{}, &esc.ExecContext{})
+	require.Empty(t, diags)
+
+	actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, testProviders{},
+		&testEnvironments{}, &esc.ExecContext{}, opts)
+	require.Empty(t, diags)
+
+	// The observer must not affect the result.
+	assert.Equal(t, expected.Properties, actual.Properties)
+
+	evaluated, cached := map[string]int{}, map[string]int{}
+	values := map[string]esc.Value{}
+	for _, o := range observations {
+		assert.Equal(t, "test", o.Environment)
+		if o.Cached {
+			cached[o.Path]++
+		} else {
+			evaluated[o.Path]++
+			values[o.Path] = o.Value
+		}
+	}
+
+	for _, key := range []string{"region", "password", "greeting", "config"} {
+		assert.Equal(t, 1, evaluated[key], key)
+	}
+	assert.Equal(t, "[secret]", values["password"].Value)
+	assert.Equal(t, "hello from us-west-2", values["greeting"].Value)
+
+	// region is referenced by greeting and config.region, and is evaluated before at least one of them.
+	assert.NotZero(t, cached["region"])
+}
+
+func TestEvalMetrics(t *testing.T) {
+	const def = `values:
+  opened:
+    fn::open::bench: {}
+  joined:
+    fn::join: [",", ["${opened}", "${opened}"]]
+`
+
+	env, diags, err := LoadYAMLBytes("test", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	delay := 10 * time.Millisecond
+
+	var metrics EvalMetrics
+	_, diags = EvalEnvironment(context.Background(), "test", env, rot128{}, testProviders{benchDelay: delay},
+		&testEnvironments{}, &esc.ExecContext{}, EvalOptions{Metrics: &metrics})
+	require.Empty(t, diags)
+
+	require.Contains(t, metrics.Opens, "bench")
+	assert.Equal(t, 1, metrics.Opens["bench"].Count)
+	assert.GreaterOrEqual(t, metrics.Opens["bench"].Total, delay)
+
+	require.Contains(t, metrics.Builtins, "fn::open::bench")
+	assert.Equal(t, 1, metrics.Builtins["fn::open::bench"].Count)
+	assert.GreaterOrEqual(t, metrics.Builtins["fn::open::bench"].Total, delay)
+
+	require.Contains(t, metrics.Builtins, "fn::join")
+	assert.Equal(t, 1, metrics.Builtins["fn::join"].Count)
+}
+
+func TestOpenCapture(t *testing.T) {
+	const def = `imports:
+  - base
+values:
+  aws:
+    fn::open:
+      provider: aws-oidc
+      inputs:
+        sessionName: site-prod-session
+        roleArn:
+          fn::secret: some-role-arn
+`
+
+	environments := &testEnvironments{root: t.TempDir()}
+	err := os.WriteFile(filepath.Join(environments.root, "base.yaml"), []byte(`values:
+  base:
+    fn::open::test: {hello: world}
+`), 0o600)
+	require.NoError(t, err)
+
+	env, diags, err := LoadYAMLBytes("prod", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	for _, validating := range []bool{false, true} {
+		t.Run(fmt.Sprintf("validating=%v", validating), func(t *testing.T) {
+			var capture OpenCapture
+			opts := EvalOptions{OpenCapture: &capture}
+
+			var diags syntax.Diagnostics
+			if validating {
+				_, diags = CheckEnvironment(context.Background(), "prod", env, rot128{}, awsOIDCProviders{},
+					environments, &esc.ExecContext{}, false, opts)
+			} else {
+				_, diags = EvalEnvironment(context.Background(), "prod", env, rot128{}, awsOIDCProviders{},
+					environments, &esc.ExecContext{}, opts)
+			}
+			require.Empty(t, diags)
+			require.Len(t, capture.Calls, 2)
+
+			base := capture.Calls[0]
+			assert.Equal(t, "base", base.Environment)
+			assert.Equal(t, "test", base.Provider)
+			assert.Equal(t, map[string]any{"hello": "world"}, base.Inputs.ToJSON(false))
+
+			aws := capture.Calls[1]
+			assert.Equal(t, "prod", aws.Environment)
+			assert.Equal(t, "aws-oidc", aws.Provider)
+			assert.Equal(t, 5, aws.Range.Begin.Line)
+			assert.Equal(t, map[string]any{
+				"sessionName": "site-prod-session",
+				"roleArn":     "[secret]",
+			}, aws.Inputs.ToJSON(false))
+			assert.Equal(t, []string{"accessKeyId", "secretAccessKey"}, aws.Schema.Required)
+
+			// Secret inputs must be redacted.
+			bytes, err := json.Marshal(capture)
+			require.NoError(t, err)
+			assert.NotContains(t, string(bytes), "some-role-arn")
+		})
+	}
+}
+
+func TestObjectKeySourceOrder(t *testing.T) {
+	const def = `values:
+  zulu: 1
+  nested:
+    yankee: 1
+    alpha: 2
+    yankee: 3
+    mike: {c: 1, b: 2, a: 3}
+`
+
+	syn, diags := encoding.DecodeYAMLBytes("<stdin>", []byte(def), TagDecoder)
+	require.Empty(t, diags)
+	env, diags := ast.ParseEnvironment([]byte(def), syn, ast.ParseOptions{DuplicateKeySeverity: hcl.DiagWarning})
+	require.False(t, diags.HasErrors())
+
+	ec := newEvalContext(context.Background(), false, "test", env, rot128{}, testProviders{}, &testEnvironments{},
+		map[string]*imported{}, &esc.ExecContext{}, false)
+	_, diags = ec.evaluate()
+	require.Empty(t, diags)
+
+	// The declared object records its keys in source order, omitting duplicates.
+	nested := ec.root.repr.(*objectExpr).properties["nested"].repr.(*objectExpr)
+	assert.Equal(t, []string{"yankee", "alpha", "mike"}, nested.propertyKeys())
+	assert.Equal(t, []string{"zulu", "nested"}, ec.root.repr.(*objectExpr).propertyKeys())
+
+	// The exported object preserves the source order, and duplicate keys refer to their first definition.
+	x := ec.root.export("test").Object["nested"]
+	assert.Equal(t, []string{"yankee", "alpha", "mike"}, x.ObjectKeys())
+	assert.Equal(t, 4, x.KeyRanges["yankee"].Begin.Line)
+	mike := x.Object["mike"]
+	assert.Equal(t, []string{"c", "b", "a"}, mike.ObjectKeys())
+}
+
+func TestAnchorEvaluatedOnce(t *testing.T) {
+	const def = `values:
+  creds: &creds
+    fn::open::record: {name: creds}
+  copy: *creds
+  joined:
+    fn::join:
+      - ","
+      - - fn::toJSON: &token {fn::open::record: {name: token}}
+        - fn::toJSON: *token
+`
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	providers := &recordingProviders{}
+	actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, providers,
+		&testEnvironments{}, &esc.ExecContext{})
+	require.Empty(t, diags)
+
+	// Each anchored open must be invoked exactly once, regardless of the number of aliases.
+	assert.ElementsMatch(t, []string{"creds", "token"}, providers.opened)
+	assert.Equal(t, actual.Properties["creds"].Value, actual.Properties["copy"].Value)
+	assert.Equal(t, `{"name":"token"},{"name":"token"}`, actual.Properties["joined"].Value)
+
+	// Aliases to top-level values are exported as references to the anchored value.
+	copyExpr := actual.Exprs["copy"]
+	require.Len(t, copyExpr.Symbol, 1)
+	assert.Equal(t, "creds", *copyExpr.Symbol[0].Key)
+	assert.Equal(t, 4, copyExpr.Range.Begin.Line)
+}
+
+func TestExportDeterministic(t *testing.T) {
+	const def = `values:
+  zulu: 1
+  alpha:
+    yankee: ${zulu}
+    bravo: [{x: 1, a: 2}]
+  mike:
+    fn::toJSON: {c: 3, b: 2, a: 1}
+`
+
+	export := func() ([]byte, *esc.Environment) {
+		env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+		require.NoError(t, err)
+		require.Empty(t, diags)
+
+		actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, testProviders{},
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Empty(t, diags)
+
+		bytes, err := json.Marshal(actual)
+		require.NoError(t, err)
+		return bytes, actual
+	}
+
+	first, env := export()
+	for i := 0; i < 10; i++ {
+		again, _ := export()
+		assert.Equal(t, first, again)
+	}
+
+	// Object keys are available in source order.
+	alpha := env.Exprs["alpha"]
+	assert.Equal(t, []string{"yankee", "bravo"}, alpha.ObjectKeys())
+	assert.Equal(t, []string{"x", "a"}, alpha.Object["bravo"].List[0].ObjectKeys())
+	assert.Equal(t, []string{"c", "b", "a"}, env.Exprs["mike"].Builtin.Arg.ObjectKeys())
+
+	// Keys without ranges are ordered lexicographically.
+	x := esc.Expr{Object: map[string]esc.Expr{"b": {}, "a": {}, "c": {}}}
+	assert.Equal(t, []string{"a", "b", "c"}, x.ObjectKeys())
+}
+
+func TestBuiltinArgValues(t *testing.T) {
+	const def = `values:
+  password:
+    fn::secret: hunter2
+  open:
+    fn::open::test:
+      user: admin
+      password: ${password}
+  json:
+    fn::toJSON: ${open}
+  joined:
+    fn::join: [",", ["${open.user}", "${password}"]]
+  hmac:
+    fn::hmac:
+      algorithm: sha256
+      key: Jefe
+      message: ${open.user}
+  secretIf:
+    fn::secretIf:
+      condition: true
+      value: swordfish
+`
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	eval := func(opts ...EvalOptions) *esc.Environment {
+		actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, testProviders{},
+			&testEnvironments{}, &esc.ExecContext{}, opts...)
+		require.Empty(t, diags)
+		return actual
+	}
+
+	t.Run("default", func(t *testing.T) {
+		actual := eval()
+		assert.Nil(t, actual.Exprs["open"].Builtin.ArgValue)
+		assert.Nil(t, actual.Exprs["json"].Builtin.ArgValue)
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		actual := eval(EvalOptions{BuiltinArgValues: true})
+
+		open := actual.Exprs["open"].Builtin.ArgValue
+		require.NotNil(t, open)
+		assert.Equal(t, map[string]any{"user": "admin", "password": "[secret]"}, open.ToJSON(false))
+
+		json := actual.Exprs["json"].Builtin.ArgValue
+		require.NotNil(t, json)
+		assert.Equal(t, map[string]any{"user": "admin", "password": "[secret]"}, json.ToJSON(false))
+
+		joined := actual.Exprs["joined"].Builtin.ArgValue
+		require.NotNil(t, joined)
+		assert.Equal(t, []any{",", []any{"admin", "[secret]"}}, joined.ToJSON(false))
+
+		password := actual.Exprs["password"].Builtin.ArgValue
+		require.NotNil(t, password)
+		assert.True(t, password.Secret)
+		assert.Equal(t, "[secret]", password.ToJSON(false))
+
+		// HMAC keys are redacted even if they are not secret.
+		hmac := actual.Exprs["hmac"].Builtin.ArgValue
+		require.NotNil(t, hmac)
+		assert.Equal(t, map[string]any{"algorithm": "sha256", "key": "[secret]", "message": "admin"}, hmac.ToJSON(false))
+
+		// Values made secret by fn::secretIf are redacted even though they are not secret themselves.
+		secretIf := actual.Exprs["secretIf"].Builtin.ArgValue
+		require.NotNil(t, secretIf)
+		assert.Equal(t, map[string]any{"condition": true, "value": "[secret]"}, secretIf.ToJSON(false))
+	})
+
+	t.Run("revealed", func(t *testing.T) {
+		actual := eval(EvalOptions{BuiltinArgValues: true, ShowSecretArgValues: true})
+
+		open := actual.Exprs["open"].Builtin.ArgValue
+		require.NotNil(t, open)
+		assert.Equal(t, map[string]any{"user": "admin", "password": "hunter2"}, open.ToJSON(false))
+
+		hmac := actual.Exprs["hmac"].Builtin.ArgValue
+		require.NotNil(t, hmac)
+		assert.Equal(t, map[string]any{"algorithm": "sha256", "key": "Jefe", "message": "admin"}, hmac.ToJSON(false))
+	})
+}
+
+func TestProvenance(t *testing.T) {
+	const def = `imports:
+  - base
+values:
+  shared: local
+  password:
+    fn::secret: hunter2
+  local: ${shared}
+  inherited: ${baseOnly}
+  secret: ${password}
+  chained: ${local}
+  list: [ "${shared}" ]
+`
+
+	environments := &testEnvironments{root: t.TempDir()}
+	err := os.WriteFile(filepath.Join(environments.root, "base.yaml"), []byte(`values:
+  shared: base
+  baseOnly: base
+`), 0o600)
+	require.NoError(t, err)
+
+	env, diags, err := LoadYAMLBytes("prod", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	eval := func(opts ...EvalOptions) *esc.Environment {
+		actual, diags := EvalEnvironment(context.Background(), "prod", env, rot128{}, testProviders{},
+			environments, &esc.ExecContext{}, opts...)
+		require.Empty(t, diags)
+		return actual
+	}
+
+	t.Run("default", func(t *testing.T) {
+		actual := eval()
+		assert.Nil(t, actual.Exprs["shared"].Provenance)
+		assert.Nil(t, actual.Exprs["local"].Provenance)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		actual := eval(EvalOptions{Provenance: true})
+
+		provenance := func(x esc.Expr) (string, int) {
+			require.NotNil(t, x.Provenance)
+			return x.Provenance.Environment, x.Provenance.Begin.Line
+		}
+
+		// The local definition overrides the base's, so it defines the value.
+		shared := actual.Exprs["shared"]
+		environment, line := provenance(shared)
+		assert.Equal(t, "prod", environment)
+		assert.Equal(t, 4, line)
+
+		require.NotNil(t, shared.Base)
+		environment, line = provenance(*shared.Base)
+		assert.Equal(t, "base", environment)
+		assert.Equal(t, 2, line)
+
+		environment, line = provenance(actual.Exprs["local"])
+		assert.Equal(t, "prod", environment)
+		assert.Equal(t, 4, line)
+
+		environment, line = provenance(actual.Exprs["inherited"])
+		assert.Equal(t, "base", environment)
+		assert.Equal(t, 3, line)
+
+		environment, line = provenance(actual.Exprs["chained"])
+		assert.Equal(t, "prod", environment)
+		assert.Equal(t, 4, line)
+
+		environment, line = provenance(actual.Exprs["list"].List[0])
+		assert.Equal(t, "prod", environment)
+		assert.Equal(t, 4, line)
+		assert.Nil(t, actual.Exprs["list"].Provenance)
+
+		// Secrets are redacted from the exported environment, but their provenance is still reported.
+		environment, line = provenance(actual.Exprs["secret"])
+		assert.Equal(t, "prod", environment)
+		assert.Equal(t, 6, line)
+		assert.Equal(t, "[secret]", actual.Properties["secret"].ToJSON(true))
+	})
+}
+
+func TestEvalKeys(t *testing.T) {
+	const def = `values:
+  region: us-west-2
+  creds:
+    fn::open::test:
+      region: ${region}
+  derived: ${creds.region}
+  unrelated:
+    fn::open::test:
+      region: eu-central-1
+`
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	var capture OpenCapture
+	actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, testProviders{},
+		&testEnvironments{}, &esc.ExecContext{}, EvalOptions{OpenCapture: &capture, Keys: []string{"derived", "missing"}})
+	require.Empty(t, diags)
+
+	assert.Equal(t, map[string]any{"derived": "us-west-2"}, esc.NewValue(actual.Properties).ToJSON(false))
+
+	// Only the provider that the requested key depends on is invoked.
+	require.Len(t, capture.Calls, 1)
+	assert.Equal(t, map[string]any{"region": "us-west-2"}, capture.Calls[0].Inputs.ToJSON(false))
+}
+
+func TestTopLevelIsolation(t *testing.T) {
+	const def = `values:
+  aws:
+    region: us-west-2
+    creds:
+      fn::open::broken: {}
+  gcp:
+    project:
+      fn::fromJSON: "{"
+  pulumi:
+    org: acme
+    region: ${aws.region}
+`
+
+	registry := NewProviderRegistry()
+	registry.Register("broken", nil, nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			panic("provider crashed")
+		})
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+		&testEnvironments{}, &esc.ExecContext{})
+
+	// The failures in aws and gcp are both reported...
+	summaries := make([]string, len(diags))
+	for i, d := range diags {
+		summaries[i] = d.Summary
+	}
+	require.Len(t, summaries, 2)
+	assert.Equal(t, `opening provider "broken": provider panicked: provider crashed`, summaries[0])
+	assert.Equal(t, "decoding JSON string: unexpected EOF", summaries[1])
+
+	// ...and do not prevent pulumi from evaluating, even though it refers to aws.
+	require.NotNil(t, actual)
+	aws := actual.Properties["aws"].Value.(map[string]esc.Value)
+	assert.True(t, aws["creds"].Unknown)
+	assert.Equal(t, map[string]any{"org": "acme", "region": "us-west-2"}, actual.Properties["pulumi"].ToJSON(false))
+}
+
+func TestOmitHidden(t *testing.T) {
+	const def = `values:
+  service:
+    fn::open::service: {}
+  summary: ${service.endpoint} (${service.internalId})
+`
+
+	registry := NewProviderRegistry()
+	registry.Register("service", nil,
+		schema.Record(schema.BuilderMap{
+			"endpoint":   schema.String(),
+			"internalId": &schema.Schema{Type: "string", Hidden: true},
+		}).Schema(),
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.NewValue(map[string]esc.Value{
+				"endpoint":   esc.NewValue("https://example.com"),
+				"internalId": esc.NewValue("svc-1234"),
+			}), nil
+		})
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	t.Run("shown", func(t *testing.T) {
+		actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Empty(t, diags)
+		assert.Equal(t, map[string]any{"endpoint": "https://example.com", "internalId": "svc-1234"},
+			actual.Properties["service"].ToJSON(false))
+	})
+
+	t.Run("omitted", func(t *testing.T) {
+		actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{}, EvalOptions{OmitHidden: true})
+		require.Empty(t, diags)
+
+		// The hidden property is absent from the export, but is still evaluated and available to other values.
+		assert.Equal(t, map[string]any{"endpoint": "https://example.com"}, actual.Properties["service"].ToJSON(false))
+		assert.Equal(t, "https://example.com (svc-1234)", actual.Properties["summary"].Value)
+	})
+}
+
+func TestRejectUnknowns(t *testing.T) {
+	const def = `values:
+  service:
+    fn::open::service: {}
+  endpoints:
+    - https://example.com
+    - ${service.endpoint}
+  token: ${service.token}
+`
+
+	registry := NewProviderRegistry()
+	registry.Register("service", nil, nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.NewValue(map[string]esc.Value{
+				"endpoint": {Unknown: true},
+				"token":    esc.NewValue("hunter2"),
+			}), nil
+		})
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	t.Run("allowed", func(t *testing.T) {
+		actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Empty(t, diags)
+		assert.True(t, actual.Properties["endpoints"].Value.([]esc.Value)[1].Unknown)
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		_, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{}, EvalOptions{RejectUnknowns: true})
+		require.Len(t, diags, 1)
+		assert.Equal(t, "the environment contains unknown values: endpoints[1], service.endpoint", diags[0].Summary)
+	})
+
+	t.Run("pointers", func(t *testing.T) {
+		_, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{}, EvalOptions{RejectUnknowns: true, JSONPointerPaths: true})
+		require.Len(t, diags, 1)
+		assert.Equal(t, "the environment contains unknown values: /endpoints/1, /service/endpoint", diags[0].Summary)
+	})
+
+	t.Run("known", func(t *testing.T) {
+		known, diags, err := LoadYAMLBytes("<stdin>", []byte("values:\n  hello: world\n"))
+		require.NoError(t, err)
+		require.Empty(t, diags)
+
+		_, diags = EvalEnvironment(context.Background(), "test", known, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{}, EvalOptions{RejectUnknowns: true})
+		assert.Empty(t, diags)
+	})
+}
+
+func TestMaxImportDepth(t *testing.T) {
+	environments := &benchEnvironments{defs: map[string][]byte{
+		"b": []byte("imports:\n  - c\nvalues:\n  b: ${c}\n"),
+		"c": []byte("imports:\n  - d\nvalues:\n  c: ${d}\n"),
+		"d": []byte("values:\n  d: hello\n  e:\n    fn::import:\n      env: e\n      path: e\n"),
+		"e": []byte("values:\n  e: world\n"),
+	}}
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte("imports:\n  - b\nvalues:\n  a: ${b}\n"))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	t.Run("unlimited", func(t *testing.T) {
+		actual, diags := EvalEnvironment(context.Background(), "a", env, rot128{}, nil, environments,
+			&esc.ExecContext{})
+		require.Empty(t, diags)
+		assert.Equal(t, "hello", actual.Properties["a"].Value)
+		assert.Equal(t, "world", actual.Properties["e"].Value)
+	})
+
+	t.Run("within limit", func(t *testing.T) {
+		_, diags := EvalEnvironment(context.Background(), "a", env, rot128{}, nil, environments,
+			&esc.ExecContext{}, EvalOptions{MaxImportDepth: 4})
+		require.Empty(t, diags)
+	})
+
+	t.Run("fn::import exceeds limit", func(t *testing.T) {
+		_, diags := EvalEnvironment(context.Background(), "a", env, rot128{}, nil, environments,
+			&esc.ExecContext{}, EvalOptions{MaxImportDepth: 3})
+		require.Len(t, diags, 1)
+		assert.Equal(t, "importing e exceeds the maximum import depth of 3", diags[0].Summary)
+		assert.Equal(t, "d", diags[0].Subject.Filename)
+		assert.Equal(t, 5, diags[0].Subject.Start.Line)
+	})
+
+	t.Run("exceeds limit", func(t *testing.T) {
+		_, diags := EvalEnvironment(context.Background(), "a", env, rot128{}, nil, environments,
+			&esc.ExecContext{}, EvalOptions{MaxImportDepth: 2})
+		// The skipped import also leaves c's reference to d unresolved.
+		require.Len(t, diags, 2)
+		assert.Equal(t, "importing d exceeds the maximum import depth of 2", diags[0].Summary)
+		assert.Equal(t, "c", diags[0].Subject.Filename)
+		assert.Equal(t, 2, diags[0].Subject.Start.Line)
+	})
+
+	// An environment that has already been imported elsewhere still counts its own imports towards the limit, so the
+	// result does not depend on the order in which environments are first imported.
+	t.Run("shared import", func(t *testing.T) {
+		environments := &benchEnvironments{defs: map[string][]byte{
+			"b": []byte("imports:\n  - c\nvalues:\n  b: ${c}\n"),
+			"c": []byte("imports:\n  - d\nvalues:\n  c: ${d}\n"),
+			"d": []byte("values:\n  d: hello\n"),
+		}}
+
+		cases := []struct {
+			name    string
+			def     string
+			subject string
+		}{
+			{name: "c first", def: "imports:\n  - c\n  - b\n", subject: "b"},
+			{name: "b first", def: "imports:\n  - b\n  - c\n", subject: "c"},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				env, diags, err := LoadYAMLBytes("<stdin>", []byte(c.def))
+				require.NoError(t, err)
+				require.Empty(t, diags)
+
+				_, diags = EvalEnvironment(context.Background(), "a", env, rot128{}, nil, environments,
+					&esc.ExecContext{}, EvalOptions{MaxImportDepth: 2})
+				require.NotEmpty(t, diags)
+				assert.Contains(t, diags[0].Summary, "exceeds the maximum import depth of 2")
+				assert.Equal(t, c.subject, diags[0].Subject.Filename)
+
+				_, diags = EvalEnvironment(context.Background(), "a", env, rot128{}, nil, environments,
+					&esc.ExecContext{}, EvalOptions{MaxImportDepth: 3})
+				assert.Empty(t, diags)
+			})
+		}
+	})
+}
+
+func TestDependentSchemas(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register("login",
+		schema.Object().
+			Properties(schema.BuilderMap{
+				"useOidc": schema.Boolean(),
+				"roleArn": schema.String(),
+			}).
+			DependentSchemas(schema.BuilderMap{
+				"useOidc": schema.Object().Required("roleArn"),
+			}).
+			Schema(),
+		nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.NewValue(inputs), nil
+		})
+
+	eval := func(t *testing.T, def string) syntax.Diagnostics {
+		env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+		require.NoError(t, err)
+		require.Empty(t, diags)
+
+		_, diags = EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		return diags
+	}
+
+	t.Run("trigger present", func(t *testing.T) {
+		diags := eval(t, "values:\n  creds:\n    fn::open::login:\n      useOidc: true\n")
+		require.Len(t, diags, 1)
+		assert.Equal(t, `dependent schema for "useOidc": missing required properties: roleArn`, diags[0].Summary)
+
+		// The error blames the object.
+		assert.Equal(t, 4, diags[0].Subject.Start.Line)
+		assert.Equal(t, 7, diags[0].Subject.Start.Column)
+	})
+
+	t.Run("trigger present and satisfied", func(t *testing.T) {
+		diags := eval(t, "values:\n  creds:\n    fn::open::login:\n      useOidc: true\n      roleArn: arn\n")
+		assert.Empty(t, diags)
+	})
+
+	t.Run("trigger absent", func(t *testing.T) {
+		diags := eval(t, "values:\n  creds:\n    fn::open::login: {}\n")
+		assert.Empty(t, diags)
+	})
+}
@@ -15,15 +15,21 @@
 package eval
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"mime"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/pulumi/esc/ast"
 	"github.com/pulumi/esc/internal/util"
 	"github.com/pulumi/esc/schema"
 	"github.com/pulumi/esc/syntax"
+	"golang.org/x/exp/maps"
 )
 
 // jsonRepr returns the JSON string representation of the given value.
@@ -43,11 +49,42 @@ func jsonRepr(v any) string {
 // defined by a literal, we want to blame the defining expression, but include the relative path to the property that
 // causes a validation failure.
 type validationLoc struct {
-	x      *expr  // the expression that defines the value
-	path   string // the relative path to the value
-	prefix bool   // true if errorf should include the path as a prefix in errors
+	x      *expr // the expression that defines the value
+	path   []any // the relative path to the value as a list of property names (strings) and indices (ints)
+	prefix bool  // true if errorf should include the path as a prefix in errors
 }
 
+// with returns a copy of the location's path with the given property name or index appended.
+func (l validationLoc) with(segment any) []any {
+	return append(l.path[:len(l.path):len(l.path)], segment)
+}
+
+// render renders the location's path. By default, the path is rendered as a property path, e.g. `a.b[0]`. If pointer
+// is true, the path is rendered as an RFC 6901 JSON Pointer, e.g. `/a/b/0`.
+func (l validationLoc) render(pointer bool) string {
+	path := ""
+	for _, segment := range l.path {
+		switch segment := segment.(type) {
+		case int:
+			if pointer {
+				path = fmt.Sprintf("%v/%v", path, segment)
+			} else {
+				path = fmt.Sprintf("%v[%v]", path, segment)
+			}
+		case string:
+			if pointer {
+				path = path + "/" + jsonPointerEscaper.Replace(segment)
+			} else {
+				path = util.JoinKey(path, segment)
+			}
+		}
+	}
+	return path
+}
+
+// jsonPointerEscaper escapes the reference tokens of a JSON Pointer as described in RFC 6901, section 3.
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
 // index returns the validationLoc associated with the given index. If the location's expression is an array literal
 // and the index is in range, then the returned location will refer to the array element at the given index. Otherwise,
 // the returned location will refer to the original expression, but will include an appropriate path prefix.
@@ -56,12 +93,12 @@ func (l validationLoc) index(i int) validationLoc {
 	if isLiteral && i < len(list.elements) {
 		return validationLoc{
 			x:    list.elements[i],
-			path: fmt.Sprintf("[%v]", i),
+			path: []any{i},
 		}
 	}
 	return validationLoc{
 		x:      l.x,
-		path:   fmt.Sprintf("%v[%v]", l.path, i),
+		path:   l.with(i),
 		prefix: true,
 	}
 }
@@ -79,27 +116,93 @@ func (l validationLoc) property(k string) validationLoc {
 		if v, ok := obj.properties[k]; ok {
 			return validationLoc{
 				x:    v,
-				path: util.JoinKey("", k),
+				path: []any{k},
 			}
 		}
 	}
 	return validationLoc{
 		x:      l.x,
-		path:   util.JoinKey(l.path, k),
+		path:   l.with(k),
 		prefix: true,
 	}
 }
 
+// A validationKey identifies the validation of a particular value against a particular schema within a particular
+// validation context.
+//
+// Values are identified by their identity so that copies of a shared value (e.g. the results of multiple references to
+// the same property) share cached validations. Schemas are identified by their contents so that equivalent schemas that
+// are built separately (e.g. the argument schemas of builtins) share cached validations. Schemas that contain
+// references are identified by address instead, as the meaning of a reference depends on the schema that contains it.
+type validationKey struct {
+	v            *value
+	schema       string
+	ref          *schema.Schema
+	jsonPointers bool
+}
+
+// A validationCache records the validations that have succeeded without issuing any diagnostics. Such a validation
+// does not depend on the location being blamed, and can be reused wherever the same value is validated against an
+// equivalent schema with the same validator settings. Failed validations are never cached so that their errors are
+// issued at each location.
+//
+// A validationCache is shared by the environments in a single evaluation.
+type validationCache struct {
+	fingerprints map[*schema.Schema]string
+	validated    map[validationKey]struct{}
+
+	hits, misses int // the number of lookups that were and were not satisfied by the cache
+}
+
+func newValidationCache() *validationCache {
+	return &validationCache{
+		fingerprints: map[*schema.Schema]string{},
+		validated:    map[validationKey]struct{}{},
+	}
+}
+
+// key returns the key for the validation of v against accept by a validator with the given settings.
+func (c *validationCache) key(v *value, accept *schema.Schema, jsonPointers bool) validationKey {
+	fingerprint, ok := c.fingerprints[accept]
+	if !ok {
+		fingerprint = schemaFingerprint(accept)
+		c.fingerprints[accept] = fingerprint
+	}
+	if fingerprint == "" {
+		return validationKey{v: v.identity(), ref: accept, jsonPointers: jsonPointers}
+	}
+	return validationKey{v: v.identity(), schema: fingerprint, jsonPointers: jsonPointers}
+}
+
+// schemaFingerprint returns the canonical JSON encoding of the given schema, or the empty string if the schema contains
+// references or cannot be encoded.
+func schemaFingerprint(s *schema.Schema) string {
+	bytes, err := json.Marshal(s)
+	if err != nil || strings.Contains(string(bytes), `"$ref"`) {
+		return ""
+	}
+	return string(bytes)
+}
+
 type validator struct {
-	diags syntax.Diagnostics
+	diags        syntax.Diagnostics
+	jsonPointers bool             // true if paths in errors should be rendered as JSON Pointers
+	cache        *validationCache // the successful validations, if any
+}
+
+// nested returns a new validator with the same settings as e. Nested validators are used to validate subschemas whose
+// errors may be discarded or rewritten.
+func (e *validator) nested() validator {
+	return validator{jsonPointers: e.jsonPointers, cache: e.cache}
 }
 
 // errorf issues a validation error at the given location.
 func (e *validator) errorf(loc validationLoc, format string, args ...any) bool {
+	message := fmt.Sprintf(format, args...)
 	if loc.prefix {
-		format = fmt.Sprintf("%s: %s", loc.path, format)
+		message = fmt.Sprintf("%s: %s", loc.render(e.jsonPointers), message)
 	}
-	diag := ast.ExprError(loc.x.repr.syntax(), fmt.Sprintf(format, args...))
+	diag := ast.ExprError(loc.x.repr.syntax(), message)
 	e.diags.Extend(diag)
 	return false
 }
@@ -218,7 +321,7 @@ func (e *validator) validateInputSchemaAnyOf(x, accept *schema.Schema, loc valid
 	var matched bool
 	var allDiags syntax.Diagnostics
 	for _, x := range x.AnyOf {
-		var ee validator
+		ee := e.nested()
 		if ee.validateSchemaType(x, accept, loc) {
 			matched = true
 		}
@@ -241,7 +344,7 @@ func (e *validator) validateInputSchemaOneOf(x, accept *schema.Schema, loc valid
 	var matched bool
 	var allDiags syntax.Diagnostics
 	for _, x := range x.OneOf {
-		var ee validator
+		ee := e.nested()
 		if ee.validateSchemaType(x, accept, loc) {
 			matched = true
 		}
@@ -264,7 +367,7 @@ func (e *validator) validateSchemaAnyOf(x, accept *schema.Schema, loc validation
 	var matched bool
 	var allDiags syntax.Diagnostics
 	for _, accept := range accept.AnyOf {
-		var ee validator
+		ee := e.nested()
 		if ee.validateSchemaType(x, accept, loc) {
 			matched = true
 		}
@@ -290,7 +393,7 @@ func (e *validator) validateSchemaOneOf(x, accept *schema.Schema, loc validation
 	var matched bool
 	var allDiags syntax.Diagnostics
 	for _, accept := range accept.OneOf {
-		var ee validator
+		ee := e.nested()
 		if ee.validateSchemaType(x, accept, loc) {
 			matched = true
 		}
@@ -325,6 +428,14 @@ func (e *validator) validateSchemaArray(x, accept *schema.Schema, loc validation
 		xprefix, aprefix, i = xprefix[1:], aprefix[1:], i+1
 	}
 	if len(xprefix) > 0 {
+		if isNever(accept.Items) {
+			extra := make([]int, len(xprefix))
+			for j := range extra {
+				extra[j] = i + j
+			}
+			return e.extraItemsError(loc, len(accept.PrefixItems), extra)
+		}
+
 		for len(xprefix) > 0 {
 			xp := xprefix[0]
 
@@ -345,12 +456,37 @@ func (e *validator) validateSchemaArray(x, accept *schema.Schema, loc validation
 	}
 
 	if x.Items != nil && !x.Items.Never {
+		if isNever(accept.Items) {
+			return e.errorf(loc, "expected an array with at most %v items", len(accept.PrefixItems))
+		}
+
 		ok := e.validateSchemaType(x.Items, accept.Items, loc)
 		allOk = allOk && ok
 	}
 	return allOk
 }
 
+// isNever returns true if s is the Never schema. An array schema whose items are Never only accepts arrays whose
+// elements are all described by its prefixItems.
+func isNever(s *schema.Schema) bool {
+	return s != nil && s.Never
+}
+
+// extraItemsError issues an error associated with an array that has elements beyond the prefixItems of a schema whose
+// items are Never.
+func (e *validator) extraItemsError(loc validationLoc, max int, indices []int) bool {
+	strs := make([]string, len(indices))
+	for i, index := range indices {
+		strs[i] = strconv.Itoa(index)
+	}
+
+	noun := "index"
+	if len(indices) > 1 {
+		noun = "indices"
+	}
+	return e.errorf(loc, "expected an array with at most %v items; unexpected items at %v %v", max, noun, strings.Join(strs, ", "))
+}
+
 // validateSchemaObject checks that the object-typed schema accept validates the object-typed schema x. In order for
 // accept to validate x:
 //
@@ -362,6 +498,7 @@ func (e *validator) validateSchemaArray(x, accept *schema.Schema, loc validation
 //   - All Required properties in accept must also be Required in x
 //   - For each DependentRequired property P in accept, if P is Required in x, all of P's dependencies must also be
 //     Required in x
+//   - For each DependentSchemas property P in accept, if P is Required in x, P's dependent schema must validate x
 //
 // - If x _does_ have AdditionalProperties, then accept's AdditionalProperties must validate x's AdditionalProperties
 func (e *validator) validateSchemaObject(x, accept *schema.Schema, loc validationLoc) bool {
@@ -369,7 +506,9 @@ func (e *validator) validateSchemaObject(x, accept *schema.Schema, loc validatio
 
 	for name, px := range x.Properties {
 		if pa, ok := accept.Properties[name]; ok {
-			ok := e.validateSchemaType(px, pa, loc.property(name))
+			ok := e.validateLabeled(name, pa, func(ee *validator) bool {
+				return ee.validateSchemaType(px, pa, loc.property(name))
+			})
 			allOk = allOk && ok
 		} else {
 			ok := e.validateSchemaType(px, accept.AdditionalProperties, loc.property(name))
@@ -402,6 +541,14 @@ func (e *validator) validateSchemaObject(x, accept *schema.Schema, loc validatio
 				allOk = allOk && ok
 			}
 		}
+		for _, name := range sortedKeys(accept.DependentSchemas) {
+			if xreq[name] {
+				ok := e.validateDependentSchema(name, func(ee *validator) bool {
+					return ee.validateSchemaType(x, accept.DependentSchemas[name], loc)
+				})
+				allOk = allOk && ok
+			}
+		}
 	} else if !x.AdditionalProperties.Never {
 		ok := e.validateSchemaType(x.AdditionalProperties, accept.AdditionalProperties, loc)
 		allOk = allOk && ok
@@ -410,15 +557,32 @@ func (e *validator) validateSchemaObject(x, accept *schema.Schema, loc validatio
 	return allOk
 }
 
-// validateValue checks that accept validates value.
+// validateValue checks that accept validates value. If the validator has a cache, successful validations are recorded
+// in the cache and are skipped if they are repeated.
 func (e *validator) validateValue(v *value, accept *schema.Schema, loc validationLoc) bool {
-	return e.validateElement(v, accept, validationLoc{x: v.def})
+	if e.cache == nil {
+		return e.validateElement(v, accept, validationLoc{x: v.def})
+	}
+
+	key := e.cache.key(v, accept, e.jsonPointers)
+	if _, ok := e.cache.validated[key]; ok {
+		e.cache.hits++
+		return true
+	}
+	e.cache.misses++
+
+	n := len(e.diags)
+	ok := e.validateElement(v, accept, validationLoc{x: v.def})
+	if ok && len(e.diags) == n {
+		e.cache.validated[key] = struct{}{}
+	}
+	return ok
 }
 
 // validateElement checks that accept validates value.
 func (e *validator) validateElement(v *value, accept *schema.Schema, loc validationLoc) bool {
 	if err := accept.Compile(); err != nil {
-		e.errorf(loc, "internal error: invalid schema: %w", err)
+		e.errorf(loc, "internal error: invalid schema: %v", err)
 		return false
 	}
 
@@ -450,7 +614,7 @@ func (e *validator) validateAnyOf(v *value, accept *schema.Schema, loc validatio
 	var matched bool
 	var allDiags syntax.Diagnostics
 	for _, accept := range accept.AnyOf {
-		var ee validator
+		ee := e.nested()
 		if ee.validateElement(v, accept, loc) {
 			matched = true
 		}
@@ -473,7 +637,7 @@ func (e *validator) validateOneOf(v *value, accept *schema.Schema, loc validatio
 	var matched *validator
 	var allDiags syntax.Diagnostics
 	for _, accept := range accept.OneOf {
-		var ee validator
+		ee := e.nested()
 		if ee.validateElement(v, accept, loc) {
 			if matched != nil {
 				e.errorf(loc, "exactly one subschema may match")
@@ -496,7 +660,58 @@ func (e *validator) validateConst(v *value, accept *schema.Schema, loc validatio
 	if accept.Const == nil || e.equalsConst(v, accept.Const) {
 		return true
 	}
-	return e.constError(loc, accept.Const)
+	return e.constMismatch(v, accept.Const, loc)
+}
+
+// constMismatch issues an error for a value that is not equal to the constant c. If the value and the constant are both
+// arrays or both objects, the error is issued at the first differing element or property rather than at the value
+// itself. This avoids rendering large constants in their entirety.
+func (e *validator) constMismatch(v *value, c any, loc validationLoc) bool {
+	switch c := c.(type) {
+	case []any:
+		a, ok := v.repr.([]*value)
+		if !ok {
+			break
+		}
+		if len(a) != len(c) {
+			return e.errorf(loc, "expected %v elements, got %v", len(c), len(a))
+		}
+		for i, c := range c {
+			if !e.equalsConst(a[i], c) {
+				return e.constMismatch(a[i], c, loc.index(i))
+			}
+		}
+	case map[string]any:
+		m, ok := v.repr.(map[string]*value)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(c)+len(m))
+		for k := range c {
+			keys = append(keys, k)
+		}
+		for k := range m {
+			if _, ok := c[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			c, cok := c[k]
+			v, vok := m[k]
+			switch {
+			case !vok:
+				return e.errorf(loc, "missing property %q", k)
+			case !cok:
+				return e.errorf(loc.property(k), "unexpected property")
+			case !e.equalsConst(v, c):
+				return e.constMismatch(v, c, loc.property(k))
+			}
+		}
+	}
+	return e.constError(loc, c)
 }
 
 // validateEnum checks that accept's Enum validates value.
@@ -509,6 +724,11 @@ func (e *validator) validateEnum(v *value, accept *schema.Schema, loc validation
 			return true
 		}
 	}
+	if s, ok := v.repr.(string); ok && accept.EnumCaseInsensitive {
+		if _, ok := foldEnum(s, accept.Enum); ok {
+			return true
+		}
+	}
 	return e.enumError(loc, accept.Enum)
 }
 
@@ -588,13 +808,17 @@ func (e *validator) validateNumber(v json.Number, accept *schema.Schema, loc val
 }
 
 // validateString checks that accept's string-specific clauses validate v.
+//
+// As in JSON Schema, the length of a string is the number of Unicode code points it contains rather than the number of
+// bytes in its UTF-8 encoding.
 func (e *validator) validateString(v string, accept *schema.Schema, loc validationLoc) bool {
 	ok := true
-	if m := accept.GetMinLength(); m != nil && uint(len(v)) < *m {
+	length := uint(utf8.RuneCountInString(v))
+	if m := accept.GetMinLength(); m != nil && length < *m {
 		e.errorf(loc, "expected a string of at least length %v", accept.MinLength)
 		ok = false
 	}
-	if m := accept.GetMaxLength(); m != nil && uint(len(v)) > *m {
+	if m := accept.GetMaxLength(); m != nil && length > *m {
 		e.errorf(loc, "expected a string of at most length %v", accept.MaxLength)
 		ok = false
 	}
@@ -602,9 +826,36 @@ func (e *validator) validateString(v string, accept *schema.Schema, loc validati
 		e.errorf(loc, "string must match the pattern %q", p.String())
 		ok = false
 	}
+	if !e.validateStringContent(v, accept, loc) {
+		ok = false
+	}
 	return ok
 }
 
+// validateStringContent checks that the content of v matches accept's contentEncoding and contentMediaType clauses.
+// Only the base64 encoding and the application/json media type are checked; other encodings and media types are
+// accepted as-is. If the string is base64-encoded, its media type applies to the decoded content.
+func (e *validator) validateStringContent(v string, accept *schema.Schema, loc validationLoc) bool {
+	content := []byte(v)
+	if strings.EqualFold(accept.ContentEncoding, "base64") {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			e.errorf(loc, "expected a base64-encoded string: %v", err)
+			return false
+		}
+		content = decoded
+	}
+
+	if accept.ContentMediaType != "" {
+		mediaType, _, err := mime.ParseMediaType(accept.ContentMediaType)
+		if err == nil && mediaType == "application/json" && !json.Valid(content) {
+			e.errorf(loc, "expected a string containing JSON")
+			return false
+		}
+	}
+	return true
+}
+
 // validateString checks that accept's array-specific clauses validate v.
 func (e *validator) validateArray(v []*value, accept *schema.Schema, loc validationLoc) bool {
 	ok := true
@@ -617,7 +868,25 @@ func (e *validator) validateArray(v []*value, accept *schema.Schema, loc validat
 		ok = false
 	}
 
-	for i, v := range v {
+	if len(accept.PrefixItems) == 0 && accept.Items.Compile() == nil && isScalarSchema(accept.Items) {
+		return e.validateScalarItems(v, accept.Items) && ok
+	}
+	return e.validateItems(v, accept, loc) && ok
+}
+
+// validateItems checks that accept's prefixItems and items clauses validate the elements of an array.
+func (e *validator) validateItems(elements []*value, accept *schema.Schema, loc validationLoc) bool {
+	ok := true
+	if isNever(accept.Items) && len(elements) > len(accept.PrefixItems) {
+		extra := make([]int, len(elements)-len(accept.PrefixItems))
+		for i := range extra {
+			extra[i] = len(accept.PrefixItems) + i
+		}
+		ok = e.extraItemsError(loc, len(accept.PrefixItems), extra)
+		elements = elements[:len(accept.PrefixItems)]
+	}
+
+	for i, v := range elements {
 		vloc := loc.index(i)
 		if i < len(accept.PrefixItems) {
 			if !e.validateValue(v, accept.PrefixItems[i], vloc) {
@@ -630,32 +899,118 @@ func (e *validator) validateArray(v []*value, accept *schema.Schema, loc validat
 	return ok
 }
 
-// validateString checks that accept's object-specific clauses validate v.
+// isScalarSchema returns true if s is a simple scalar schema, i.e. a schema for a scalar type that has no reference,
+// composition, const, or enum keywords. Values of the schema's type only need to be checked against its type-specific
+// keywords.
+func isScalarSchema(s *schema.Schema) bool {
+	if s == nil || s.Always || s.Never || s.GetRef() != nil || len(s.AnyOf) != 0 || len(s.OneOf) != 0 ||
+		s.Const != nil || len(s.Enum) != 0 {
+		return false
+	}
+	switch s.Type {
+	case "null", "boolean", "number", "string":
+		return true
+	default:
+		return false
+	}
+}
+
+// validateScalarItems checks that the simple scalar schema accept validates each element of an array. This is a fast
+// path for validateArray that avoids the full validateValue recursion for elements of the expected type. Other elements
+// (e.g. unknown values or values of the wrong type) are validated using validateValue.
+func (e *validator) validateScalarItems(elements []*value, accept *schema.Schema) bool {
+	ok := true
+	for _, v := range elements {
+		loc := validationLoc{x: v.def}
+
+		fast, eok := false, false
+		if !v.unknown {
+			switch repr := v.repr.(type) {
+			case nil:
+				fast, eok = accept.Type == "null", true
+			case bool:
+				fast, eok = accept.Type == "boolean", true
+			case json.Number:
+				if fast = accept.Type == "number"; fast {
+					eok = e.validateNumber(repr, accept, loc)
+				}
+			case string:
+				if fast = accept.Type == "string"; fast {
+					eok = e.validateString(repr, accept, loc)
+				}
+			}
+		}
+		if !fast {
+			eok = e.validateValue(v, accept, loc)
+		}
+		ok = eok && ok
+	}
+	return ok
+}
+
+// validateAdditionalProperty checks that the additionalProperties schema accept validates the value v of the property
+// k. Errors are prefixed with the name of the property to make it clear that they stem from the additionalProperties
+// constraint rather than from a declared property.
+func (e *validator) validateAdditionalProperty(k string, v *value, accept *schema.Schema, loc validationLoc) bool {
+	additional := e.nested()
+	ok := additional.validateValue(v, accept, loc)
+	for _, d := range additional.diags {
+		d.Summary = fmt.Sprintf("additional property %q: %s", k, d.Summary)
+	}
+	e.diags.Extend(additional.diags...)
+	return ok
+}
+
+// validateLabeled runs validate against the properties schema accept of the property k. If accept has a title (or,
+// failing that, a description), errors are prefixed with the name of the property and its title to give them context,
+// e.g. `roleArn (the IAM role to assume): expected string, got number`.
+func (e *validator) validateLabeled(k string, accept *schema.Schema, validate func(ee *validator) bool) bool {
+	label := accept.Title
+	if label == "" {
+		label = accept.Description
+	}
+	if label == "" {
+		return validate(e)
+	}
+
+	property := e.nested()
+	ok := validate(&property)
+	for _, d := range property.diags {
+		d.Summary = fmt.Sprintf("%v (%v): %s", k, label, d.Summary)
+	}
+	e.diags.Extend(property.diags...)
+	return ok
+}
+
+// validateObject checks that accept's object-specific clauses validate v.
 func (e *validator) validateObject(v *value, accept *schema.Schema, loc validationLoc) bool {
 	keys := v.keys()
 
 	ok := true
 	if m := accept.GetMinProperties(); m != nil && uint(len(keys)) < *m {
-		e.errorf(loc, "expected an object with at least %v properties", accept.MinProperties)
+		e.errorf(loc, "expected an object with at least %v properties, but it has %v", accept.MinProperties, len(keys))
 		ok = false
 	}
 	if m := accept.GetMaxProperties(); m != nil && uint(len(keys)) > *m {
-		e.errorf(loc, "expected an object with at most %v properties", accept.MaxProperties)
+		e.errorf(loc, "expected an object with at most %v properties, but it has %v", accept.MaxProperties, len(keys))
 		ok = false
 	}
 
 	keySet := make(map[string]struct{}, len(keys))
 	for _, k := range keys {
-		keySet[k] = struct{}{}
-
 		kv := v.property(nil, k)
 		vloc := loc.property(k)
 
+		// If the schema requires non-null properties, null-valued properties do not count as present.
+		if !accept.RequiredNonNull || kv.containsUnknowns() || kv.repr != nil {
+			keySet[k] = struct{}{}
+		}
+
 		if p, has := accept.Properties[k]; has {
-			if !e.validateValue(kv, p, vloc) {
+			if !e.validateLabeled(k, p, func(ee *validator) bool { return ee.validateValue(kv, p, vloc) }) {
 				ok = false
 			}
-		} else if !e.validateValue(kv, accept.AdditionalProperties, vloc) {
+		} else if !e.validateAdditionalProperty(k, kv, accept.AdditionalProperties, vloc) {
 			ok = false
 		}
 	}
@@ -680,5 +1035,34 @@ func (e *validator) validateObject(v *value, accept *schema.Schema, loc validati
 		ok = false
 	}
 
+	// If a property with a dependent schema is present, the entire object must also validate against that schema.
+	for _, k := range sortedKeys(accept.DependentSchemas) {
+		if _, has := keySet[k]; has {
+			dependent := accept.DependentSchemas[k]
+			if !e.validateDependentSchema(k, func(ee *validator) bool { return ee.validateElement(v, dependent, loc) }) {
+				ok = false
+			}
+		}
+	}
+
+	return ok
+}
+
+// validateDependentSchema runs validate against the dependent schema of the property k. Errors are prefixed
+// with the name of the property that triggered the dependent schema.
+func (e *validator) validateDependentSchema(k string, validate func(ee *validator) bool) bool {
+	dependent := e.nested()
+	ok := validate(&dependent)
+	for _, d := range dependent.diags {
+		d.Summary = fmt.Sprintf("dependent schema for %q: %s", k, d.Summary)
+	}
+	e.diags.Extend(dependent.diags...)
 	return ok
 }
+
+// sortedKeys returns the keys of the given schema map in lexical order.
+func sortedKeys(m map[string]*schema.Schema) []string {
+	keys := maps.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
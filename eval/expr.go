@@ -16,11 +16,14 @@ package eval
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/pulumi/esc"
 	"github.com/pulumi/esc/ast"
 	"github.com/pulumi/esc/schema"
+	"github.com/pulumi/esc/syntax"
+	"golang.org/x/exp/maps"
 )
 
 const (
@@ -74,6 +77,28 @@ func (x *expr) defRange(environment string) esc.Range {
 	return convertRange(x.repr.syntax().Syntax().Syntax().Range(), environment)
 }
 
+// provenance returns the expression that ultimately defined the value of x. Property accesses are followed to the
+// expressions they resolved to. If the access was not resolved or refers to the expression itself, provenance returns
+// x.
+func (x *expr) provenance() *expr {
+	seen := map[*expr]bool{}
+	for !seen[x] {
+		seen[x] = true
+
+		symbol, ok := x.repr.(*symbolExpr)
+		if !ok {
+			break
+		}
+		accessors := symbol.property.accessors
+		resolved := accessors[len(accessors)-1].value
+		if resolved == nil || resolved.def == nil {
+			break
+		}
+		x = resolved.def
+	}
+	return x
+}
+
 func exportAccessor(accessor ast.PropertyAccessor, environment string) esc.Accessor {
 	switch a := accessor.(type) {
 	case *ast.PropertyName:
@@ -89,11 +114,87 @@ func exportAccessor(accessor ast.PropertyAccessor, environment string) esc.Acces
 	panic(fmt.Errorf("invalid property accessor %#v", accessor))
 }
 
+// exportOptions controls the serialization of exprs.
+type exportOptions struct {
+	argValues   bool // if true, the evaluated values of builtin arguments are attached to builtin exprs
+	showSecrets bool // if true, secret argument values are not redacted
+	provenance  bool // if true, the ranges of the exprs that defined the values of leaf exprs are attached
+}
+
+// argValue returns the exported value of a builtin argument. If argument values are not being exported or the
+// argument has not been evaluated, argValue returns nil.
+func (opts exportOptions) argValue(environment string, x *expr) *esc.Value {
+	if !opts.argValues || x == nil || x.value == nil {
+		return nil
+	}
+	v := x.value.export(environment)
+	if !opts.showSecrets {
+		v = redactSecrets(v)
+	}
+	return &v
+}
+
+// argValueList returns the exported value of a builtin argument that is syntactically a list of the given exprs.
+func (opts exportOptions) argValueList(environment string, xs ...*expr) *esc.Value {
+	elements := make([]esc.Value, len(xs))
+	for i, x := range xs {
+		v := opts.argValue(environment, x)
+		if v == nil {
+			return nil
+		}
+		elements[i] = *v
+	}
+	v := esc.NewValue(elements)
+	return &v
+}
+
+// argValueObject returns the exported value of a builtin argument that is syntactically an object of the given exprs.
+func (opts exportOptions) argValueObject(environment string, xs map[string]*expr) *esc.Value {
+	properties := make(map[string]esc.Value, len(xs))
+	for k, x := range xs {
+		v := opts.argValue(environment, x)
+		if v == nil {
+			return nil
+		}
+		properties[k] = *v
+	}
+	v := esc.NewValue(properties)
+	return &v
+}
+
+// redactSecrets returns a copy of v with the contents of any secret values replaced with "[secret]".
+func redactSecrets(v esc.Value) esc.Value {
+	if v.Secret {
+		return esc.Value{Value: "[secret]", Secret: true, Unknown: v.Unknown, Trace: v.Trace}
+	}
+
+	switch repr := v.Value.(type) {
+	case []esc.Value:
+		a := make([]esc.Value, len(repr))
+		for i, e := range repr {
+			a[i] = redactSecrets(e)
+		}
+		v.Value = a
+	case map[string]esc.Value:
+		m := make(map[string]esc.Value, len(repr))
+		for k, e := range repr {
+			m[k] = redactSecrets(e)
+		}
+		v.Value = m
+	}
+	return v
+}
+
 // export transforms an expr into its exported, serializable representation.
 func (x *expr) export(environment string) esc.Expr {
+	return x.exportWithOptions(environment, exportOptions{})
+}
+
+// exportWithOptions transforms an expr into its exported, serializable representation using the given options.
+func (x *expr) exportWithOptions(environment string, opts exportOptions) esc.Expr {
 	var base *esc.Expr
 	if x.base != nil {
-		b := x.base.def.export(environment)
+		b := x.base.def.exportWithOptions(environment, opts)
 		base = &b
 	}
 
@@ -120,13 +221,7 @@ func (x *expr) export(environment string) esc.Expr {
 		for i, p := range repr.parts {
 			var value []esc.PropertyAccessor
 			if p.value != nil {
-				value = make([]esc.PropertyAccessor, len(p.value.accessors))
-				for i, a := range p.value.accessors {
-					value[i] = esc.PropertyAccessor{
-						Accessor: exportAccessor(a.accessor, environment),
-						Value:    a.value.def.defRange(environment),
-					}
-				}
+				value = p.value.export(environment)
 			}
 			interp[i] = esc.Interpolation{
 				Text:  p.syntax.Text,
@@ -135,18 +230,11 @@ func (x *expr) export(environment string) esc.Expr {
 		}
 		ex.Interpolate = interp
 	case *symbolExpr:
-		value := make([]esc.PropertyAccessor, len(repr.property.accessors))
-		for i, a := range repr.property.accessors {
-			value[i] = esc.PropertyAccessor{
-				Accessor: exportAccessor(a.accessor, environment),
-				Value:    a.value.def.defRange(environment),
-			}
-		}
-		ex.Symbol = value
+		ex.Symbol = repr.property.export(environment)
 	case *accessExpr:
 		accessor := exportAccessor(repr.accessor, environment)
 		if _, ok := repr.receiver.def.repr.(*accessExpr); ok {
-			ex = repr.receiver.def.export(environment)
+			ex = repr.receiver.def.exportWithOptions(environment, opts)
 			ex.Access.Accessors = append(ex.Access.Accessors, accessor)
 		} else {
 			ex.Access = &esc.AccessExpr{
@@ -154,19 +242,193 @@ func (x *expr) export(environment string) esc.Expr {
 				Accessors: []esc.Accessor{accessor},
 			}
 		}
+	case *arithmeticExpr:
+		argRange := convertRange(repr.node.Args().Syntax().Syntax().Range(), environment)
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Tuple(schema.Number(), schema.Number()).Schema(),
+			Arg: esc.Expr{
+				Range: argRange,
+				List:  []esc.Expr{repr.left.exportWithOptions(environment, opts), repr.right.exportWithOptions(environment, opts)},
+			},
+			ArgValue: opts.argValueList(environment, repr.left, repr.right),
+		}
+	case *clampExpr:
+		args := map[string]*expr{"value": repr.value, "min": repr.min, "max": repr.max}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"value": schema.Number().Schema(),
+				"min":   schema.Number().Schema(),
+				"max":   schema.Number().Schema(),
+			}).Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *repeatExpr:
+		args := map[string]*expr{"value": repr.value, "count": repr.count}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"value": schema.Always().Schema(),
+				"count": schema.Number().Schema(),
+			}).Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *setExpr:
+		list := make([]esc.Expr, len(repr.operands))
+		for i, operand := range repr.operands {
+			list[i] = operand.exportWithOptions(environment, opts)
+		}
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Array().Items(schema.Array().Items(schema.Always())).Schema(),
+			Arg: esc.Expr{
+				Range: convertRange(repr.node.Args().Syntax().Syntax().Range(), environment),
+				List:  list,
+			},
+			ArgValue: opts.argValueList(environment, repr.operands...),
+		}
+	case *andExpr:
+		ex.Builtin = exportLogical(environment, opts, repr.node, repr.operands)
+	case *orExpr:
+		ex.Builtin = exportLogical(environment, opts, repr.node, repr.operands)
+	case *notExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Boolean().Schema(),
+			Arg:       repr.operand.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.operand),
+		}
+	case *semverCompareExpr:
+		argRange := convertRange(repr.node.Args().Syntax().Syntax().Range(), environment)
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Tuple(schema.String(), schema.String()).Schema(),
+			Arg: esc.Expr{
+				Range: argRange,
+				List:  []esc.Expr{repr.left.exportWithOptions(environment, opts), repr.right.exportWithOptions(environment, opts)},
+			},
+			ArgValue: opts.argValueList(environment, repr.left, repr.right),
+		}
+	case *equalsExpr:
+		argRange := convertRange(repr.node.Args().Syntax().Syntax().Range(), environment)
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Tuple(schema.Always(), schema.Always()).Schema(),
+			Arg: esc.Expr{
+				Range: argRange,
+				List:  []esc.Expr{repr.left.exportWithOptions(environment, opts), repr.right.exportWithOptions(environment, opts)},
+			},
+			ArgValue: opts.argValueList(environment, repr.left, repr.right),
+		}
+	case *objectDiffExpr:
+		args := map[string]*expr{"a": repr.a, "b": repr.b}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		objectSchema := schema.Object().AdditionalProperties(schema.Always())
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.BuilderMap{
+				"a": objectSchema,
+				"b": objectSchema,
+			}).Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *objectFromKeysExpr:
+		args := map[string]*expr{"keys": repr.keys}
+		if repr.value != nil {
+			args["value"] = repr.value
+		}
+		if repr.values != nil {
+			args["values"] = repr.values
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"keys":   objectFromKeysSchema,
+				"value":  schema.Always().Schema(),
+				"values": schema.Array().Items(schema.Always()).Schema(),
+			}).Required("keys").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *capitalizeExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.string.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.string),
+		}
+	case *titleExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.string.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.string),
+		}
+	case *changeCaseExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.string.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.string),
+		}
 	case *fromBase64Expr:
 		ex.Builtin = &esc.BuiltinExpr{
 			Name:      repr.node.Name().Value,
 			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
 			ArgSchema: schema.String().Schema(),
-			Arg:       repr.string.export(environment),
+			Arg:       repr.string.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.string),
+		}
+	case *fromHexExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.string.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.string),
 		}
 	case *fromJSONExpr:
 		ex.Builtin = &esc.BuiltinExpr{
 			Name:      repr.node.Name().Value,
 			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
 			ArgSchema: schema.Always().Schema(),
-			Arg:       repr.string.export(environment),
+			Arg:       repr.string.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.string),
 		}
 	case *joinExpr:
 		argRange := convertRange(repr.node.Args().Syntax().Syntax().Range(), environment)
@@ -176,8 +438,373 @@ func (x *expr) export(environment string) esc.Expr {
 			ArgSchema: schema.Tuple(schema.String(), schema.Array().Items(schema.String())).Schema(),
 			Arg: esc.Expr{
 				Range: argRange,
-				List:  []esc.Expr{repr.delimiter.export(environment), repr.values.export(environment)},
+				List:  []esc.Expr{repr.delimiter.exportWithOptions(environment, opts), repr.values.exportWithOptions(environment, opts)},
+			},
+			ArgValue: opts.argValueList(environment, repr.delimiter, repr.values),
+		}
+	case *letExpr:
+		bindings := make(map[string]esc.Expr, len(repr.bindings))
+		for _, b := range repr.bindings {
+			bindings[b.name] = b.expr.exportWithOptions(environment, opts)
+		}
+
+		// The argument's value is not exported, as bindings are only evaluated if they are used.
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"bindings": schema.Object().AdditionalProperties(schema.Always()).Schema(),
+				"in":       schema.Always().Schema(),
+			}).Schema(),
+			Arg: esc.Expr{Object: map[string]esc.Expr{
+				"bindings": {Object: bindings},
+				"in":       repr.in.exportWithOptions(environment, opts),
+			}},
+		}
+	case *mapExpr:
+		ex.Builtin = exportTemplateBuiltin(environment, opts, repr.node.Name(), repr.node.As,
+			map[string]*expr{"items": repr.items, "each": repr.each},
+			schema.SchemaMap{"items": templateItemsSchema, "each": schema.Always().Schema()})
+	case *filterExpr:
+		ex.Builtin = exportTemplateBuiltin(environment, opts, repr.node.Name(), repr.node.As,
+			map[string]*expr{"items": repr.items, "where": repr.where},
+			schema.SchemaMap{"items": templateItemsSchema, "where": schema.Boolean().Schema()})
+	case *reduceExpr:
+		ex.Builtin = exportTemplateBuiltin(environment, opts, repr.node.Name(), repr.node.As,
+			map[string]*expr{"items": repr.items, "initial": repr.initial, "reducer": repr.reducer},
+			schema.SchemaMap{
+				"items":   templateItemsSchema,
+				"initial": schema.Always().Schema(),
+				"reducer": schema.Always().Schema(),
+			})
+	case *switchExpr:
+		cases := make([]esc.Expr, len(repr.cases))
+		for i, c := range repr.cases {
+			cases[i] = esc.Expr{Object: map[string]esc.Expr{
+				"when": c.when.exportWithOptions(environment, opts),
+				"then": c.then.exportWithOptions(environment, opts),
+			}}
+		}
+		arg := map[string]esc.Expr{
+			"value": repr.value.exportWithOptions(environment, opts),
+			"cases": {List: cases},
+		}
+		if repr.defaultValue != nil {
+			arg["default"] = repr.defaultValue.exportWithOptions(environment, opts)
+		}
+
+		// The argument's value is not exported, as only the selected case is evaluated.
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"value": schema.Always().Schema(),
+				"cases": schema.Array().Items(schema.Record(schema.SchemaMap{
+					"when": schema.Always().Schema(),
+					"then": schema.Always().Schema(),
+				})).Schema(),
+				"default": schema.Always().Schema(),
+			}).Required("value", "cases").Schema(),
+			Arg: esc.Expr{Object: arg},
+		}
+	case *atPathExpr:
+		args := map[string]*expr{"value": repr.value, "path": repr.path}
+		if repr.strict != nil {
+			args["strict"] = repr.strict
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"value":  schema.Always().Schema(),
+				"path":   atPathSchema,
+				"strict": schema.Boolean().Schema(),
+			}).Required("value", "path").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *getOrExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"from":    schema.Always().Schema(),
+				"path":    schema.String().Schema(),
+				"default": schema.Always().Schema(),
+			}).Schema(),
+			Arg: esc.Expr{
+				Object: map[string]esc.Expr{
+					"from":    repr.from.exportWithOptions(environment, opts),
+					"path":    repr.path.exportWithOptions(environment, opts),
+					"default": repr.defaultValue.exportWithOptions(environment, opts),
+				},
+			},
+			ArgValue: opts.argValueObject(environment, map[string]*expr{
+				"from":    repr.from,
+				"path":    repr.path,
+				"default": repr.defaultValue,
+			}),
+		}
+	case *groupByExpr:
+		args := map[string]*expr{"items": repr.items, "key": repr.key}
+		if repr.strict != nil {
+			args["strict"] = repr.strict
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"items":  groupBySchema,
+				"key":    schema.String().Schema(),
+				"strict": schema.Boolean().Schema(),
+			}).Required("items", "key").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *maskExpr:
+		args := map[string]*expr{"string": repr.string, "visible": repr.visible}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		// The string is always treated as a secret, even if its value is not.
+		argValue := opts.argValueObject(environment, args)
+		if argValue != nil {
+			properties := argValue.Value.(map[string]esc.Value)
+			str := properties["string"]
+			str.Secret = true
+			if !opts.showSecrets {
+				str = redactSecrets(str)
+			}
+			properties["string"] = str
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"string":  schema.String().Schema(),
+				"visible": schema.Number().Schema(),
+			}).Required("string", "visible").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: argValue,
+		}
+	case *nearestExpr:
+		args := map[string]*expr{"value": repr.value, "options": repr.options}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"value":   schema.String().Schema(),
+				"options": schema.Array().Items(schema.String()).Schema(),
+			}).Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *padExpr:
+		args := map[string]*expr{"string": repr.string, "length": repr.length}
+		if repr.pad != nil {
+			args["pad"] = repr.pad
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"string": schema.String().Schema(),
+				"length": schema.Number().Schema(),
+				"pad":    schema.String().Schema(),
+			}).Required("length", "string").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *parseArnExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.arn.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.arn),
+		}
+	case *parseDurationExpr:
+		args := map[string]*expr{"duration": repr.duration}
+		if repr.unit != nil {
+			args["unit"] = repr.unit
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"duration": schema.String().Schema(),
+				"unit":     durationUnitSchema,
+			}).Required("duration").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *formatTimeExpr:
+		args := map[string]*expr{"time": repr.time, "inputLayout": repr.inputLayout, "outputLayout": repr.outputLayout}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"time":         schema.String().Schema(),
+				"inputLayout":  timeLayoutSchema,
+				"outputLayout": timeLayoutSchema,
+			}).Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *parseSizeExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.size.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.size),
+		}
+	case *requireExpr:
+		args := map[string]*expr{"value": repr.value, "message": repr.message}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"value":   schema.Always().Schema(),
+				"message": schema.String().Schema(),
+			}).Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *sampleExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Array().Items(schema.Always()).Schema(),
+			Arg:       repr.array.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.array),
+		}
+	case *randomStringExpr:
+		args := map[string]*expr{"length": repr.length}
+		if repr.charset != nil {
+			args["charset"] = repr.charset
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"length":  schema.Number().Schema(),
+				"charset": schema.String().Schema(),
+			}).Required("length").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *hmacExpr:
+		args := map[string]*expr{"algorithm": repr.algorithm, "key": repr.key, "message": repr.message}
+		if repr.encoding != nil {
+			args["encoding"] = repr.encoding
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		// The key is always treated as a secret, even if its value is not.
+		argValue := opts.argValueObject(environment, args)
+		if argValue != nil {
+			properties := argValue.Value.(map[string]esc.Value)
+			key := properties["key"]
+			key.Secret = true
+			if !opts.showSecrets {
+				key = redactSecrets(key)
+			}
+			properties["key"] = key
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"algorithm": hmacAlgorithmSchema,
+				"key":       schema.String().Schema(),
+				"message":   schema.String().Schema(),
+				"encoding":  hmacEncodingSchema,
+			}).Required("algorithm", "key", "message").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: argValue,
+		}
+	case *importRawExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.path.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.path),
+		}
+	case *importExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Record(schema.SchemaMap{
+				"env":  schema.String().Schema(),
+				"path": schema.String().Schema(),
+			}).Schema(),
+			Arg: esc.Expr{
+				Object: map[string]esc.Expr{
+					"env":  repr.environment.exportWithOptions(environment, opts),
+					"path": repr.path.exportWithOptions(environment, opts),
+				},
 			},
+			ArgValue: opts.argValueObject(environment, map[string]*expr{
+				"env":  repr.environment,
+				"path": repr.path,
+			}),
+		}
+	case *jwtDecodeExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.token.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.token),
 		}
 	case *openExpr:
 		name := repr.node.Name().Value
@@ -191,28 +818,34 @@ func (x *expr) export(environment string) esc.Expr {
 				}).Schema(),
 				Arg: esc.Expr{
 					Object: map[string]esc.Expr{
-						"provider": repr.provider.export(environment),
-						"inputs":   repr.inputs.export(environment),
+						"provider": repr.provider.exportWithOptions(environment, opts),
+						"inputs":   repr.inputs.exportWithOptions(environment, opts),
 					},
 				},
+				ArgValue: opts.argValueObject(environment, map[string]*expr{
+					"provider": repr.provider,
+					"inputs":   repr.inputs,
+				}),
 			}
 		} else {
 			ex.Builtin = &esc.BuiltinExpr{
 				Name:      name,
 				NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
 				ArgSchema: repr.inputSchema,
-				Arg:       repr.inputs.export(environment),
+				Arg:       repr.inputs.exportWithOptions(environment, opts),
+				ArgValue:  opts.argValue(environment, repr.inputs),
 			}
 		}
 	case *secretExpr:
 		var arg esc.Expr
+		var argValue *esc.Value
 		if repr.plaintext != nil {
-			arg = repr.plaintext.export(environment)
+			arg, argValue = repr.plaintext.exportWithOptions(environment, opts), opts.argValue(environment, repr.plaintext)
 		} else {
 			arg = esc.Expr{
 				Range: convertRange(repr.node.Args().Syntax().Syntax().Range(), environment),
 				Object: map[string]esc.Expr{
-					"ciphertext": repr.ciphertext.export(environment),
+					"ciphertext": repr.ciphertext.exportWithOptions(environment, opts),
 				},
 			}
 		}
@@ -221,52 +854,209 @@ func (x *expr) export(environment string) esc.Expr {
 			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
 			ArgSchema: schema.Always().Schema(),
 			Arg:       arg,
+			ArgValue:  argValue,
+		}
+	case *secretIfExpr:
+		args := map[string]*expr{"condition": repr.condition, "value": repr.value}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		// The value is treated as a secret if the result is secret, even if the value itself is not.
+		argValue := opts.argValueObject(environment, args)
+		if argValue != nil && x.value != nil && x.value.secret {
+			properties := argValue.Value.(map[string]esc.Value)
+			v := properties["value"]
+			v.Secret = true
+			if !opts.showSecrets {
+				v = redactSecrets(v)
+			}
+			properties["value"] = v
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"condition": schema.Boolean().Schema(),
+				"value":     schema.Always().Schema(),
+			}).Required("condition", "value").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: argValue,
 		}
 	case *toBase64Expr:
 		ex.Builtin = &esc.BuiltinExpr{
 			Name:      repr.node.Name().Value,
 			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
 			ArgSchema: schema.String().Schema(),
-			Arg:       repr.value.export(environment),
+			Arg:       repr.value.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.value),
+		}
+	case *toHexExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.String().Schema(),
+			Arg:       repr.value.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.value),
 		}
 	case *toJSONExpr:
 		ex.Builtin = &esc.BuiltinExpr{
 			Name:      repr.node.Name().Value,
 			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
 			ArgSchema: schema.Always().Schema(),
-			Arg:       repr.value.export(environment),
+			Arg:       repr.value.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.value),
 		}
-	case *toStringExpr:
+	case *toArrayExpr:
 		ex.Builtin = &esc.BuiltinExpr{
 			Name:      repr.node.Name().Value,
 			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
 			ArgSchema: schema.Always().Schema(),
-			Arg:       repr.value.export(environment),
+			Arg:       repr.value.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.value),
 		}
-	case *arrayExpr:
-		ex.List = make([]esc.Expr, len(repr.elements))
-		for i, el := range repr.elements {
-			ex.List[i] = el.export(environment)
+	case *typeOfExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Always().Schema(),
+			Arg:       repr.value.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.value),
 		}
-	case *objectExpr:
-		ex.KeyRanges = make(map[string]esc.Range, len(repr.node.Entries))
-		for _, kvp := range repr.node.Entries {
-			ex.KeyRanges[kvp.Key.Value] = convertRange(kvp.Key.Syntax().Syntax().Range(), environment)
+	case *isEmptyExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Always().Schema(),
+			Arg:       repr.value.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.value),
+		}
+	case *toStringExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Always().Schema(),
+			Arg:       repr.value.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.value),
+		}
+	case *urlEncodeExpr:
+		ex.Builtin = exportURLBuiltin(environment, opts, repr.node.Name(), repr.value, repr.mode)
+	case *urlDecodeExpr:
+		ex.Builtin = exportURLBuiltin(environment, opts, repr.node.Name(), repr.value, repr.mode)
+	case *encodeQueryExpr:
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: queryValuesSchema,
+			Arg:       repr.values.exportWithOptions(environment, opts),
+			ArgValue:  opts.argValue(environment, repr.values),
+		}
+	case *urlJoinExpr:
+		args := map[string]*expr{"base": repr.base, "segments": repr.segments}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
 		}
 
-		ex.Object = make(map[string]esc.Expr, len(repr.properties))
-		for k, v := range repr.properties {
-			ex.Object[k] = v.export(environment)
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"base":     schema.String().Schema(),
+				"segments": schema.Array().Items(schema.String()).Schema(),
+			}).Required("base", "segments").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *zipExpr:
+		args := map[string]*expr{"arrays": repr.arrays}
+		if repr.pad != nil {
+			args["pad"] = repr.pad
+		}
+		arg := make(map[string]esc.Expr, len(args))
+		for k, x := range args {
+			arg[k] = x.exportWithOptions(environment, opts)
+		}
+
+		ex.Builtin = &esc.BuiltinExpr{
+			Name:      repr.node.Name().Value,
+			NameRange: convertRange(repr.node.Name().Syntax().Syntax().Range(), environment),
+			ArgSchema: schema.Object().Properties(schema.SchemaMap{
+				"arrays": zipSchema,
+				"pad":    schema.Boolean().Schema(),
+			}).Required("arrays").Schema(),
+			Arg:      esc.Expr{Object: arg},
+			ArgValue: opts.argValueObject(environment, args),
+		}
+	case *arrayExpr:
+		ex.List = make([]esc.Expr, len(repr.elements))
+		for i, el := range repr.elements {
+			ex.List[i] = el.exportWithOptions(environment, opts)
+		}
+	case *objectExpr:
+		// Key ranges are recorded for each key's first definition so that consumers can recover the source order of the
+		// object's properties (see esc.Expr.ObjectKeys).
+		ex.KeyRanges = make(map[string]esc.Range, len(repr.keys))
+		ex.Object = make(map[string]esc.Expr, len(repr.keys))
+		for _, k := range repr.keys {
+			ex.KeyRanges[k.Value] = convertRange(k.Syntax().Syntax().Range(), environment)
+			ex.Object[k.Value] = repr.properties[k.Value].exportWithOptions(environment, opts)
+			if comment := syntax.HeadCommentText(k.Syntax().Syntax()); comment != "" {
+				if ex.KeyComments == nil {
+					ex.KeyComments = map[string]string{}
+				}
+				ex.KeyComments[k.Value] = comment
+			}
 		}
 	default:
 		panic(fmt.Sprintf("fatal: invalid expr type %T", repr))
 	}
 
+	if opts.provenance && ex.List == nil && ex.Object == nil {
+		r := x.provenance().defRange(environment)
+		ex.Provenance = &r
+	}
+
 	return ex
 }
 
 type propertyAccess struct {
+	scope     *scope // the scope in which the access was declared
 	accessors []*propertyAccessor
+	invalid   bool // true if the access was found to be invalid prior to evaluation
+}
+
+// A scope binds a name to a value within the template of a call to fn::map or the body of a call to fn::let. Scopes
+// are lexical: each property access records the scope in which it was declared, and a name is resolved by searching
+// that scope and its parents before searching the environment's top-level properties.
+//
+// A name is bound either to a value or to an expression. Expressions are evaluated when the name is first accessed.
+type scope struct {
+	parent *scope
+	name   string
+	value  *value
+	expr   *expr
+}
+
+// lookup returns the innermost scope that binds the given name, if any.
+func (s *scope) lookup(name string) (*scope, bool) {
+	for ; s != nil; s = s.parent {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// names returns the names bound by the scope and its parents.
+func (s *scope) names() []string {
+	var names []string
+	for ; s != nil; s = s.parent {
+		names = append(names, s.name)
+	}
+	return names
 }
 
 type propertyAccessor struct {
@@ -274,6 +1064,20 @@ type propertyAccessor struct {
 	value    *value
 }
 
+// export exports the accessors of a property access. Accessors that have not been evaluated (e.g. because they are
+// part of a short-circuited argument to fn::and or fn::or or the default of a call to fn::getOr whose path resolved)
+// have no value range.
+func (p *propertyAccess) export(environment string) []esc.PropertyAccessor {
+	accessors := make([]esc.PropertyAccessor, len(p.accessors))
+	for i, a := range p.accessors {
+		accessors[i] = esc.PropertyAccessor{Accessor: exportAccessor(a.accessor, environment)}
+		if a.value != nil {
+			accessors[i].Value = a.value.def.defRange(environment)
+		}
+	}
+	return accessors
+}
+
 type interpolation struct {
 	syntax ast.Interpolation
 	value  *propertyAccess
@@ -350,6 +1154,7 @@ func (x *arrayExpr) syntax() ast.Expr {
 type objectExpr struct {
 	node *ast.ObjectExpr
 
+	keys       []*ast.StringExpr // the object's keys in source order. Duplicate keys are omitted.
 	properties map[string]*expr
 }
 
@@ -357,6 +1162,15 @@ func (x *objectExpr) syntax() ast.Expr {
 	return x.node
 }
 
+// propertyKeys returns the names of the object's properties in source order.
+func (x *objectExpr) propertyKeys() []string {
+	keys := make([]string, len(x.keys))
+	for i, k := range x.keys {
+		keys[i] = k.Value
+	}
+	return keys
+}
+
 // openExpr represents a call to the fn::open builtin.
 type openExpr struct {
 	node *ast.OpenExpr
@@ -393,6 +1207,39 @@ func (x *fromJSONExpr) syntax() ast.Expr {
 	return x.node
 }
 
+// toArrayExpr represents a call to the fn::toArray builtin.
+type toArrayExpr struct {
+	node *ast.ToArrayExpr
+
+	value *expr
+}
+
+func (x *toArrayExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// typeOfExpr represents a call to the fn::typeOf builtin.
+type typeOfExpr struct {
+	node *ast.TypeOfExpr
+
+	value *expr
+}
+
+func (x *typeOfExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// isEmptyExpr represents a call to the fn::isEmpty builtin.
+type isEmptyExpr struct {
+	node *ast.IsEmptyExpr
+
+	value *expr
+}
+
+func (x *isEmptyExpr) syntax() ast.Expr {
+	return x.node
+}
+
 // toStringExpr represents a call to the fn::toString builtin.
 type toStringExpr struct {
 	node *ast.ToStringExpr
@@ -404,6 +1251,65 @@ func (x *toStringExpr) syntax() ast.Expr {
 	return x.node
 }
 
+// urlEncodeExpr represents a call to the fn::urlEncode builtin.
+type urlEncodeExpr struct {
+	node *ast.URLEncodeExpr
+
+	value *expr
+	mode  *expr // nil if the mode was omitted
+}
+
+func (x *urlEncodeExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// urlDecodeExpr represents a call to the fn::urlDecode builtin.
+type urlDecodeExpr struct {
+	node *ast.URLDecodeExpr
+
+	value *expr
+	mode  *expr // nil if the mode was omitted
+}
+
+func (x *urlDecodeExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// encodeQueryExpr represents a call to the fn::encodeQuery builtin.
+type encodeQueryExpr struct {
+	node *ast.EncodeQueryExpr
+
+	values *expr
+}
+
+func (x *encodeQueryExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// urlJoinExpr represents a call to the fn::urlJoin builtin.
+type urlJoinExpr struct {
+	node *ast.URLJoinExpr
+
+	base     *expr
+	segments *expr
+}
+
+func (x *urlJoinExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// zipExpr represents a call to the fn::zip builtin.
+type zipExpr struct {
+	node *ast.ZipExpr
+
+	arrays *expr
+	pad    *expr
+}
+
+func (x *zipExpr) syntax() ast.Expr {
+	return x.node
+}
+
 // joinExpr represents a call to the fn::join builtin.
 type joinExpr struct {
 	node *ast.JoinExpr
@@ -416,6 +1322,438 @@ func (x *joinExpr) syntax() ast.Expr {
 	return x.node
 }
 
+// equalsExpr represents a call to the fn::equals builtin.
+type equalsExpr struct {
+	node *ast.EqualsExpr
+
+	left  *expr
+	right *expr
+}
+
+func (x *equalsExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// objectDiffExpr represents a call to the fn::objectDiff builtin.
+type objectDiffExpr struct {
+	node *ast.ObjectDiffExpr
+
+	a *expr
+	b *expr
+}
+
+func (x *objectDiffExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// objectFromKeysExpr represents a call to the fn::objectFromKeys builtin.
+type objectFromKeysExpr struct {
+	node *ast.ObjectFromKeysExpr
+
+	keys   *expr
+	value  *expr
+	values *expr
+}
+
+func (x *objectFromKeysExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// exportLogical exports a call to the fn::and or fn::or builtins. Operands that were skipped due to short-circuiting
+// have no value, so the argument value is omitted if any operand was not evaluated.
+func exportLogical(environment string, opts exportOptions, node ast.BuiltinExpr, operands []*expr) *esc.BuiltinExpr {
+	list := make([]esc.Expr, len(operands))
+	for i, operand := range operands {
+		list[i] = operand.exportWithOptions(environment, opts)
+	}
+	return &esc.BuiltinExpr{
+		Name:      node.Name().Value,
+		NameRange: convertRange(node.Name().Syntax().Syntax().Range(), environment),
+		ArgSchema: schema.Array().Items(schema.Boolean()).Schema(),
+		Arg: esc.Expr{
+			Range: convertRange(node.Args().Syntax().Syntax().Range(), environment),
+			List:  list,
+		},
+		ArgValue: opts.argValueList(environment, operands...),
+	}
+}
+
+// clampExpr represents a call to the fn::clamp builtin.
+type clampExpr struct {
+	node *ast.ClampExpr
+
+	value *expr
+	min   *expr
+	max   *expr
+}
+
+func (x *clampExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// repeatExpr represents a call to the fn::repeat builtin.
+type repeatExpr struct {
+	node *ast.RepeatExpr
+
+	value *expr
+	count *expr
+}
+
+func (x *repeatExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// arithmeticExpr represents a call to the fn::add, fn::sub, fn::mul, or fn::div builtins.
+type arithmeticExpr struct {
+	node *ast.ArithmeticExpr
+
+	left  *expr
+	right *expr
+}
+
+func (x *arithmeticExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// setExpr represents a call to the fn::union, fn::intersection, or fn::difference builtins.
+type setExpr struct {
+	node *ast.SetExpr
+
+	operands []*expr
+}
+
+func (x *setExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// andExpr represents a call to the fn::and builtin.
+type andExpr struct {
+	node *ast.AndExpr
+
+	operands []*expr
+}
+
+func (x *andExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// orExpr represents a call to the fn::or builtin.
+type orExpr struct {
+	node *ast.OrExpr
+
+	operands []*expr
+}
+
+func (x *orExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// notExpr represents a call to the fn::not builtin.
+type notExpr struct {
+	node *ast.NotExpr
+
+	operand *expr
+}
+
+func (x *notExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// semverCompareExpr represents a call to the fn::semverCompare builtin.
+type semverCompareExpr struct {
+	node *ast.SemverCompareExpr
+
+	left  *expr
+	right *expr
+}
+
+func (x *semverCompareExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// letExpr represents a call to the fn::let builtin.
+type letExpr struct {
+	node *ast.LetExpr
+
+	bindings []letBinding
+	in       *expr
+}
+
+// letBinding is a single binding of a call to the fn::let builtin.
+type letBinding struct {
+	name string
+	expr *expr
+}
+
+func (x *letExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// mapExpr represents a call to the fn::map builtin.
+type mapExpr struct {
+	node *ast.MapExpr
+
+	scope *scope // the scope in which the call was declared
+	items *expr
+	each  *expr // the template as declared outside of any element's scope. Only used for export.
+}
+
+func (x *mapExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// filterExpr represents a call to the fn::filter builtin.
+type filterExpr struct {
+	node *ast.FilterExpr
+
+	scope *scope // the scope in which the call was declared
+	items *expr
+	where *expr // the predicate as declared outside of any element's scope. Only used for export.
+}
+
+func (x *filterExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// reduceExpr represents a call to the fn::reduce builtin.
+type reduceExpr struct {
+	node *ast.ReduceExpr
+
+	scope   *scope // the scope in which the call was declared
+	items   *expr
+	initial *expr
+	reducer *expr // the reducer as declared outside of any element's scope. Only used for export.
+}
+
+func (x *reduceExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// switchExpr represents a call to the fn::switch builtin.
+type switchExpr struct {
+	node *ast.SwitchExpr
+
+	value        *expr
+	cases        []switchCase
+	defaultValue *expr // nil if the default was omitted
+}
+
+// switchCase is a single case of a call to the fn::switch builtin.
+type switchCase struct {
+	when *expr
+	then *expr
+}
+
+func (x *switchExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// atPathExpr represents a call to the fn::atPath builtin.
+type atPathExpr struct {
+	node *ast.AtPathExpr
+
+	value  *expr
+	path   *expr
+	strict *expr
+}
+
+func (x *atPathExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// groupByExpr represents a call to the fn::groupBy builtin.
+type groupByExpr struct {
+	node *ast.GroupByExpr
+
+	items  *expr
+	key    *expr
+	strict *expr // nil if strict was omitted
+}
+
+func (x *groupByExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// getOrExpr represents a call to the fn::getOr builtin.
+type getOrExpr struct {
+	node *ast.GetOrExpr
+
+	from         *expr
+	path         *expr
+	defaultValue *expr
+}
+
+func (x *getOrExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// maskExpr represents a call to the fn::mask builtin.
+type maskExpr struct {
+	node *ast.MaskExpr
+
+	string  *expr
+	visible *expr
+}
+
+func (x *maskExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// nearestExpr represents a call to the fn::nearest builtin.
+type nearestExpr struct {
+	node *ast.NearestExpr
+
+	value   *expr
+	options *expr
+}
+
+func (x *nearestExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// padExpr represents a call to the fn::padLeft or fn::padRight builtins.
+type padExpr struct {
+	node *ast.PadExpr
+
+	string *expr
+	length *expr
+	pad    *expr // nil if the pad string was omitted
+}
+
+func (x *padExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// parseArnExpr represents a call to the fn::parseArn builtin.
+type parseArnExpr struct {
+	node *ast.ParseArnExpr
+
+	arn *expr
+}
+
+func (x *parseArnExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// parseDurationExpr represents a call to the fn::parseDuration builtin.
+type parseDurationExpr struct {
+	node *ast.ParseDurationExpr
+
+	duration *expr
+	unit     *expr // nil if the unit was omitted
+}
+
+func (x *parseDurationExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// formatTimeExpr represents a call to the fn::formatTime builtin.
+type formatTimeExpr struct {
+	node *ast.FormatTimeExpr
+
+	time         *expr
+	inputLayout  *expr
+	outputLayout *expr
+}
+
+func (x *formatTimeExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// parseSizeExpr represents a call to the fn::parseSize builtin.
+type parseSizeExpr struct {
+	node *ast.ParseSizeExpr
+
+	size *expr
+}
+
+func (x *parseSizeExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// randomStringExpr represents a call to the fn::randomString builtin.
+type randomStringExpr struct {
+	node *ast.RandomStringExpr
+
+	length  *expr
+	charset *expr // nil if the charset was omitted
+}
+
+func (x *randomStringExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// requireExpr represents a call to the fn::require builtin.
+type requireExpr struct {
+	node *ast.RequireExpr
+
+	value   *expr
+	message *expr
+}
+
+func (x *requireExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// sampleExpr represents a call to the fn::sample builtin.
+type sampleExpr struct {
+	node *ast.SampleExpr
+
+	array *expr
+}
+
+func (x *sampleExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// hmacExpr represents a call to the fn::hmac builtin.
+type hmacExpr struct {
+	node *ast.HMACExpr
+
+	algorithm *expr
+	key       *expr
+	message   *expr
+	encoding  *expr // nil if the encoding was omitted
+}
+
+func (x *hmacExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// importExpr represents a call to the fn::import builtin.
+type importExpr struct {
+	node *ast.ImportExpr
+
+	environment *expr
+	path        *expr
+}
+
+func (x *importExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// importRawExpr represents a call to the fn::importRaw builtin.
+type importRawExpr struct {
+	node *ast.ImportRawExpr
+
+	path *expr
+}
+
+func (x *importRawExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// jwtDecodeExpr represents a call to the fn::jwtDecode builtin.
+type jwtDecodeExpr struct {
+	node *ast.JWTDecodeExpr
+
+	token *expr
+}
+
+func (x *jwtDecodeExpr) syntax() ast.Expr {
+	return x.node
+}
+
 // secretExpr represents a call to the fn::secret builtin.
 type secretExpr struct {
 	node *ast.SecretExpr
@@ -428,7 +1766,19 @@ func (x *secretExpr) syntax() ast.Expr {
 	return x.node
 }
 
-// toBase64Expr represents a call to the fn::toBase64 builtin.
+// secretIfExpr represents a call to the fn::secretIf builtin.
+type secretIfExpr struct {
+	node *ast.SecretIfExpr
+
+	condition *expr
+	value     *expr
+}
+
+func (x *secretIfExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// toBase64Expr represents a call to the fn::toBase64 or fn::toBase64URL builtins.
 type toBase64Expr struct {
 	node *ast.ToBase64Expr
 
@@ -439,7 +1789,41 @@ func (x *toBase64Expr) syntax() ast.Expr {
 	return x.node
 }
 
-// fromBase64Expr represents a call from the fn::fromBase64 builtin.
+// capitalizeExpr represents a call to the fn::capitalize builtin.
+type capitalizeExpr struct {
+	node *ast.CapitalizeExpr
+
+	string *expr
+}
+
+func (x *capitalizeExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// titleExpr represents a call to the fn::title builtin.
+type titleExpr struct {
+	node *ast.TitleExpr
+
+	string *expr
+}
+
+func (x *titleExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// changeCaseExpr represents a call to the fn::camelCase, fn::snakeCase, or fn::kebabCase builtins.
+type changeCaseExpr struct {
+	node *ast.ChangeCaseExpr
+
+	style  ast.CaseStyle
+	string *expr
+}
+
+func (x *changeCaseExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// fromBase64Expr represents a call from the fn::fromBase64 or fn::fromBase64URL builtins.
 type fromBase64Expr struct {
 	node *ast.FromBase64Expr
 
@@ -449,3 +1833,85 @@ type fromBase64Expr struct {
 func (x *fromBase64Expr) syntax() ast.Expr {
 	return x.node
 }
+
+// toHexExpr represents a call to the fn::toHex builtin.
+type toHexExpr struct {
+	node *ast.ToHexExpr
+
+	value *expr
+}
+
+func (x *toHexExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// fromHexExpr represents a call from the fn::fromHex builtin.
+type fromHexExpr struct {
+	node *ast.FromHexExpr
+
+	string *expr
+}
+
+func (x *fromHexExpr) syntax() ast.Expr {
+	return x.node
+}
+
+// exportURLBuiltin exports a call to the fn::urlEncode or fn::urlDecode builtins, which share the same arguments.
+func exportURLBuiltin(environment string, opts exportOptions, name *ast.StringExpr, value, mode *expr) *esc.BuiltinExpr {
+	args := map[string]*expr{"value": value}
+	if mode != nil {
+		args["mode"] = mode
+	}
+	arg := make(map[string]esc.Expr, len(args))
+	for k, x := range args {
+		arg[k] = x.exportWithOptions(environment, opts)
+	}
+
+	return &esc.BuiltinExpr{
+		Name:      name.Value,
+		NameRange: convertRange(name.Syntax().Syntax().Range(), environment),
+		ArgSchema: schema.Object().Properties(schema.SchemaMap{
+			"value": schema.String().Schema(),
+			"mode":  urlModeSchema,
+		}).Required("value").Schema(),
+		Arg:      esc.Expr{Object: arg},
+		ArgValue: opts.argValueObject(environment, args),
+	}
+}
+
+var templateItemsSchema = schema.Array().Items(schema.Always()).Schema()
+
+// exportTemplateBuiltin exports a call to the fn::map, fn::filter, or fn::reduce builtins, which evaluate a template
+// for each element of a list. Each of the given arguments is required, and the optional binding name ('as') is added
+// to the argument's schema. The argument's value is not exported, as the template is evaluated separately for each
+// element.
+func exportTemplateBuiltin(
+	environment string,
+	opts exportOptions,
+	name *ast.StringExpr,
+	as *ast.StringExpr,
+	args map[string]*expr,
+	argSchemas schema.SchemaMap,
+) *esc.BuiltinExpr {
+	arg := make(map[string]esc.Expr, len(args)+1)
+	for k, x := range args {
+		arg[k] = x.exportWithOptions(environment, opts)
+	}
+	if as != nil {
+		arg["as"] = esc.Expr{
+			Range:   convertRange(as.Syntax().Syntax().Range(), environment),
+			Literal: as.Value,
+		}
+	}
+
+	required := maps.Keys(args)
+	sort.Strings(required)
+	argSchemas["as"] = schema.String().Schema()
+
+	return &esc.BuiltinExpr{
+		Name:      name.Value,
+		NameRange: convertRange(name.Syntax().Syntax().Range(), environment),
+		ArgSchema: schema.Object().Properties(argSchemas).Required(required...).Schema(),
+		Arg:       esc.Expr{Object: arg},
+	}
+}
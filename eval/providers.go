@@ -0,0 +1,108 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pulumi/esc"
+	"github.com/pulumi/esc/schema"
+)
+
+// A ProviderFunc implements a provider's Open method. It receives the provider's validated inputs and returns the
+// provider's outputs.
+type ProviderFunc func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error)
+
+// funcProvider adapts a ProviderFunc and its schemata to the esc.Provider interface.
+type funcProvider struct {
+	inputs  *schema.Schema
+	outputs *schema.Schema
+	open    ProviderFunc
+}
+
+func (p funcProvider) Schema() (*schema.Schema, *schema.Schema) {
+	return p.inputs, p.outputs
+}
+
+func (p funcProvider) Open(
+	ctx context.Context,
+	inputs map[string]esc.Value,
+	executionContext esc.EnvExecContext,
+) (esc.Value, error) {
+	return p.open(ctx, inputs, executionContext)
+}
+
+// A ProviderRegistry is an in-memory ProviderLoader. Providers are registered by name, and may be implemented either
+// by an esc.Provider or by a plain function. This allows tests and embedders to exercise fn::open without access to
+// any external services. A ProviderRegistry is safe for concurrent use.
+type ProviderRegistry struct {
+	m         sync.RWMutex
+	providers map[string]esc.Provider
+}
+
+// NewProviderRegistry creates a new, empty provider registry.
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{providers: map[string]esc.Provider{}}
+}
+
+// Register registers a provider with the given name that is implemented by open. The provider's inputs are validated
+// against the given input schema and its outputs are described by the given output schema. A nil schema accepts or
+// describes any value. Registering a provider replaces any provider previously registered with the same name.
+func (r *ProviderRegistry) Register(name string, inputs, outputs *schema.Schema, open ProviderFunc) {
+	if inputs == nil {
+		inputs = schema.Always().Schema()
+	}
+	if outputs == nil {
+		outputs = schema.Always().Schema()
+	}
+	r.RegisterProvider(name, funcProvider{inputs: inputs, outputs: outputs, open: open})
+}
+
+// RegisterProvider registers the given provider under the given name. Registering a provider replaces any provider
+// previously registered with the same name.
+func (r *ProviderRegistry) RegisterProvider(name string, provider esc.Provider) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.providers[name] = provider
+}
+
+// LoadProvider loads the provider registered with the given name.
+func (r *ProviderRegistry) LoadProvider(ctx context.Context, name string) (esc.Provider, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+	return provider, nil
+}
+
+// ProviderNames returns the names of the registered providers in lexicographic order.
+func (r *ProviderRegistry) ProviderNames() []string {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,176 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pulumi/esc"
+	"github.com/pulumi/esc/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderRegistry(t *testing.T) {
+	const def = `values:
+  aws:
+    fn::open:
+      provider: aws-oidc
+      inputs:
+        sessionName: site-prod-session
+        roleArn: some-role-arn
+  other:
+    fn::open::other: {}
+`
+
+	var received map[string]esc.Value
+	registry := NewProviderRegistry()
+	registry.Register("aws-oidc",
+		schema.Record(schema.BuilderMap{
+			"roleArn":     schema.String(),
+			"sessionName": schema.String(),
+		}).Schema(),
+		schema.Record(schema.BuilderMap{
+			"accessKeyId":     schema.String(),
+			"secretAccessKey": schema.String(),
+		}).Schema(),
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			received = inputs
+			return esc.NewValue(map[string]esc.Value{
+				"accessKeyId":     esc.NewValue("AKIA"),
+				"secretAccessKey": esc.NewSecret("shh"),
+			}), nil
+		})
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	t.Run("unregistered", func(t *testing.T) {
+		_, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Len(t, diags, 1)
+		assert.Equal(t, `unknown provider "other"`, diags[0].Summary)
+	})
+
+	registry.Register("other", nil, nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.NewValue("other"), nil
+		})
+
+	t.Run("registered", func(t *testing.T) {
+		actual, diags := EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Empty(t, diags)
+
+		assert.Equal(t, map[string]any{"roleArn": "some-role-arn", "sessionName": "site-prod-session"},
+			esc.NewValue(received).ToJSON(false))
+		assert.Equal(t, map[string]any{
+			"aws": map[string]any{
+				"accessKeyId":     "AKIA",
+				"secretAccessKey": "[secret]",
+			},
+			"other": "other",
+		}, esc.NewValue(actual.Properties).ToJSON(true))
+	})
+}
+
+func TestProviderSuggestion(t *testing.T) {
+	const def = `values:
+  aws:
+    fn::open:
+      provider: aws-odic
+      inputs: {}
+  short:
+    fn::open::aws-oid: {}
+  unrelated:
+    fn::open::vault: {}
+`
+
+	registry := NewProviderRegistry()
+	registry.Register("aws-oidc", nil, nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.NewValue("aws"), nil
+		})
+
+	env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+	require.NoError(t, err)
+	require.Empty(t, diags)
+
+	_, diags = CheckEnvironment(context.Background(), "test", env, rot128{}, registry,
+		&testEnvironments{}, &esc.ExecContext{}, false)
+	require.Len(t, diags, 3)
+
+	assert.Equal(t, `unknown provider "aws-odic"; did you mean "aws-oidc"?`, diags[0].Summary)
+	assert.Equal(t, 4, diags[0].Subject.Start.Line)
+	assert.Equal(t, 17, diags[0].Subject.Start.Column)
+
+	assert.Equal(t, `unknown provider "aws-oid"; did you mean "aws-oidc"?`, diags[1].Summary)
+	assert.Equal(t, 7, diags[1].Subject.Start.Line)
+
+	assert.Equal(t, `unknown provider "vault"`, diags[2].Summary)
+}
+
+func TestOpenErrorExample(t *testing.T) {
+	const def = `values:
+  db:
+    fn::open::database:
+      host: db.example.com
+`
+
+	registry := NewProviderRegistry()
+	registry.Register("database",
+		schema.Object().
+			Properties(schema.BuilderMap{
+				"host": schema.String(),
+				"port": schema.Number(),
+			}).
+			Examples(map[string]any{"host": "db.example.com", "port": 5432}).
+			Schema(),
+		nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.Value{}, errors.New("port is required")
+		})
+	registry.Register("plain", nil, nil,
+		func(ctx context.Context, inputs map[string]esc.Value, executionContext esc.EnvExecContext) (esc.Value, error) {
+			return esc.Value{}, errors.New("failed")
+		})
+
+	t.Run("example", func(t *testing.T) {
+		env, diags, err := LoadYAMLBytes("<stdin>", []byte(def))
+		require.NoError(t, err)
+		require.Empty(t, diags)
+
+		_, diags = EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Len(t, diags, 1)
+		assert.Equal(t, `opening provider "database": port is required`, diags[0].Summary)
+		assert.Equal(t, "inputs: {\"host\":\"db.example.com\"}\nexpected shape like: {\"host\":\"db.example.com\",\"port\":5432}",
+			diags[0].Detail)
+	})
+
+	t.Run("no example", func(t *testing.T) {
+		env, diags, err := LoadYAMLBytes("<stdin>", []byte("values:\n  other:\n    fn::open::plain: {}\n"))
+		require.NoError(t, err)
+		require.Empty(t, diags)
+
+		_, diags = EvalEnvironment(context.Background(), "test", env, rot128{}, registry,
+			&testEnvironments{}, &esc.ExecContext{})
+		require.Len(t, diags, 1)
+		assert.Equal(t, "inputs: {}", diags[0].Detail)
+	})
+}
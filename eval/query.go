@@ -0,0 +1,96 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pulumi/esc"
+	"github.com/pulumi/esc/ast"
+)
+
+// ErrNotFound is returned by Query when a path does not resolve to a value.
+var ErrNotFound = errors.New("value not found")
+
+// Query resolves a property path (e.g. `config.aws.roleArn` or `list[0].id`) against an evaluated value and returns
+// the value at that path. The path grammar is the same as that used within interpolations, so negative array indices
+// (e.g. `list[-1]`) count back from the end of the array. If any accessor in the path cannot be resolved, Query
+// returns an error that wraps ErrNotFound.
+//
+// If an unknown value is encountered before the end of the path, the result is an unknown value.
+func Query(v esc.Value, path string) (esc.Value, error) {
+	access, diags := ast.ParsePropertyPath(path)
+	if diags.HasErrors() {
+		return esc.Value{}, fmt.Errorf("invalid path %q: %w", path, diags)
+	}
+	return QueryAccess(v, access)
+}
+
+// QueryAccess resolves a parsed property access against an evaluated value. See Query for details.
+func QueryAccess(v esc.Value, access *ast.PropertyAccess) (esc.Value, error) {
+	receiver := v
+	for i, accessor := range access.Accessors {
+		if receiver.Unknown {
+			return esc.Value{Unknown: true, Secret: receiver.Secret}, nil
+		}
+
+		switch repr := receiver.Value.(type) {
+		case []esc.Value:
+			sub, ok := accessor.(*ast.PropertySubscript)
+			if !ok {
+				return esc.Value{}, queryError(access, i, "cannot access an array element using a property name")
+			}
+			index, ok := sub.Index.(int)
+			if !ok {
+				return esc.Value{}, queryError(access, i, "cannot access an array element using a property name")
+			}
+			resolved := index
+			if resolved < 0 {
+				resolved += len(repr)
+			}
+			if resolved < 0 || resolved >= len(repr) {
+				return esc.Value{}, queryError(access, i, fmt.Sprintf("array index %v out-of-bounds for array of length %v", index, len(repr)))
+			}
+			receiver = repr[resolved]
+		case map[string]esc.Value:
+			var key string
+			switch a := accessor.(type) {
+			case *ast.PropertyName:
+				key = a.Name
+			case *ast.PropertySubscript:
+				k, ok := a.Index.(string)
+				if !ok {
+					return esc.Value{}, queryError(access, i, "cannot access an object property using an integer index")
+				}
+				key = k
+			}
+			prop, ok := repr[key]
+			if !ok {
+				return esc.Value{}, queryError(access, i, fmt.Sprintf("unknown property %q", key))
+			}
+			receiver = prop
+		default:
+			return esc.Value{}, queryError(access, i, "receiver must be an array or an object")
+		}
+	}
+	return receiver, nil
+}
+
+// queryError returns an ErrNotFound error for the accessor at the given index.
+func queryError(access *ast.PropertyAccess, index int, summary string) error {
+	prefix := &ast.PropertyAccess{Accessors: access.Accessors[:index+1]}
+	return fmt.Errorf("%w: %v: %v", ErrNotFound, prefix, summary)
+}
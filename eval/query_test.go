@@ -0,0 +1,79 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eval
+
+import (
+	"testing"
+
+	"github.com/pulumi/esc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	root := esc.NewValue(map[string]esc.Value{
+		"config": esc.NewValue(map[string]esc.Value{
+			"aws": esc.NewValue(map[string]esc.Value{
+				"roleArn": esc.NewValue("arn:aws:iam::123456789012:role/test"),
+			}),
+			"key with a .": esc.NewSecret("hunter2"),
+		}),
+		"list": esc.NewValue([]esc.Value{
+			esc.NewValue(map[string]esc.Value{"id": esc.NewValue("first")}),
+			esc.NewValue(map[string]esc.Value{"id": esc.NewValue("second")}),
+		}),
+		"unknown": {Unknown: true},
+	})
+
+	cases := []struct {
+		path     string
+		expected esc.Value
+		notFound bool
+		invalid  bool
+	}{
+		{path: "config.aws.roleArn", expected: esc.NewValue("arn:aws:iam::123456789012:role/test")},
+		{path: `config["key with a ."]`, expected: esc.NewSecret("hunter2")},
+		{path: `["config"].aws`, expected: root.Value.(map[string]esc.Value)["config"].Value.(map[string]esc.Value)["aws"]},
+		{path: "list[0].id", expected: esc.NewValue("first")},
+		{path: "list[1].id", expected: esc.NewValue("second")},
+		{path: "list[-1].id", expected: esc.NewValue("second")},
+		{path: "list[-2].id", expected: esc.NewValue("first")},
+		{path: "unknown.foo[0]", expected: esc.Value{Unknown: true}},
+		{path: "missing", notFound: true},
+		{path: "config.aws.missing", notFound: true},
+		{path: "list[2]", notFound: true},
+		{path: "list[-3]", notFound: true},
+		{path: "list.id", notFound: true},
+		{path: "config[0]", notFound: true},
+		{path: "config.aws.roleArn.foo", notFound: true},
+		{path: "list[", invalid: true},
+		{path: "config..aws", invalid: true},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			actual, err := Query(root, c.path)
+			switch {
+			case c.notFound:
+				assert.ErrorIs(t, err, ErrNotFound)
+			case c.invalid:
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, ErrNotFound)
+			default:
+				require.NoError(t, err)
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package eval
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"sort"
 	"strconv"
 	"strings"
@@ -38,6 +39,7 @@ type value struct {
 	def    *expr          // the expression that produced this value
 	base   *value         // the base value, if any
 	schema *schema.Schema // the value's schema
+	origin *value         // the value this value was copied from, if any
 
 	mergedKeys []string   // the value's merged keys. computed lazily--use keys().
 	exported   *esc.Value // non-nil if this value has already been exported
@@ -46,6 +48,7 @@ type value struct {
 	// fn::open)
 	unknown bool
 	secret  bool // true if the value is secret
+	hidden  bool // true if the value should be omitted from user-facing output
 
 	repr any // nil | bool | json.Number | string | []*value | map[string]*value
 }
@@ -58,6 +61,15 @@ func (v *value) GoString() string {
 	return fmt.Sprintf("%q @%v:%v (%p)", v.def.path, r.Environment, r.Begin, v)
 }
 
+// identity returns the value from which this value was originally copied, or the value itself if it is not a copy.
+// Copies that share an identity have the same contents, though their definitions may differ.
+func (v *value) identity() *value {
+	if v.origin != nil {
+		return v.origin
+	}
+	return v
+}
+
 // containsUnknowns returns true if the value contains any unknown values.
 func (v *value) containsUnknowns() bool {
 	if v == nil {
@@ -138,6 +150,83 @@ func (v *value) is(other *value) bool {
 	return false
 }
 
+// typeName returns the name of the JSON type of the receiver's representation. The names are the same as those used by
+// JSON schema (e.g. "null" or "object").
+func (v *value) typeName() string {
+	switch repr := v.repr.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []*value:
+		return "array"
+	case map[string]*value:
+		return "object"
+	default:
+		panic(fmt.Errorf("illegal value of type %T", repr))
+	}
+}
+
+// equals returns true if the receiver is structurally equal to other. Numbers are compared by numeric value, so 1 and
+// 1.0 are equal. Object properties are compared as per JSON merge patch semantics. Neither value may contain unknowns.
+func (v *value) equals(other *value) bool {
+	switch repr := v.repr.(type) {
+	case nil:
+		return other.repr == nil
+	case bool:
+		o, ok := other.repr.(bool)
+		return ok && repr == o
+	case json.Number:
+		o, ok := other.repr.(json.Number)
+		if !ok {
+			return false
+		}
+		a, _, aerr := big.ParseFloat(string(repr), 10, 0, big.ToNearestEven)
+		b, _, berr := big.ParseFloat(string(o), 10, 0, big.ToNearestEven)
+		if aerr != nil || berr != nil {
+			return repr == o
+		}
+		return a.Cmp(b) == 0
+	case string:
+		o, ok := other.repr.(string)
+		return ok && repr == o
+	case []*value:
+		o, ok := other.repr.([]*value)
+		if !ok || len(repr) != len(o) {
+			return false
+		}
+		for i, e := range repr {
+			if !e.equals(o[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]*value:
+		if _, ok := other.repr.(map[string]*value); !ok {
+			return false
+		}
+		keys, otherKeys := v.keys(), other.keys()
+		if len(keys) != len(otherKeys) {
+			return false
+		}
+		for i, k := range keys {
+			if k != otherKeys[i] {
+				return false
+			}
+			if !v.property(nil, k).equals(other.property(nil, k)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
 // combine combines the unknown-ness and secret-ness of the given values and applies the result to the receiver.
 // If any of the inputs contains unknowns or secrets, the receiver is unknown or secret. This should only be used when
 // computing an aggregate value from other values (e.g. the output of fn::join is unknown if any of its inputs
@@ -227,6 +316,9 @@ func (v *value) merge(base *value) {
 		return
 	}
 
+	// Merging changes the value's contents, so it no longer shares the identity of the value it was copied from.
+	v.origin = nil
+
 	if v.base != nil {
 		// If this value already has a base, apply the merge to its base.
 		v.base.merge(base)
@@ -246,6 +338,42 @@ func (v *value) merge(base *value) {
 	v.schema = mergedSchema(v.base.schema, v.schema)
 }
 
+// secretCopy returns a deep copy of this value in which every value is secret. The value's base is copied as well so
+// that properties inherited from imports are also secret. The original value is not modified, as it may be shared
+// with other environments.
+func (v *value) secretCopy() *value {
+	if v == nil {
+		return nil
+	}
+
+	c := &value{
+		def:        v.def,
+		base:       v.base.secretCopy(),
+		schema:     v.schema,
+		mergedKeys: v.mergedKeys,
+		unknown:    v.unknown,
+		secret:     true,
+		hidden:     v.hidden,
+	}
+	switch repr := v.repr.(type) {
+	case []*value:
+		elements := make([]*value, len(repr))
+		for i, e := range repr {
+			elements[i] = e.secretCopy()
+		}
+		c.repr = elements
+	case map[string]*value:
+		properties := make(map[string]*value, len(repr))
+		for k, p := range repr {
+			properties[k] = p.secretCopy()
+		}
+		c.repr = properties
+	default:
+		c.repr = repr
+	}
+	return c
+}
+
 // toString returns the string representation of this value, whether the string is known, and whether the string is
 // secret.
 func (v *value) toString() (str string, unknown bool, secret bool) {
@@ -330,6 +458,59 @@ func (v *value) export(environment string) esc.Value {
 	return *v.exported
 }
 
+// exportVisible converts the value into its serializable representation, omitting hidden values. Returns false if the
+// value itself is hidden.
+func (v *value) exportVisible(environment string) (esc.Value, bool) {
+	if v.hidden {
+		return esc.Value{}, false
+	}
+
+	ev := v.export(environment)
+	switch repr := v.repr.(type) {
+	case []*value:
+		a := make([]esc.Value, 0, len(repr))
+		for _, v := range repr {
+			if ee, ok := v.exportVisible(environment); ok {
+				a = append(a, ee)
+			}
+		}
+		ev.Value = a
+	case map[string]*value:
+		keys := v.keys()
+		pm := make(map[string]esc.Value, len(keys))
+		for _, k := range keys {
+			pv := v.property(v.def.repr.syntax(), k)
+			if ee, ok := pv.exportVisible(environment); ok {
+				pm[k] = ee
+			}
+		}
+		ev.Value = pm
+	}
+	return ev, true
+}
+
+// markHidden marks the parts of the value that are described by hidden schemas within s.
+func markHidden(v *value, s *schema.Schema) {
+	if s == nil {
+		return
+	}
+	if s.Hidden {
+		v.hidden = true
+		return
+	}
+
+	switch repr := v.repr.(type) {
+	case []*value:
+		for i, e := range repr {
+			markHidden(e, s.Item(i))
+		}
+	case map[string]*value:
+		for k, p := range repr {
+			markHidden(p, s.Property(k))
+		}
+	}
+}
+
 // unexport creates a value from a Value. This is used when interacting with providers, as the Provider API works on
 // Values, but the evaluator needs values.
 func unexport(v esc.Value, x *expr) *value {
@@ -444,8 +625,10 @@ func (c copier) copy(v *value) *value {
 		def:     v.def,
 		base:    c.copy(v.base),
 		schema:  v.schema,
+		origin:  v.identity(),
 		unknown: v.unknown,
 		secret:  v.secret,
+		hidden:  v.hidden,
 		repr:    repr,
 	}
 	return copy
@@ -16,6 +16,7 @@ package esc
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/pulumi/esc/schema"
 )
@@ -34,6 +35,15 @@ type Expr struct {
 	// Ranges for the object's keys, if this is an object expression.
 	KeyRanges map[string]Range `json:"keyRanges,omitempty"`
 
+	// The text of the comments that precede the object's keys, if this is an object expression. Keys without comments
+	// are omitted.
+	KeyComments map[string]string `json:"keyComments,omitempty"`
+
+	// The range of the expression that ultimately defined this expression's value, if requested and this is not a list
+	// or object expression. Property accesses are followed to the expressions they refer to, including expressions in
+	// imported environments.
+	Provenance *Range `json:"provenance,omitempty"`
+
 	// The fields below act as a discriminated union. Only one must be non-nil at any given time. If all fields are nil,
 	// then this Expr is a null literal expression.
 
@@ -59,6 +69,28 @@ type Expr struct {
 	Builtin *BuiltinExpr `json:"builtin,omitempty"`
 }
 
+// ObjectKeys returns the keys of an object expression in a deterministic order. Keys with known ranges are returned in
+// source order, followed by any remaining keys in lexicographic order.
+func (x *Expr) ObjectKeys() []string {
+	keys := make([]string, 0, len(x.Object))
+	for k := range x.Object {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		ri, iok := x.KeyRanges[keys[i]]
+		rj, jok := x.KeyRanges[keys[j]]
+		switch {
+		case iok && jok:
+			return ri.Begin.Byte < rj.Begin.Byte
+		default:
+			return iok && !jok
+		}
+	})
+	return keys
+}
+
 // An Interpolation holds information about a part of an interpolated string expression.
 type Interpolation struct {
 	// The text of the expression. Precedes the stringified Value in the output.
@@ -70,7 +102,8 @@ type Interpolation struct {
 
 // An Accessor is an element index or property name.
 type Accessor struct {
-	// The integer index of the element to access. Mutually exclusive with Key.
+	// The integer index of the element to access. Negative indices count back from the end of the array. Mutually
+	// exclusive with Key.
 	Index *int `json:"index,omitempty"`
 
 	// The key of the property to access. Mutually exclusive with Index.
@@ -103,6 +136,10 @@ type BuiltinExpr struct {
 	NameRange Range          `json:"nameRange"`
 	ArgSchema *schema.Schema `json:"argSchema"`
 	Arg       Expr           `json:"arg"`
+
+	// The evaluated value of the argument, if requested during evaluation. Secrets are redacted unless otherwise
+	// requested.
+	ArgValue *Value `json:"argValue,omitempty"`
 }
 
 // A Range defines a range within an environment definition.
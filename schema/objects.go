@@ -80,11 +80,21 @@ func (b *ObjectBuilder) Required(names ...string) *ObjectBuilder {
 	return b
 }
 
+func (b *ObjectBuilder) RequiredNonNull(nonNull bool) *ObjectBuilder {
+	b.s.RequiredNonNull = nonNull
+	return b
+}
+
 func (b *ObjectBuilder) DependentRequired(names map[string][]string) *ObjectBuilder {
 	b.s.DependentRequired = names
 	return b
 }
 
+func (b *ObjectBuilder) DependentSchemas(m MapBuilder) *ObjectBuilder {
+	b.s.DependentSchemas = m.Build()
+	return b
+}
+
 func (b *ObjectBuilder) Title(title string) *ObjectBuilder {
 	b.s.Title = title
 	return b
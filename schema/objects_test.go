@@ -145,6 +145,16 @@ func TestObjects(t *testing.T) {
 		require.EqualValues(t, dependentRequired, s.DependentRequired)
 	})
 
+	t.Run("dependentSchemas", func(t *testing.T) {
+		dependentSchemas := SchemaMap{
+			"a": Object().Required("A").Schema(),
+		}
+		s := Object().
+			DependentSchemas(dependentSchemas).
+			Schema()
+		require.Equal(t, map[string]*Schema(dependentSchemas), s.DependentSchemas)
+	})
+
 	t.Run("title", func(t *testing.T) {
 		title := "example"
 		s := Object().
@@ -89,6 +89,7 @@ type Schema struct {
 	Items                *Schema            `json:"items,omitempty"`
 	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
 	Properties           map[string]*Schema `json:"properties,omitempty"`
+	DependentSchemas     map[string]*Schema `json:"dependentSchemas,omitempty"`
 
 	// Validation vocabulary
 
@@ -111,6 +112,11 @@ type Schema struct {
 	Required          []string            `json:"required,omitempty"`
 	DependentRequired map[string][]string `json:"dependentRequired,omitempty"`
 
+	// Content vocabulary
+
+	ContentEncoding  string `json:"contentEncoding,omitempty"`
+	ContentMediaType string `json:"contentMediaType,omitempty"`
+
 	// Metadata vocabulary
 
 	Title       string `json:"title,omitempty"`
@@ -122,6 +128,18 @@ type Schema struct {
 	// Environments extensions
 	Secret bool `json:"secret,omitempty"`
 
+	// Hidden marks values that should be omitted from user-facing output, e.g. because they are computed or managed by
+	// a server. Unlike Secret, which causes a value to be redacted, Hidden causes a value to be omitted entirely.
+	Hidden bool `json:"hidden,omitempty"`
+
+	// RequiredNonNull causes properties whose values are null to be treated as missing when checking required and
+	// dependentRequired.
+	RequiredNonNull bool `json:"requiredNonNull,omitempty"`
+
+	// EnumCaseInsensitive causes string values to match the members of enum without regard to case. Matching values
+	// are normalized to the casing of the enum member they match.
+	EnumCaseInsensitive bool `json:"enumCaseInsensitive,omitempty"`
+
 	ref              *Schema
 	multipleOf       *big.Float
 	maximum          *big.Float
@@ -136,7 +154,8 @@ type Schema struct {
 	maxProperties    *uint
 	minProperties    *uint
 
-	compiled bool
+	compiled   bool  // true if compilation has started. Compilation happens at most once per schema.
+	compileErr error // the result of compilation, if any
 }
 
 func (s *Schema) UnmarshalJSON(data []byte) error {
@@ -151,9 +170,58 @@ func (s *Schema) UnmarshalJSON(data []byte) error {
 	}
 
 	type rawSchema Schema
+	var raw struct {
+		*rawSchema
+
+		// Draft-04 schemas express exclusive bounds as booleans that modify maximum and minimum, so we accept either
+		// form here and normalize to the numeric form.
+		ExclusiveMaximum json.RawMessage `json:"exclusiveMaximum,omitempty"`
+		ExclusiveMinimum json.RawMessage `json:"exclusiveMinimum,omitempty"`
+	}
+	raw.rawSchema = (*rawSchema)(s)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	var err error
+	if s.ExclusiveMaximum, s.Maximum, err = parseExclusiveBound(raw.ExclusiveMaximum, s.Maximum); err != nil {
+		return fmt.Errorf("exclusiveMaximum: %w", err)
+	}
+	if s.ExclusiveMinimum, s.Minimum, err = parseExclusiveBound(raw.ExclusiveMinimum, s.Minimum); err != nil {
+		return fmt.Errorf("exclusiveMinimum: %w", err)
+	}
+	return nil
+}
+
+// parseExclusiveBound parses an exclusiveMaximum or exclusiveMinimum keyword and returns the resulting exclusive and
+// inclusive bounds. The keyword may be a number or a draft-04-style boolean. If the keyword is true, the inclusive
+// bound becomes the exclusive bound.
+func parseExclusiveBound(data json.RawMessage, inclusive json.Number) (json.Number, json.Number, error) {
+	if len(data) == 0 {
+		return "", inclusive, nil
+	}
+
 	dec := json.NewDecoder(bytes.NewReader(data))
 	dec.UseNumber()
-	return dec.Decode((*rawSchema)(s))
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return "", "", err
+	}
+	switch v := v.(type) {
+	case json.Number:
+		return v, inclusive, nil
+	case bool:
+		if v {
+			return inclusive, "", nil
+		}
+		return "", inclusive, nil
+	default:
+		return "", "", fmt.Errorf("expected a number or a boolean, got %s", data)
+	}
 }
 
 func (s *Schema) MarshalJSON() ([]byte, error) {
@@ -176,12 +244,39 @@ func (s *Schema) arrayItem(index int) *Schema {
 	if s.Type != "array" {
 		return Never()
 	}
+	if index < 0 {
+		return s.arrayItemFromEnd(index)
+	}
 	if index < len(s.PrefixItems) {
 		return s.PrefixItems[index]
 	}
 	return s.Items
 }
 
+// arrayItemFromEnd returns the schema of the element at the given negative index, which counts back from the end of
+// the array. If the array may have additional items, the element may be any of the trailing prefix items or an
+// additional item.
+func (s *Schema) arrayItemFromEnd(index int) *Schema {
+	start := len(s.PrefixItems) + index
+	if s.Items != nil && s.Items.Never {
+		if start < 0 {
+			return Never()
+		}
+		return s.PrefixItems[start]
+	}
+	if s.Items == nil {
+		return nil
+	}
+
+	if start < 0 {
+		start = 0
+	}
+	oneOf := make([]*Schema, 0, len(s.PrefixItems)-start+1)
+	oneOf = append(oneOf, s.PrefixItems[start:]...)
+	return union(append(oneOf, s.Items))
+}
+
+// Item returns the schema of the element at the given index. Negative indices count back from the end of the array.
 func (s *Schema) Item(index int) *Schema {
 	var oneOf []*Schema
 	for _, x := range s.AnyOf {
@@ -230,25 +325,36 @@ func (s *Schema) GetMinItems() *uint              { return s.minItems }
 func (s *Schema) GetMaxProperties() *uint         { return s.maxProperties }
 func (s *Schema) GetMinProperties() *uint         { return s.minProperties }
 
+// Compile resolves references and parses the validation keywords of the schema and its subschemas. Compilation results
+// (including errors) are cached, so repeated calls to Compile are cheap.
 func (s *Schema) Compile() error {
-	if s == nil || s.compiled {
+	if s == nil {
 		return nil
 	}
-
 	return s.compile(s)
 }
 
 func (s *Schema) compile(root *Schema) error {
-	if s == nil || s.compiled {
+	if s == nil {
 		return nil
 	}
-	s.compiled = true
+	if !s.compiled {
+		// Mark the schema as compiled before compiling its subschemas in order to terminate recursive references.
+		s.compiled = true
+		s.compileErr = s.compileKeywords(root)
+	}
+	return s.compileErr
+}
 
+func (s *Schema) compileKeywords(root *Schema) error {
 	var err error
 	if s.Ref != "" {
 		if s.ref, err = parseRef(root, s.Ref); err != nil {
 			return err
 		}
+		if s.hasRefCycle(root) {
+			return errRefCycle
+		}
 		if err = s.ref.compile(root); err != nil {
 			return err
 		}
@@ -281,6 +387,11 @@ func (s *Schema) compile(root *Schema) error {
 			return err
 		}
 	}
+	for _, v := range s.DependentSchemas {
+		if err := v.compile(root); err != nil {
+			return err
+		}
+	}
 
 	if s.multipleOf, err = parseNumber(s.MultipleOf); err != nil {
 		return err
@@ -322,6 +433,54 @@ func (s *Schema) compile(root *Schema) error {
 	return nil
 }
 
+// errRefCycle is returned by Compile for schemas that can reach themselves without descending into a value.
+var errRefCycle = errors.New("schema contains an unresolvable reference cycle")
+
+// hasRefCycle returns true if s can reach itself through $ref, anyOf, oneOf, and dependentSchemas alone. These keywords
+// apply their subschemas to the same value, so validating a value against such a schema would never terminate. Cycles
+// that pass through keywords that apply to part of the value (e.g. items or properties) are fine, as each step consumes
+// part of the value.
+func (s *Schema) hasRefCycle(root *Schema) bool {
+	visited := map[*Schema]bool{}
+
+	var reaches func(x *Schema) bool
+	reaches = func(x *Schema) bool {
+		if x == nil {
+			return false
+		}
+		if x == s {
+			return true
+		}
+		if visited[x] {
+			return false
+		}
+		visited[x] = true
+
+		if x.Ref != "" {
+			if ref, err := parseRef(root, x.Ref); err == nil && reaches(ref) {
+				return true
+			}
+		}
+		for _, x := range x.AnyOf {
+			if reaches(x) {
+				return true
+			}
+		}
+		for _, x := range x.OneOf {
+			if reaches(x) {
+				return true
+			}
+		}
+		for _, x := range x.DependentSchemas {
+			if reaches(x) {
+				return true
+			}
+		}
+		return false
+	}
+	return reaches(s.ref)
+}
+
 func parseRef(root *Schema, ref string) (*Schema, error) {
 	refName, ok := strings.CutPrefix(ref, "#/$defs/")
 	if !ok || strings.Contains(refName, "/") {
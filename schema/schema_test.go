@@ -78,6 +78,18 @@ func TestItem(t *testing.T) {
 		assert.Equal(t, s.Items, s.Item(2))
 	})
 
+	t.Run("negative", func(t *testing.T) {
+		tuple := Tuple(String(), Number()).Schema()
+		assert.Equal(t, tuple.PrefixItems[1], tuple.Item(-1))
+		assert.Equal(t, tuple.PrefixItems[0], tuple.Item(-2))
+		assert.True(t, tuple.Item(-3).Never)
+
+		s := Array().PrefixItems(String(), Number()).Items(Boolean()).Schema()
+		assert.Equal(t, OneOf(Number(), Boolean()).Schema(), s.Item(-1))
+		assert.Equal(t, OneOf(String(), Number(), Boolean()).Schema(), s.Item(-2))
+		assert.Equal(t, OneOf(String(), Number(), Boolean()).Schema(), s.Item(-3))
+	})
+
 	t.Run("anyOf", func(t *testing.T) {
 		s := AnyOf(
 			Array().PrefixItems(String(), Number()).Items(Boolean()),
@@ -177,3 +189,138 @@ func TestCompile(t *testing.T) {
 		})
 	}
 }
+
+func TestCompileCached(t *testing.T) {
+	items := String().Pattern("^[a-z]+$").Schema()
+	s := Array().Items(items).Schema()
+
+	require.NoError(t, s.Compile())
+	pattern := items.GetPattern()
+	require.NotNil(t, pattern)
+
+	// Compiling again must not recompile the schema or its subschemas.
+	require.NoError(t, s.Compile())
+	require.NoError(t, items.Compile())
+	assert.Same(t, pattern, items.GetPattern())
+
+	// Compilation errors are cached along with successful results.
+	invalid := Array().Items(String().Pattern("[")).Schema()
+	err := invalid.Compile()
+	require.Error(t, err)
+	assert.Equal(t, err, invalid.Compile())
+	assert.Equal(t, err, invalid.Items.Compile())
+}
+
+func TestCompileRefCycle(t *testing.T) {
+	cases := []struct {
+		name  string
+		json  string
+		cycle bool
+	}{
+		{
+			name:  "self",
+			json:  `{"$ref": "#/$defs/a", "$defs": {"a": {"$ref": "#/$defs/a"}}}`,
+			cycle: true,
+		},
+		{
+			name:  "mutual",
+			json:  `{"$ref": "#/$defs/a", "$defs": {"a": {"$ref": "#/$defs/b"}, "b": {"$ref": "#/$defs/a"}}}`,
+			cycle: true,
+		},
+		{
+			name: "anyOf",
+			json: `{"$ref": "#/$defs/a", "$defs": {"a": {"anyOf": [
+				{"type": "string"},
+				{"oneOf": [{"$ref": "#/$defs/a"}]}
+			]}}}`,
+			cycle: true,
+		},
+		{
+			name: "dependentSchemas",
+			json: `{"$ref": "#/$defs/a", "$defs": {"a": {
+				"type": "object",
+				"dependentSchemas": {"x": {"$ref": "#/$defs/a"}}
+			}}}`,
+			cycle: true,
+		},
+		{
+			name: "items",
+			json: `{"$ref": "#/$defs/list", "$defs": {"list": {"anyOf": [
+				{"type": "string"},
+				{"type": "array", "items": {"$ref": "#/$defs/list"}}
+			]}}}`,
+		},
+		{
+			name: "properties",
+			json: `{"$ref": "#/$defs/node", "$defs": {"node": {
+				"type": "object",
+				"properties": {"next": {"$ref": "#/$defs/node"}}
+			}}}`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var s Schema
+			require.NoError(t, json.Unmarshal([]byte(c.json), &s))
+
+			err := s.Compile()
+			if c.cycle {
+				assert.EqualError(t, err, "schema contains an unresolvable reference cycle")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalExclusiveBounds(t *testing.T) {
+	cases := []struct {
+		name     string
+		json     string
+		expected *Schema
+	}{
+		{
+			name:     "numeric",
+			json:     `{"type":"number","exclusiveMinimum":1,"exclusiveMaximum":10}`,
+			expected: Number().ExclusiveMinimum("1").ExclusiveMaximum("10").Schema(),
+		},
+		{
+			name:     "draft-04 exclusive",
+			json:     `{"type":"number","minimum":1,"exclusiveMinimum":true,"maximum":10,"exclusiveMaximum":true}`,
+			expected: Number().ExclusiveMinimum("1").ExclusiveMaximum("10").Schema(),
+		},
+		{
+			name:     "draft-04 inclusive",
+			json:     `{"type":"number","minimum":1,"exclusiveMinimum":false,"maximum":10,"exclusiveMaximum":false}`,
+			expected: Number().Minimum("1").Maximum("10").Schema(),
+		},
+		{
+			name:     "draft-04 mixed",
+			json:     `{"type":"number","minimum":1,"exclusiveMinimum":true,"maximum":10}`,
+			expected: Number().ExclusiveMinimum("1").Maximum("10").Schema(),
+		},
+		{
+			name:     "draft-04 without bound",
+			json:     `{"type":"number","exclusiveMinimum":true}`,
+			expected: Number().Schema(),
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var s Schema
+			err := json.Unmarshal([]byte(c.json), &s)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, &s)
+
+			require.NoError(t, s.Compile())
+			assert.Equal(t, c.expected.ExclusiveMinimum != "", s.GetExclusiveMinimum() != nil)
+			assert.Equal(t, c.expected.ExclusiveMaximum != "", s.GetExclusiveMaximum() != nil)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		var s Schema
+		err := json.Unmarshal([]byte(`{"type":"number","exclusiveMinimum":"1"}`), &s)
+		assert.Error(t, err)
+	})
+}
@@ -53,6 +53,11 @@ func (b *StringBuilder) Enum(vals ...string) *StringBuilder {
 	return b
 }
 
+func (b *StringBuilder) EnumCaseInsensitive(caseInsensitive bool) *StringBuilder {
+	b.s.EnumCaseInsensitive = caseInsensitive
+	return b
+}
+
 func (b *StringBuilder) MaxLength(n int) *StringBuilder {
 	b.s.MaxLength = json.Number(strconv.FormatInt(int64(n), 10))
 	return b
@@ -68,6 +73,16 @@ func (b *StringBuilder) Pattern(pattern string) *StringBuilder {
 	return b
 }
 
+func (b *StringBuilder) ContentEncoding(encoding string) *StringBuilder {
+	b.s.ContentEncoding = encoding
+	return b
+}
+
+func (b *StringBuilder) ContentMediaType(mediaType string) *StringBuilder {
+	b.s.ContentMediaType = mediaType
+	return b
+}
+
 func (b *StringBuilder) Title(title string) *StringBuilder {
 	b.s.Title = title
 	return b
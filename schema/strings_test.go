@@ -101,6 +101,22 @@ func TestStrings(t *testing.T) {
 		require.Equal(t, pattern, s.Pattern)
 	})
 
+	t.Run("contentEncoding", func(t *testing.T) {
+		encoding := "base64"
+		s := String().
+			ContentEncoding(encoding).
+			Schema()
+		require.Equal(t, encoding, s.ContentEncoding)
+	})
+
+	t.Run("contentMediaType", func(t *testing.T) {
+		mediaType := "application/json"
+		s := String().
+			ContentMediaType(mediaType).
+			Schema()
+		require.Equal(t, mediaType, s.ContentMediaType)
+	})
+
 	t.Run("title", func(t *testing.T) {
 		title := "example"
 		s := String().
@@ -79,15 +79,37 @@ func (s YAMLSyntax) ScalarRange(start, end int) *hcl.Range {
 	}
 }
 
+// IsAnchor returns true if the YAML node defines an anchor.
+func (s YAMLSyntax) IsAnchor() bool {
+	return s.Node != nil && s.Node.Anchor != ""
+}
+
+// AliasOf returns the anchored YAML node referenced by the YAML node if it is an alias. Otherwise, AliasOf returns nil.
+func (s YAMLSyntax) AliasOf() *yaml.Node {
+	if s.Node == nil || s.Kind != yaml.AliasNode {
+		return nil
+	}
+	return s.Alias
+}
+
 func (s YAMLSyntax) HeadComment() string {
+	if s.Node == nil {
+		return ""
+	}
 	return s.Node.HeadComment
 }
 
 func (s YAMLSyntax) LineComment() string {
+	if s.Node == nil {
+		return ""
+	}
 	return s.Node.LineComment
 }
 
 func (s YAMLSyntax) FootComment() string {
+	if s.Node == nil {
+		return ""
+	}
 	return s.Node.FootComment
 }
 
@@ -98,8 +120,9 @@ type linePosition struct {
 }
 
 type positionIndex struct {
-	lines []linePosition
-	path  []any
+	lines   []linePosition
+	path    []any
+	anchors map[*yaml.Node]syntax.Node // decoded anchors. A nil value indicates an anchor that is being decoded.
 }
 
 func (p positionIndex) pathString() string {
@@ -126,16 +149,16 @@ func isASCII(s []byte) bool {
 	return true
 }
 
-func newPositionIndex(yaml []byte) positionIndex {
+func newPositionIndex(text []byte) positionIndex {
 	offset, lines, path := 0, []linePosition(nil), []any(nil)
 	for {
-		line, rest, found := bytes.Cut(yaml, []byte{'\n'})
+		line, rest, found := bytes.Cut(text, []byte{'\n'})
 
 		lines = append(lines, linePosition{offset: offset, ascii: isASCII(line), line: line})
 		if !found {
-			return positionIndex{lines, path}
+			return positionIndex{lines: lines, path: path, anchors: map[*yaml.Node]syntax.Node{}}
 		}
-		offset, yaml = offset+len(line)+1, rest
+		offset, text = offset+len(line)+1, rest
 	}
 }
 
@@ -203,7 +226,59 @@ func (p positionIndex) yamlNodeRange(filename string, n *yaml.Node) *hcl.Range {
 // for the node itself, though it does use the tag decoder for the node's children. This allows tag decoders to call
 // UnmarshalYAMLNode without infinitely recurring on the same node. See UnmarshalYAML for more details.
 func UnmarshalYAMLNode(filename string, n *yaml.Node, tags TagDecoder) (syntax.Node, syntax.Diagnostics) {
-	return unmarshalYAMLNode(filename, positionIndex{}, n, tags)
+	return unmarshalYAMLNode(filename, positionIndex{anchors: map[*yaml.Node]syntax.Node{}}, n, tags)
+}
+
+// unmarshalYAMLAlias unmarshals a YAML alias. The result shares the contents of the syntax node for the anchored YAML
+// node, but carries the alias's own syntax (and therefore its own range and comments).
+func unmarshalYAMLAlias(filename string, positions positionIndex, n *yaml.Node, tags TagDecoder) (syntax.Node, syntax.Diagnostics) {
+	rng := positions.yamlNodeRange(filename, n)
+	path := positions.pathString()
+
+	if n.Alias == nil {
+		return nil, syntax.Diagnostics{syntax.Error(rng, fmt.Sprintf("unknown anchor %q", n.Value), path)}
+	}
+
+	anchored, ok := positions.anchors[n.Alias]
+	if ok && anchored == nil {
+		return nil, syntax.Diagnostics{syntax.Error(rng, fmt.Sprintf("alias %q refers to an anchor that contains it", n.Value), path)}
+	}
+	var diags syntax.Diagnostics
+	if !ok {
+		// The anchor is outside of the tree being decoded. Decode it here.
+		anchored, diags = unmarshalYAML(filename, positions, n.Alias, tags)
+		if anchored == nil {
+			return nil, diags
+		}
+	}
+
+	// The original value is omitted so that the anchor's value is used when the alias is marshaled.
+	s := YAMLSyntax{n, rng, path, nil}
+	switch anchored := anchored.(type) {
+	case *syntax.NullNode:
+		return syntax.NullSyntax(s), diags
+	case *syntax.BooleanNode:
+		return syntax.BooleanSyntax(s, anchored.Value()), diags
+	case *syntax.NumberNode:
+		return syntax.NumberSyntax(s, anchored.Value()), diags
+	case *syntax.StringNode:
+		return syntax.StringSyntax(s, anchored.Value()), diags
+	case *syntax.ArrayNode:
+		elements := make([]syntax.Node, anchored.Len())
+		for i := range elements {
+			elements[i] = anchored.Index(i)
+		}
+		return syntax.ArraySyntax(s, elements...), diags
+	case *syntax.ObjectNode:
+		entries := make([]syntax.ObjectPropertyDef, anchored.Len())
+		for i := range entries {
+			entries[i] = anchored.Index(i)
+		}
+		return syntax.ObjectSyntax(s, entries...), diags
+	default:
+		// The anchor was decoded by a tag decoder. Share the node as-is.
+		return anchored, diags
+	}
 }
 
 func unmarshalYAMLNode(filename string, positions positionIndex, n *yaml.Node, tags TagDecoder) (syntax.Node, syntax.Diagnostics) {
@@ -284,7 +359,7 @@ func unmarshalYAMLNode(filename string, positions positionIndex, n *yaml.Node, t
 			return syntax.StringSyntax(YAMLSyntax{n, rng, path, v}, n.Value), nil
 		}
 	case yaml.AliasNode:
-		return nil, syntax.Diagnostics{syntax.Error(rng, "alias nodes are not supported", path)}
+		return unmarshalYAMLAlias(filename, positions, n, tags)
 	default:
 		return nil, syntax.Diagnostics{syntax.Error(rng, fmt.Sprintf("unexpected node kind %v", n.Kind), path)}
 	}
@@ -296,14 +371,27 @@ func unmarshalYAMLNode(filename string, positions positionIndex, n *yaml.Node, t
 // - Scalars are decoded as the corresponding literal type (null -> nullNode, bool -> BooleanNode, etc.)
 // - Sequences are decoded as array nodes
 // - Mappings are decoded as object nodes
+// - Aliases are decoded as nodes that share the contents of the syntax node for their anchor
 //
 // Tagged nodes are decoded using the given TagDecoder. To avoid infinite recursion, the TagDecoder must call
 // UnmarshalYAMLNode if it needs to unmarshal the node it is processing.
 func UnmarshalYAML(filename string, n *yaml.Node, tags TagDecoder) (syntax.Node, syntax.Diagnostics) {
-	return unmarshalYAML(filename, positionIndex{}, n, tags)
+	return unmarshalYAML(filename, positionIndex{anchors: map[*yaml.Node]syntax.Node{}}, n, tags)
 }
 
 func unmarshalYAML(filename string, positions positionIndex, n *yaml.Node, tags TagDecoder) (syntax.Node, syntax.Diagnostics) {
+	if n.Anchor == "" || positions.anchors == nil {
+		return unmarshalYAMLTagged(filename, positions, n, tags)
+	}
+
+	// Record the anchored node so that aliases to it can share its syntax node.
+	positions.anchors[n] = nil
+	node, diags := unmarshalYAMLTagged(filename, positions, n, tags)
+	positions.anchors[n] = node
+	return node, diags
+}
+
+func unmarshalYAMLTagged(filename string, positions positionIndex, n *yaml.Node, tags TagDecoder) (syntax.Node, syntax.Diagnostics) {
 	if tags != nil {
 		if s, diags, ok := tags.DecodeTag(filename, n); ok {
 			return s, diags
@@ -454,7 +542,7 @@ func DecodeYAMLBytes(filename string, bytes []byte, tags TagDecoder) (syntax.Nod
 // DecodeYAML decodes a YAML value from the given decoder into a syntax node. See UnmarshalYAML for mode details on the
 // decoding process.
 func DecodeYAML(filename string, d *yaml.Decoder, tags TagDecoder) (syntax.Node, syntax.Diagnostics) {
-	v := yamlValue{filename: filename, tags: tags}
+	v := yamlValue{filename: filename, positions: positionIndex{anchors: map[*yaml.Node]syntax.Node{}}, tags: tags}
 	if err := d.Decode(&v); err != nil {
 		if errors.Is(err, io.EOF) {
 			return &syntax.ObjectNode{}, v.diags
@@ -15,6 +15,8 @@
 package syntax
 
 import (
+	"strings"
+
 	"github.com/hashicorp/hcl/v2"
 )
 
@@ -75,6 +77,25 @@ func (s triviaSyntax) FootComment() string {
 	return s.footComment
 }
 
+// HeadCommentText returns the text of the comment that precedes the given syntax, if any. The comment marker and a
+// single following space are removed from each line of the comment.
+func HeadCommentText(s Syntax) string {
+	trivia, ok := s.(Trivia)
+	if !ok {
+		return ""
+	}
+	comment := trivia.HeadComment()
+	if comment == "" {
+		return ""
+	}
+
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimPrefix(line, "#"), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
 func CopyTrivia(s Syntax) Syntax {
 	trivia, ok := s.(Trivia)
 	if !ok {
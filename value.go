@@ -15,9 +15,11 @@
 package esc
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"sort"
 	"strconv"
 	"strings"
@@ -196,6 +198,112 @@ func (v Value) ToJSON(redact bool) any {
 	}
 }
 
+// EncodeJSON writes the JSON representation of this value to w. The output is identical to that of a json.Encoder with
+// the given indent encoding the result of ToJSON, but is written incrementally as the value is walked rather than
+// first building the entire JSON representation in memory. If redact is true, secrets are replaced with [secret].
+func (v Value) EncodeJSON(w io.Writer, redact bool, indent string) error {
+	enc := jsonStreamer{w: bufio.NewWriter(w), redact: redact, indent: indent}
+	enc.value(v, 0)
+	enc.writeString("\n")
+	if enc.err != nil {
+		return enc.err
+	}
+	return enc.w.Flush()
+}
+
+// jsonStreamer writes the JSON representation of a Value. The first error encountered is recorded in err, after which
+// all writes are ignored.
+type jsonStreamer struct {
+	w      *bufio.Writer
+	redact bool
+	indent string
+	err    error
+}
+
+func (s *jsonStreamer) writeString(str string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(str)
+	}
+}
+
+func (s *jsonStreamer) newline(depth int) {
+	if s.indent == "" {
+		return
+	}
+	s.writeString("\n")
+	for i := 0; i < depth; i++ {
+		s.writeString(s.indent)
+	}
+}
+
+func (s *jsonStreamer) scalar(v any) {
+	if s.err != nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		s.err = err
+		return
+	}
+	_, s.err = s.w.Write(b)
+}
+
+func (s *jsonStreamer) value(v Value, depth int) {
+	if v.Secret && s.redact {
+		s.scalar("[secret]")
+		return
+	}
+	if v.Unknown {
+		s.scalar("[unknown]")
+		return
+	}
+
+	separator := ":"
+	if s.indent != "" {
+		separator = ": "
+	}
+
+	switch pv := v.Value.(type) {
+	case []Value:
+		if len(pv) == 0 {
+			s.writeString("[]")
+			return
+		}
+		s.writeString("[")
+		for i, e := range pv {
+			if i > 0 {
+				s.writeString(",")
+			}
+			s.newline(depth + 1)
+			s.value(e, depth+1)
+		}
+		s.newline(depth)
+		s.writeString("]")
+	case map[string]Value:
+		if len(pv) == 0 {
+			s.writeString("{}")
+			return
+		}
+		keys := maps.Keys(pv)
+		sort.Strings(keys)
+
+		s.writeString("{")
+		for i, k := range keys {
+			if i > 0 {
+				s.writeString(",")
+			}
+			s.newline(depth + 1)
+			s.scalar(k)
+			s.writeString(separator)
+			s.value(pv[k], depth+1)
+		}
+		s.newline(depth)
+		s.writeString("}")
+	default:
+		s.scalar(pv)
+	}
+}
+
 // ToString returns the string representation of this value. If redact is true, secrets are replaced with [secret].
 func (v Value) ToString(redact bool) string {
 	if v.Secret && redact {
@@ -235,6 +343,62 @@ func (v Value) ToString(redact bool) string {
 	}
 }
 
+// ValueStats describes the size and complexity of a value.
+type ValueStats struct {
+	// Leaves is the number of scalar (i.e. non-array, non-object) values within the value, including unknown values.
+	Leaves int `json:"leaves"`
+
+	// SecretLeaves is the number of leaves that are secret, either directly or because an enclosing value is secret.
+	SecretLeaves int `json:"secretLeaves"`
+
+	// MaxDepth is the greatest number of arrays and objects that enclose any value within the value. The depth of a
+	// scalar value is 0.
+	MaxDepth int `json:"maxDepth"`
+
+	// Size is the size in bytes of the value's JSON serialization, including the plaintext of any secrets.
+	Size int `json:"size"`
+}
+
+// Stats returns size and complexity metrics for this value.
+func (v Value) Stats() ValueStats {
+	var stats ValueStats
+	v.stats(&stats, 0, false)
+
+	bytes, err := json.Marshal(v.ToJSON(false))
+	if err == nil {
+		stats.Size = len(bytes)
+	}
+	return stats
+}
+
+func (v Value) stats(stats *ValueStats, depth int, secret bool) {
+	secret = secret || v.Secret
+
+	var elements []Value
+	container := false
+	switch pv := v.Value.(type) {
+	case []Value:
+		elements, container = pv, true
+	case map[string]Value:
+		elements, container = maps.Values(pv), true
+	}
+	if v.Unknown || !container {
+		stats.Leaves++
+		if secret {
+			stats.SecretLeaves++
+		}
+		return
+	}
+
+	depth++
+	if depth > stats.MaxDepth {
+		stats.MaxDepth = depth
+	}
+	for _, e := range elements {
+		e.stats(stats, depth, secret)
+	}
+}
+
 // String is shorthand for ToString(true).
 func (v Value) String() string {
 	return v.ToString(true)
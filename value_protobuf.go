@@ -0,0 +1,171 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package esc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pulumi/esc/internal/util"
+	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ProtobufSecrets controls the representation of secret values in protobuf values.
+type ProtobufSecrets int
+
+const (
+	// ProtobufSecretsRedact replaces secret values with "[secret]".
+	ProtobufSecretsRedact ProtobufSecrets = iota
+	// ProtobufSecretsFlag wraps secret values in a single-key object of the form {"fn::secret": value}.
+	ProtobufSecretsFlag
+	// ProtobufSecretsReveal represents secret values as plain values.
+	ProtobufSecretsReveal
+)
+
+// ToProtobuf converts a Value into a google.protobuf.Value. Unknown values are represented as "[unknown]", and secret
+// values are represented according to secrets.
+//
+// Numbers are converted to doubles. Numbers that cannot be represented exactly by a double (e.g. integers larger than
+// 2^53) lose precision.
+func (v Value) ToProtobuf(secrets ProtobufSecrets) (*structpb.Value, error) {
+	return v.toProtobuf("", secrets)
+}
+
+func (v Value) toProtobuf(path string, secrets ProtobufSecrets) (*structpb.Value, error) {
+	if v.Secret {
+		switch secrets {
+		case ProtobufSecretsRedact:
+			return structpb.NewStringValue("[secret]"), nil
+		case ProtobufSecretsFlag:
+			plaintext := v
+			plaintext.Secret = false
+			pv, err := plaintext.toProtobuf(path, secrets)
+			if err != nil {
+				return nil, err
+			}
+			return structpb.NewStructValue(&structpb.Struct{Fields: map[string]*structpb.Value{"fn::secret": pv}}), nil
+		}
+	}
+	if v.Unknown {
+		return structpb.NewStringValue("[unknown]"), nil
+	}
+
+	switch pv := v.Value.(type) {
+	case nil:
+		return structpb.NewNullValue(), nil
+	case bool:
+		return structpb.NewBoolValue(pv), nil
+	case json.Number:
+		f, err := pv.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("%v: invalid number %q: %w", path, pv, err)
+		}
+		return structpb.NewNumberValue(f), nil
+	case string:
+		return structpb.NewStringValue(pv), nil
+	case []Value:
+		values := make([]*structpb.Value, len(pv))
+		for i, v := range pv {
+			ev, err := v.toProtobuf(fmt.Sprintf("%v[%v]", path, i), secrets)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = ev
+		}
+		return structpb.NewListValue(&structpb.ListValue{Values: values}), nil
+	case map[string]Value:
+		s, err := toProtobufStruct(path, pv, secrets)
+		if err != nil {
+			return nil, err
+		}
+		return structpb.NewStructValue(s), nil
+	default:
+		return nil, fmt.Errorf("%v: unsupported value of type %T", path, pv)
+	}
+}
+
+func toProtobufStruct(path string, m map[string]Value, secrets ProtobufSecrets) (*structpb.Struct, error) {
+	fields := make(map[string]*structpb.Value, len(m))
+	for k, v := range m {
+		fv, err := v.toProtobuf(util.JoinKey(path, k), secrets)
+		if err != nil {
+			return nil, err
+		}
+		fields[k] = fv
+	}
+	return &structpb.Struct{Fields: fields}, nil
+}
+
+// ToProtobuf converts the environment's properties into a google.protobuf.Struct. See Value.ToProtobuf for details.
+func (e *Environment) ToProtobuf(secrets ProtobufSecrets) (*structpb.Struct, error) {
+	return toProtobufStruct("", e.Properties, secrets)
+}
+
+// FromProtobuf converts a google.protobuf.Value into a Value. Single-key objects of the form {"fn::secret": value} are
+// converted to secret values, which allows values produced by ToProtobuf with ProtobufSecretsFlag to round-trip.
+func FromProtobuf(v *structpb.Value) (Value, error) {
+	return fromProtobuf("", v)
+}
+
+func fromProtobuf(path string, v *structpb.Value) (Value, error) {
+	switch kind := v.GetKind().(type) {
+	case nil, *structpb.Value_NullValue:
+		return Value{}, nil
+	case *structpb.Value_BoolValue:
+		return NewValue(kind.BoolValue), nil
+	case *structpb.Value_NumberValue:
+		return NewValue(json.Number(strconv.FormatFloat(kind.NumberValue, 'g', -1, 64))), nil
+	case *structpb.Value_StringValue:
+		return NewValue(kind.StringValue), nil
+	case *structpb.Value_ListValue:
+		elements := kind.ListValue.GetValues()
+		vs := make([]Value, len(elements))
+		for i, e := range elements {
+			ev, err := fromProtobuf(fmt.Sprintf("%v[%v]", path, i), e)
+			if err != nil {
+				return Value{}, err
+			}
+			vs[i] = ev
+		}
+		return NewValue(vs), nil
+	case *structpb.Value_StructValue:
+		fields := kind.StructValue.GetFields()
+		if plaintext, ok := fields["fn::secret"]; ok && len(fields) == 1 {
+			pv, err := fromProtobuf(path, plaintext)
+			if err != nil {
+				return Value{}, err
+			}
+			pv.Secret = true
+			return pv, nil
+		}
+
+		keys := maps.Keys(fields)
+		sort.Strings(keys)
+		vs := make(map[string]Value, len(keys))
+		for _, k := range keys {
+			fv, err := fromProtobuf(util.JoinKey(path, k), fields[k])
+			if err != nil {
+				return Value{}, err
+			}
+			vs[k] = fv
+		}
+		return NewValue(vs), nil
+	default:
+		return Value{}, fmt.Errorf("%v: unsupported protobuf value of type %T", path, kind)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package esc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProtobufRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		json any
+	}{
+		{name: "null", json: nil},
+		{name: "bool", json: true},
+		{name: "integer", json: 42.0},
+		{name: "float", json: 0.25},
+		{name: "string", json: "hello"},
+		{name: "array", json: []any{1.0, "two", false, nil}},
+		{name: "object", json: map[string]any{
+			"region": "us-west-2",
+			"ports":  []any{80.0, 443.0},
+			"tls":    map[string]any{"enabled": true, "cert": nil},
+		}},
+		{name: "secret", json: map[string]any{
+			"user":     "admin",
+			"password": map[string]any{"fn::secret": "hunter2"},
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			expected, err := structpb.NewValue(c.json)
+			require.NoError(t, err)
+
+			v, err := FromProtobuf(expected)
+			require.NoError(t, err)
+
+			actual, err := v.ToProtobuf(ProtobufSecretsFlag)
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(expected, actual), "expected %v, got %v", expected, actual)
+		})
+	}
+}
+
+func TestToProtobuf(t *testing.T) {
+	v := NewValue(map[string]Value{
+		"count":    NewValue(json.Number("3")),
+		"password": NewSecret("hunter2"),
+		"pending":  {Unknown: true},
+	})
+
+	cases := []struct {
+		secrets  ProtobufSecrets
+		expected map[string]any
+	}{
+		{
+			secrets:  ProtobufSecretsRedact,
+			expected: map[string]any{"count": 3.0, "password": "[secret]", "pending": "[unknown]"},
+		},
+		{
+			secrets: ProtobufSecretsFlag,
+			expected: map[string]any{
+				"count":    3.0,
+				"password": map[string]any{"fn::secret": "hunter2"},
+				"pending":  "[unknown]",
+			},
+		},
+		{
+			secrets:  ProtobufSecretsReveal,
+			expected: map[string]any{"count": 3.0, "password": "hunter2", "pending": "[unknown]"},
+		},
+	}
+	for _, c := range cases {
+		actual, err := v.ToProtobuf(c.secrets)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, actual.AsInterface())
+	}
+
+	env := Environment{Properties: map[string]Value{"password": NewSecret("hunter2")}}
+	s, err := env.ToProtobuf(ProtobufSecretsRedact)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"password": "[secret]"}, s.AsMap())
+
+	_, err = NewValue(json.Number("nope")).ToProtobuf(ProtobufSecretsReveal)
+	assert.Error(t, err)
+}
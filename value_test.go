@@ -0,0 +1,89 @@
+// Copyright 2024, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package esc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueStats(t *testing.T) {
+	v := NewValue(map[string]Value{
+		"region": NewValue("us-west-2"),
+		"aws": NewValue(map[string]Value{
+			"creds": NewSecret(map[string]Value{
+				"accessKeyId":     NewValue("AKIA"),
+				"secretAccessKey": NewValue("shh"),
+			}),
+			"token": NewSecret("hunter2"),
+		}),
+		"ports":   NewValue([]Value{NewValue(json.Number("80")), NewValue(json.Number("443"))}),
+		"empty":   NewValue([]Value{}),
+		"pending": {Unknown: true},
+	})
+
+	assert.Equal(t, ValueStats{
+		Leaves:       7,
+		SecretLeaves: 3,
+		MaxDepth:     3,
+		Size: len(`{"aws":{"creds":{"accessKeyId":"AKIA","secretAccessKey":"shh"},"token":"hunter2"},` +
+			`"empty":[],"pending":"[unknown]","ports":[80,443],"region":"us-west-2"}`),
+	}, v.Stats())
+
+	assert.Equal(t, ValueStats{Leaves: 1, Size: 4}, NewValue(true).Stats())
+}
+
+func TestEncodeJSON(t *testing.T) {
+	services := make([]Value, 500)
+	for i := range services {
+		services[i] = NewValue(map[string]Value{
+			"name":    NewValue(fmt.Sprintf("service-<%d>&", i)),
+			"port":    NewValue(json.Number(fmt.Sprint(8000 + i))),
+			"enabled": NewValue(i%2 == 0),
+			"token":   NewSecret(fmt.Sprintf("token-%d", i)),
+			"tags":    NewValue([]Value{NewValue("a"), NewValue("\u00e9\n\"quoted\"")}),
+			"owner":   {},
+			"pending": {Unknown: true},
+			"empty":   NewValue(map[string]Value{}),
+			"none":    NewValue([]Value{}),
+		})
+	}
+	v := NewValue(map[string]Value{
+		"services": NewValue(services),
+		"creds": NewSecret(map[string]Value{
+			"password": NewValue("hunter2"),
+		}),
+	})
+
+	for _, indent := range []string{"", "  ", "\t"} {
+		for _, redact := range []bool{false, true} {
+			t.Run(fmt.Sprintf("indent=%q,redact=%v", indent, redact), func(t *testing.T) {
+				var expected bytes.Buffer
+				enc := json.NewEncoder(&expected)
+				enc.SetIndent("", indent)
+				require.NoError(t, enc.Encode(v.ToJSON(redact)))
+
+				var actual bytes.Buffer
+				require.NoError(t, v.EncodeJSON(&actual, redact, indent))
+				assert.Equal(t, expected.String(), actual.String())
+			})
+		}
+	}
+}